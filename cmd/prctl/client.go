@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+)
+
+// Client is an admin backend of prctl, either the HTTP API of a running server or the database itself.
+type Client interface {
+	AddTeam(ctx context.Context, team *dto.Team) (*dto.Team, error)
+	GetTeam(ctx context.Context, teamName string) (*dto.Team, error)
+	GetTeamStats(ctx context.Context, req *dto.TeamStatsPrRequest) (*dto.TeamStatsPrResponse, error)
+
+	SetIsActive(ctx context.Context, req *dto.SetIsActiveRequest) (*dto.SetIsActiveResponse, error)
+	MassDeactivation(ctx context.Context, req *dto.MassDeactivationRequest) (*dto.MassDeactivationResponse, error)
+
+	CreatePr(ctx context.Context, req *dto.PrCreateRequest) (*dto.PullRequest, error)
+	MergePr(ctx context.Context, req *dto.MergeRequest) (*dto.MergeResponse, error)
+	ReassignPr(ctx context.Context, req *dto.ReassignRequest) (*dto.ReassignResponse, error)
+	ListPr(ctx context.Context, req *dto.PrListRequest) (*dto.PrListResponse, error)
+
+	GetTask(ctx context.Context, taskId string) (*dto.TaskResponse, error)
+
+	Close()
+}
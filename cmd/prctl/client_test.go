@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/handlers"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpClient_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   *apiError
+	}{
+		{
+			name:   "error envelope",
+			status: http.StatusConflict,
+			body:   `{"error":{"code":"PR_MERGED","message":"cannot reassign on merged PR"}}`,
+			want:   &apiError{Status: http.StatusConflict, Code: handlers.ErrStatusPrMerged, Message: "cannot reassign on merged PR"},
+		},
+		{
+			name:   "not an envelope",
+			status: http.StatusBadGateway,
+			body:   "upstream is down\n",
+			want:   &apiError{Status: http.StatusBadGateway, Code: "Bad Gateway", Message: "upstream is down"},
+		},
+		{
+			name:   "envelope without an error",
+			status: http.StatusInternalServerError,
+			body:   `{"status":"failed"}`,
+			want:   &apiError{Status: http.StatusInternalServerError, Code: "Internal Server Error", Message: `{"status":"failed"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := newHttpClient(server.URL, "", time.Second)
+			_, err := client.ReassignPr(context.Background(), &dto.ReassignRequest{PrId: "pr-1", OldReviewerId: "u1"})
+
+			var apiErr *apiError
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.want, apiErr)
+		})
+	}
+}
+
+func TestHttpClient_Request(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`{"pr":{"pull_request_id":"pr-1","status":"MERGED"}}`))
+	}))
+	defer server.Close()
+
+	//The trailing slash of the server address is dropped
+	client := newHttpClient(server.URL+"/", "secret", time.Second)
+	resp, err := client.MergePr(context.Background(), &dto.MergeRequest{PrId: "pr-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "pr-1", resp.PrId)
+	assert.Equal(t, "MERGED", resp.Status)
+
+	require.NotNil(t, got)
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "/pullRequest/merge", got.URL.Path)
+	assert.Equal(t, "Bearer secret", got.Header.Get("Authorization"))
+	assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+}
+
+func TestDbClient_Check(t *testing.T) {
+	//Services are not set, a request passing the validation would panic
+	client := &dbClient{validate: validator.New()}
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "team add without members", call: func() error {
+			_, err := client.AddTeam(ctx, &dto.Team{TeamName: "backend"})
+			return err
+		}},
+		{name: "team stats without a name", call: func() error {
+			_, err := client.GetTeamStats(ctx, &dto.TeamStatsPrRequest{})
+			return err
+		}},
+		{name: "set-active without a state", call: func() error {
+			_, err := client.SetIsActive(ctx, &dto.SetIsActiveRequest{UserId: "u1"})
+			return err
+		}},
+		{name: "deactivate without ids", call: func() error {
+			_, err := client.MassDeactivation(ctx, &dto.MassDeactivationRequest{})
+			return err
+		}},
+		{name: "pr create without an author", call: func() error {
+			_, err := client.CreatePr(ctx, &dto.PrCreateRequest{PrId: "pr-1", PrName: "feature"})
+			return err
+		}},
+		{name: "pr merge with a long id", call: func() error {
+			_, err := client.MergePr(ctx, &dto.MergeRequest{PrId: "pr-0123456789012345678901234567890"})
+			return err
+		}},
+		{name: "pr reassign without a reviewer", call: func() error {
+			_, err := client.ReassignPr(ctx, &dto.ReassignRequest{PrId: "pr-1"})
+			return err
+		}},
+		{name: "pr list with a bad limit", call: func() error {
+			_, err := client.ListPr(ctx, &dto.PrListRequest{Limit: 1000})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+
+			var apiErr *apiError
+			require.True(t, errors.As(err, &apiErr), "got %v", err)
+			assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+			assert.Equal(t, handlers.ErrStatusBadRequest, apiErr.Code)
+			assert.NotEmpty(t, apiErr.Message)
+		})
+	}
+
+	t.Run("arguments outside of a request", func(t *testing.T) {
+		_, err := client.GetTeam(ctx, "")
+		assert.EqualError(t, err, "team name is required")
+
+		_, err = client.GetTask(ctx, "not-a-uuid")
+		assert.EqualError(t, err, "invalid task id")
+	})
+}
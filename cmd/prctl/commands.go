@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+)
+
+// action runs a parsed subcommand, args are the positional arguments left after the flags
+type action func(ctx context.Context, client Client, args []string) (any, error)
+
+// command declares the flags of a subcommand on the flag set and returns its action
+type command func(fs *flag.FlagSet) action
+
+var commands = map[string]command{
+	"team add":        teamAdd,
+	"team get":        teamGet,
+	"team stats":      teamStats,
+	"user set-active": userSetActive,
+	"user deactivate": userDeactivate,
+	"pr create":       prCreate,
+	"pr merge":        prMerge,
+	"pr reassign":     prReassign,
+	"pr list":         prList,
+	"tasks status":    tasksStatus,
+}
+
+func teamAdd(fs *flag.FlagSet) action {
+	var members listFlag
+	name := fs.String("name", "", "team name")
+	fs.Var(&members, "member", "member as user_id:username, repeatable")
+	minReviewers := optionalInt(fs, "min-reviewers", "minimum reviewers of a PR")
+	maxReviewers := optionalInt(fs, "max-reviewers", "maximum reviewers of a PR")
+	requiredApprovals := optionalInt(fs, "required-approvals", "approvals needed to merge a PR")
+
+	return func(ctx context.Context, client Client, _ []string) (any, error) {
+		team := &dto.Team{
+			TeamName:          *name,
+			MinReviewers:      minReviewers.value,
+			MaxReviewers:      maxReviewers.value,
+			RequiredApprovals: requiredApprovals.value,
+		}
+		for _, member := range members {
+			userId, username, ok := strings.Cut(member, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid member %q, expected user_id:username", member)
+			}
+			team.Members = append(team.Members, dto.Members{
+				UserId:   userId,
+				Username: username,
+				IsActive: true,
+			})
+		}
+		return client.AddTeam(ctx, team)
+	}
+}
+
+func teamGet(fs *flag.FlagSet) action {
+	name := fs.String("name", "", "team name")
+
+	return func(ctx context.Context, client Client, _ []string) (any, error) {
+		return client.GetTeam(ctx, *name)
+	}
+}
+
+func teamStats(fs *flag.FlagSet) action {
+	var from, to timeFlag
+	name := fs.String("name", "", "team name")
+	fs.Var(&from, "from", "start of the window, RFC 3339")
+	fs.Var(&to, "to", "end of the window (exclusive), RFC 3339")
+
+	return func(ctx context.Context, client Client, _ []string) (any, error) {
+		return client.GetTeamStats(ctx, &dto.TeamStatsPrRequest{
+			TeamName: *name,
+			From:     from.value,
+			To:       to.value,
+		})
+	}
+}
+
+func userSetActive(fs *flag.FlagSet) action {
+	userId := fs.String("id", "", "user id")
+	isActive := fs.Bool("active", true, "new state of the user")
+	reassign := fs.Bool("reassign", false, "reassign open reviews of a deactivated user")
+
+	return func(ctx context.Context, client Client, _ []string) (any, error) {
+		return client.SetIsActive(ctx, &dto.SetIsActiveRequest{
+			UserId:   *userId,
+			IsActive: isActive,
+			Reassign: *reassign,
+		})
+	}
+}
+
+func userDeactivate(fs *flag.FlagSet) action {
+	reassign := fs.Bool("reassign", false, "reassign open reviews of the deactivated users")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] <user_id>...\n", fs.Name())
+		fs.PrintDefaults()
+	}
+
+	return func(ctx context.Context, client Client, args []string) (any, error) {
+		if len(args) == 0 {
+			return nil, errors.New("at least one user id is required")
+		}
+		return client.MassDeactivation(ctx, &dto.MassDeactivationRequest{
+			UsersId:  args,
+			Reassign: *reassign,
+		})
+	}
+}
+
+func prCreate(fs *flag.FlagSet) action {
+	var files listFlag
+	prId := fs.String("id", "", "pull request id")
+	name := fs.String("name", "", "pull request name")
+	authorId := fs.String("author", "", "author id")
+	draft := fs.Bool("draft", false, "create a draft without reviewers")
+	fs.Var(&files, "file", "changed file path for the code owner rules, repeatable")
+
+	return func(ctx context.Context, client Client, _ []string) (any, error) {
+		return client.CreatePr(ctx, &dto.PrCreateRequest{
+			PrId:         *prId,
+			PrName:       *name,
+			AuthorId:     *authorId,
+			Draft:        *draft,
+			ChangedFiles: files,
+		})
+	}
+}
+
+func prMerge(fs *flag.FlagSet) action {
+	prId := fs.String("id", "", "pull request id")
+	force := fs.Bool("force", false, "merge without the required approvals, admin only")
+
+	return func(ctx context.Context, client Client, _ []string) (any, error) {
+		return client.MergePr(ctx, &dto.MergeRequest{
+			PrId:  *prId,
+			Force: *force,
+		})
+	}
+}
+
+func prReassign(fs *flag.FlagSet) action {
+	prId := fs.String("id", "", "pull request id")
+	oldReviewerId := fs.String("old-reviewer", "", "id of the replaced reviewer")
+
+	return func(ctx context.Context, client Client, _ []string) (any, error) {
+		return client.ReassignPr(ctx, &dto.ReassignRequest{
+			PrId:          *prId,
+			OldReviewerId: *oldReviewerId,
+		})
+	}
+}
+
+func prList(fs *flag.FlagSet) action {
+	var createdFrom, createdTo timeFlag
+	teamName := fs.String("team", "", "team of the author")
+	authorId := fs.String("author", "", "author id")
+	reviewerId := fs.String("reviewer", "", "assigned reviewer id")
+	status := fs.String("status", "", "DRAFT, OPEN, MERGED or CLOSED")
+	fs.Var(&createdFrom, "created-from", "created at or after, RFC 3339")
+	fs.Var(&createdTo, "created-to", "created before, RFC 3339")
+	limit := fs.Int("limit", 0, "page size, 1-100")
+	cursor := fs.String("cursor", "", "next_cursor of the previous page")
+
+	return func(ctx context.Context, client Client, _ []string) (any, error) {
+		return client.ListPr(ctx, &dto.PrListRequest{
+			TeamName:    *teamName,
+			AuthorId:    *authorId,
+			ReviewerId:  *reviewerId,
+			Status:      strings.ToUpper(*status),
+			CreatedFrom: createdFrom.value,
+			CreatedTo:   createdTo.value,
+			Limit:       *limit,
+			Cursor:      *cursor,
+		})
+	}
+}
+
+func tasksStatus(fs *flag.FlagSet) action {
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s <task_id>\n", fs.Name())
+	}
+
+	return func(ctx context.Context, client Client, args []string) (any, error) {
+		if len(args) != 1 {
+			return nil, errors.New("exactly one task id is required")
+		}
+		return client.GetTask(ctx, args[0])
+	}
+}
+
+// listFlag collects the values of a repeated flag
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// timeFlag is an optional RFC 3339 timestamp
+type timeFlag struct {
+	value *time.Time
+}
+
+func (f *timeFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return f.value.Format(time.RFC3339)
+}
+
+func (f *timeFlag) Set(value string) error {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return errors.New("expected RFC 3339, e.g. 2025-01-31T00:00:00Z")
+	}
+	f.value = &t
+	return nil
+}
+
+// intFlag is an optional integer, nil keeps the server default
+type intFlag struct {
+	value *int
+}
+
+func optionalInt(fs *flag.FlagSet, name string, usage string) *intFlag {
+	f := &intFlag{}
+	fs.Var(f, name, usage)
+	return f
+}
+
+func (f *intFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return strconv.Itoa(*f.value)
+}
+
+func (f *intFlag) Set(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.New("expected an integer")
+	}
+	f.value = &n
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordClient keeps the last request instead of sending it
+type recordClient struct {
+	request any
+}
+
+func (c *recordClient) AddTeam(_ context.Context, team *dto.Team) (*dto.Team, error) {
+	c.request = team
+	return team, nil
+}
+
+func (c *recordClient) GetTeam(_ context.Context, teamName string) (*dto.Team, error) {
+	c.request = teamName
+	return &dto.Team{TeamName: teamName}, nil
+}
+
+func (c *recordClient) GetTeamStats(_ context.Context, req *dto.TeamStatsPrRequest) (*dto.TeamStatsPrResponse, error) {
+	c.request = req
+	return &dto.TeamStatsPrResponse{}, nil
+}
+
+func (c *recordClient) SetIsActive(_ context.Context, req *dto.SetIsActiveRequest) (*dto.SetIsActiveResponse, error) {
+	c.request = req
+	return &dto.SetIsActiveResponse{}, nil
+}
+
+func (c *recordClient) MassDeactivation(_ context.Context, req *dto.MassDeactivationRequest) (*dto.MassDeactivationResponse, error) {
+	c.request = req
+	return &dto.MassDeactivationResponse{}, nil
+}
+
+func (c *recordClient) CreatePr(_ context.Context, req *dto.PrCreateRequest) (*dto.PullRequest, error) {
+	c.request = req
+	return &dto.PullRequest{}, nil
+}
+
+func (c *recordClient) MergePr(_ context.Context, req *dto.MergeRequest) (*dto.MergeResponse, error) {
+	c.request = req
+	return &dto.MergeResponse{}, nil
+}
+
+func (c *recordClient) ReassignPr(_ context.Context, req *dto.ReassignRequest) (*dto.ReassignResponse, error) {
+	c.request = req
+	return &dto.ReassignResponse{}, nil
+}
+
+func (c *recordClient) ListPr(_ context.Context, req *dto.PrListRequest) (*dto.PrListResponse, error) {
+	c.request = req
+	return &dto.PrListResponse{}, nil
+}
+
+func (c *recordClient) GetTask(_ context.Context, taskId string) (*dto.TaskResponse, error) {
+	c.request = taskId
+	return &dto.TaskResponse{}, nil
+}
+
+func (c *recordClient) Close() {}
+
+func TestCommands(t *testing.T) {
+	two := 2
+	active := false
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		want    any
+		wantErr string
+	}{
+		{
+			name:    "team add",
+			command: "team add",
+			args:    []string{"-name", "backend", "-member", "u1:alice", "-member", "u2:bob", "-max-reviewers", "2"},
+			want: &dto.Team{
+				TeamName:     "backend",
+				Members:      []dto.Members{{UserId: "u1", Username: "alice", IsActive: true}, {UserId: "u2", Username: "bob", IsActive: true}},
+				MaxReviewers: &two,
+			},
+		},
+		{
+			name:    "team add with an invalid member",
+			command: "team add",
+			args:    []string{"-name", "backend", "-member", "alice"},
+			wantErr: `invalid member "alice", expected user_id:username`,
+		},
+		{
+			name:    "team stats",
+			command: "team stats",
+			args:    []string{"-name", "backend", "-from", "2025-01-01T00:00:00Z"},
+			want:    &dto.TeamStatsPrRequest{TeamName: "backend", From: &from},
+		},
+		{
+			name:    "user set-active",
+			command: "user set-active",
+			args:    []string{"-id", "u1", "-active=false", "-reassign"},
+			want:    &dto.SetIsActiveRequest{UserId: "u1", IsActive: &active, Reassign: true},
+		},
+		{
+			name:    "user deactivate",
+			command: "user deactivate",
+			args:    []string{"-reassign", "u1", "u2"},
+			want:    &dto.MassDeactivationRequest{UsersId: []string{"u1", "u2"}, Reassign: true},
+		},
+		{
+			name:    "user deactivate without ids",
+			command: "user deactivate",
+			args:    []string{"-reassign"},
+			wantErr: "at least one user id is required",
+		},
+		{
+			name:    "pr create",
+			command: "pr create",
+			args:    []string{"-id", "pr-1", "-name", "feature", "-author", "u1", "-draft", "-file", "api/a.go", "-file", "web/b.ts"},
+			want:    &dto.PrCreateRequest{PrId: "pr-1", PrName: "feature", AuthorId: "u1", Draft: true, ChangedFiles: []string{"api/a.go", "web/b.ts"}},
+		},
+		{
+			name:    "pr list",
+			command: "pr list",
+			args:    []string{"-team", "backend", "-status", "open", "-limit", "10"},
+			want:    &dto.PrListRequest{TeamName: "backend", Status: "OPEN", Limit: 10},
+		},
+		{
+			name:    "tasks status",
+			command: "tasks status",
+			args:    []string{"b6a4e4c8-4d4b-4a8e-9f7a-3c1f0e2d5a61"},
+			want:    "b6a4e4c8-4d4b-4a8e-9f7a-3c1f0e2d5a61",
+		},
+		{
+			name:    "tasks status with several ids",
+			command: "tasks status",
+			args:    []string{"t1", "t2"},
+			wantErr: "exactly one task id is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet(tt.command, flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			call := commands[tt.command](fs)
+			require.NoError(t, fs.Parse(tt.args))
+
+			client := &recordClient{}
+			_, err := call(context.Background(), client, fs.Args())
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, client.request, "nothing is sent")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, client.request)
+		})
+	}
+}
+
+func TestFlags(t *testing.T) {
+	var at timeFlag
+	assert.EqualError(t, at.Set("2025-01-01"), "expected RFC 3339, e.g. 2025-01-31T00:00:00Z")
+	assert.Nil(t, at.value)
+	require.NoError(t, at.Set("2025-01-31T10:00:00+03:00"))
+	assert.True(t, at.value.Equal(time.Date(2025, 1, 31, 7, 0, 0, 0, time.UTC)))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	limit := optionalInt(fs, "limit", "")
+	assert.Nil(t, limit.value, "not set keeps the server default")
+	assert.EqualError(t, limit.Set("ten"), "expected an integer")
+	require.NoError(t, limit.Set("0"))
+	require.NotNil(t, limit.value)
+	assert.Equal(t, 0, *limit.value)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/Estriper0/avito_intership/internal/handlers"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	"github.com/Estriper0/avito_intership/internal/service"
+	"github.com/Estriper0/avito_intership/pkg/postgres"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// dbClient works on the database through the service layer, for break-glass operations when the API is down.
+// Migrations are not applied, the schema must already be migrated by the server.
+// Events still go to the outbox and are delivered once the server workers are running.
+type dbClient struct {
+	db          *pgxpool.Pool
+	validate    *validator.Validate
+	teamService *service.TeamService
+	userService *service.UserService
+	prService   *service.PullRequestService
+	jobService  *service.JobService
+}
+
+func newDbClient(config *config.Config, logger *slog.Logger) (*dbClient, error) {
+	dbPool, err := postgres.New(config.DB.Url, config.DB.PoolSize)
+	if err != nil {
+		return nil, err
+	}
+
+	trManager := manager.Must(trmpgx.NewDefaultFactory(dbPool))
+
+	teamRepo := db.NewTeamRepo(dbPool, trmpgx.DefaultCtxGetter)
+	userRepo := db.NewUserRepo(dbPool, trmpgx.DefaultCtxGetter)
+	prRepo := db.NewPullRequestRepo(dbPool, trmpgx.DefaultCtxGetter)
+	jobRepo := db.NewJobRepo(dbPool, trmpgx.DefaultCtxGetter)
+	eventRepo := db.NewPrEventRepo(dbPool, trmpgx.DefaultCtxGetter)
+	webhookRepo := db.NewWebhookRepo(dbPool, trmpgx.DefaultCtxGetter)
+
+	selectors, err := service.NewReviewerSelectors(config.Review, userRepo)
+	if err != nil {
+		dbPool.Close()
+		return nil, err
+	}
+
+	outbox := service.NewOutbox(webhookRepo, jobRepo, config.Worker.MaxAttempts, logger)
+	prService := service.NewPullRequestService(prRepo, userRepo, teamRepo, eventRepo, selectors, outbox, trManager, logger)
+
+	return &dbClient{
+		db:          dbPool,
+		validate:    validator.New(),
+		teamService: service.NewTeamService(teamRepo, userRepo, prRepo, prService, outbox, trManager, logger),
+		userService: service.NewUserService(userRepo, teamRepo, prRepo, prService, outbox, trManager, logger),
+		prService:   prService,
+		jobService:  service.NewJobService(jobRepo, teamRepo, userRepo, prService, config.Worker.MaxAttempts, logger),
+	}, nil
+}
+
+// Direct access is trusted as an admin, the PR history records the changes as made by "admin"
+func (c *dbClient) context(ctx context.Context) context.Context {
+	return auth.WithPrincipal(ctx, &auth.Principal{Role: auth.RoleAdmin})
+}
+
+// Requests are validated the same way as by the handlers
+func (c *dbClient) check(req any) error {
+	if err := c.validate.Struct(req); err != nil {
+		return &apiError{Status: http.StatusBadRequest, Code: handlers.ErrStatusBadRequest, Message: err.Error()}
+	}
+	return nil
+}
+
+func (c *dbClient) AddTeam(ctx context.Context, team *dto.Team) (*dto.Team, error) {
+	if err := c.check(team); err != nil {
+		return nil, err
+	}
+	if _, err := c.teamService.Add(c.context(ctx), team); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+func (c *dbClient) GetTeam(ctx context.Context, teamName string) (*dto.Team, error) {
+	if teamName == "" {
+		return nil, errors.New("team name is required")
+	}
+	return c.teamService.Get(c.context(ctx), teamName)
+}
+
+func (c *dbClient) GetTeamStats(ctx context.Context, req *dto.TeamStatsPrRequest) (*dto.TeamStatsPrResponse, error) {
+	if err := c.check(req); err != nil {
+		return nil, err
+	}
+	return c.teamService.GetStatsPR(c.context(ctx), req)
+}
+
+func (c *dbClient) SetIsActive(ctx context.Context, req *dto.SetIsActiveRequest) (*dto.SetIsActiveResponse, error) {
+	if err := c.check(req); err != nil {
+		return nil, err
+	}
+	return c.userService.SetIsActive(c.context(ctx), req)
+}
+
+func (c *dbClient) MassDeactivation(ctx context.Context, req *dto.MassDeactivationRequest) (*dto.MassDeactivationResponse, error) {
+	if err := c.check(req); err != nil {
+		return nil, err
+	}
+	return c.userService.MassDeactivation(c.context(ctx), req)
+}
+
+func (c *dbClient) CreatePr(ctx context.Context, req *dto.PrCreateRequest) (*dto.PullRequest, error) {
+	if err := c.check(req); err != nil {
+		return nil, err
+	}
+	return c.prService.Create(c.context(ctx), req)
+}
+
+func (c *dbClient) MergePr(ctx context.Context, req *dto.MergeRequest) (*dto.MergeResponse, error) {
+	if err := c.check(req); err != nil {
+		return nil, err
+	}
+	return c.prService.Merge(c.context(ctx), req)
+}
+
+func (c *dbClient) ReassignPr(ctx context.Context, req *dto.ReassignRequest) (*dto.ReassignResponse, error) {
+	if err := c.check(req); err != nil {
+		return nil, err
+	}
+	return c.prService.Reassign(c.context(ctx), req)
+}
+
+func (c *dbClient) ListPr(ctx context.Context, req *dto.PrListRequest) (*dto.PrListResponse, error) {
+	if err := c.check(req); err != nil {
+		return nil, err
+	}
+	return c.prService.List(c.context(ctx), req)
+}
+
+func (c *dbClient) GetTask(ctx context.Context, taskId string) (*dto.TaskResponse, error) {
+	if err := c.validate.Var(taskId, "uuid"); err != nil {
+		return nil, errors.New("invalid task id")
+	}
+	return c.jobService.Get(c.context(ctx), taskId)
+}
+
+func (c *dbClient) Close() {
+	c.db.Close()
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+)
+
+// apiError is the standard error envelope of the API
+type apiError struct {
+	Status  int
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s (HTTP %d)", e.Code, e.Message, e.Status)
+}
+
+// httpClient calls the API of a running server, so auth, metrics and webhooks work as for any other caller
+type httpClient struct {
+	server string
+	token  string
+	client *http.Client
+}
+
+func newHttpClient(server string, token string, timeout time.Duration) *httpClient {
+	return &httpClient{
+		server: strings.TrimRight(server, "/"),
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *httpClient) do(ctx context.Context, method string, path string, query url.Values, body any, out any) error {
+	endpoint := c.server + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("http:%s %s:Marshal - %w", method, path, err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return fmt.Errorf("http:%s %s:NewRequest - %w", method, path, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http:%s %s:Do - %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("http:%s %s:ReadAll - %w", method, path, err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var envelope struct {
+			Error *apiError `json:"error"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil || envelope.Error == nil {
+			return &apiError{Status: resp.StatusCode, Code: http.StatusText(resp.StatusCode), Message: strings.TrimSpace(string(data))}
+		}
+		envelope.Error.Status = resp.StatusCode
+		return envelope.Error
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("http:%s %s:Unmarshal - %w", method, path, err)
+	}
+	return nil
+}
+
+func (c *httpClient) AddTeam(ctx context.Context, team *dto.Team) (*dto.Team, error) {
+	var resp struct {
+		Team *dto.Team `json:"team"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/team/add", nil, team, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Team, nil
+}
+
+func (c *httpClient) GetTeam(ctx context.Context, teamName string) (*dto.Team, error) {
+	var resp dto.Team
+	if err := c.do(ctx, http.MethodGet, "/team/get", url.Values{"team_name": {teamName}}, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *httpClient) GetTeamStats(ctx context.Context, req *dto.TeamStatsPrRequest) (*dto.TeamStatsPrResponse, error) {
+	query := url.Values{"team_name": {req.TeamName}}
+	setTime(query, "from", req.From)
+	setTime(query, "to", req.To)
+
+	var resp dto.TeamStatsPrResponse
+	if err := c.do(ctx, http.MethodGet, "/team/stats/pull_request", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *httpClient) SetIsActive(ctx context.Context, req *dto.SetIsActiveRequest) (*dto.SetIsActiveResponse, error) {
+	var resp dto.SetIsActiveResponse
+	if err := c.do(ctx, http.MethodPost, "/users/setIsActive", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *httpClient) MassDeactivation(ctx context.Context, req *dto.MassDeactivationRequest) (*dto.MassDeactivationResponse, error) {
+	var resp dto.MassDeactivationResponse
+	if err := c.do(ctx, http.MethodPost, "/users/massDeactivation", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *httpClient) CreatePr(ctx context.Context, req *dto.PrCreateRequest) (*dto.PullRequest, error) {
+	var resp struct {
+		PR *dto.PullRequest `json:"pr"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/pullRequest/create", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.PR, nil
+}
+
+func (c *httpClient) MergePr(ctx context.Context, req *dto.MergeRequest) (*dto.MergeResponse, error) {
+	var resp struct {
+		PR *dto.MergeResponse `json:"pr"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/pullRequest/merge", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.PR, nil
+}
+
+func (c *httpClient) ReassignPr(ctx context.Context, req *dto.ReassignRequest) (*dto.ReassignResponse, error) {
+	var resp dto.ReassignResponse
+	if err := c.do(ctx, http.MethodPost, "/pullRequest/reassign", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *httpClient) ListPr(ctx context.Context, req *dto.PrListRequest) (*dto.PrListResponse, error) {
+	query := url.Values{}
+	setString(query, "team_name", req.TeamName)
+	setString(query, "author_id", req.AuthorId)
+	setString(query, "reviewer_id", req.ReviewerId)
+	setString(query, "status", req.Status)
+	setTime(query, "created_from", req.CreatedFrom)
+	setTime(query, "created_to", req.CreatedTo)
+	setString(query, "cursor", req.Cursor)
+	if req.Limit > 0 {
+		query.Set("limit", strconv.Itoa(req.Limit))
+	}
+
+	var resp dto.PrListResponse
+	if err := c.do(ctx, http.MethodGet, "/pullRequest/list", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *httpClient) GetTask(ctx context.Context, taskId string) (*dto.TaskResponse, error) {
+	var resp dto.TaskResponse
+	if err := c.do(ctx, http.MethodGet, "/tasks/"+url.PathEscape(taskId), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *httpClient) Close() {
+	c.client.CloseIdleConnections()
+}
+
+func setString(query url.Values, key string, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setTime(query url.Values, key string, value *time.Time) {
+	if value != nil {
+		query.Set(key, value.Format(time.RFC3339))
+	}
+}
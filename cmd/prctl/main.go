@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+const (
+	ModeHTTP = "http"
+	ModeDB   = "db"
+)
+
+const usage = `prctl - admin tool of the PR reviewer service
+
+Usage:
+  prctl [global flags] <command> <subcommand> [flags]
+
+Commands:
+  team add         create a team with its members
+  team get         show a team
+  team stats       pull request statistics of a team
+  user set-active  activate or deactivate a user
+  user deactivate  deactivate several users at once
+  pr create        create a pull request
+  pr merge         merge a pull request
+  pr reassign      replace a reviewer of a pull request
+  pr list          list pull requests
+  tasks status     show a background task
+
+Run "prctl <command> <subcommand> -h" for the flags of a subcommand.
+
+Global flags:
+`
+
+// errUsage is returned for wrong arguments, the usage is already printed
+var errUsage = errors.New("invalid arguments")
+
+type options struct {
+	mode       string
+	server     string
+	token      string
+	output     string
+	configPath string
+	timeout    time.Duration
+}
+
+func main() {
+	var opts options
+	globalFlags(flag.CommandLine, &opts)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if code := exitCode(os.Stderr, run(opts, flag.Args(), os.Stdout, os.Stderr)); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// globalFlags declares the flags which go before the command
+func globalFlags(fs *flag.FlagSet, opts *options) {
+	fs.StringVar(&opts.mode, "mode", ModeHTTP, "backend: http (running server) or db (direct database access through the service layer)")
+	fs.StringVar(&opts.server, "server", envOr("PRCTL_SERVER", "http://localhost:8080"), "server address for -mode http (PRCTL_SERVER)")
+	fs.StringVar(&opts.token, "token", os.Getenv("PRCTL_TOKEN"), "bearer token for -mode http (PRCTL_TOKEN)")
+	fs.StringVar(&opts.output, "output", OutputTable, "output format: table, json or yaml")
+	fs.StringVar(&opts.configPath, "config", "configs/config.yaml", "service config for -mode db, the database is taken from the DB_* variables")
+	fs.DurationVar(&opts.timeout, "timeout", 30*time.Second, "timeout of the command")
+}
+
+// exitCode reports the error of the command and returns the exit status: 2 for wrong arguments
+// (the usage is already printed), 1 for a failed command
+func exitCode(stderr io.Writer, err error) int {
+	if err == nil {
+		return 0
+	}
+	if errors.Is(err, errUsage) {
+		return 2
+	}
+	fmt.Fprintf(stderr, "prctl: %s\n", err.Error())
+	return 1
+}
+
+// run executes the command, the result goes to stdout and the usage to stderr
+func run(opts options, args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) < 2 {
+		printUsage(stderr)
+		return errUsage
+	}
+
+	cmd, ok := commands[args[0]+" "+args[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "prctl: unknown command %q\n\n", args[0]+" "+args[1])
+		printUsage(stderr)
+		return errUsage
+	}
+
+	if opts.output != OutputTable && opts.output != OutputJSON && opts.output != OutputYAML {
+		return fmt.Errorf("unknown output format %q", opts.output)
+	}
+
+	//Arguments are checked before connecting anywhere
+	fs := flag.NewFlagSet("prctl "+args[0]+" "+args[1], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	call := cmd(fs)
+	if err := fs.Parse(args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
+	}
+
+	client, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+
+	result, err := call(ctx, client, fs.Args())
+	if err != nil {
+		return err
+	}
+	return printResult(stdout, opts.output, result)
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, usage)
+	flag.CommandLine.SetOutput(w)
+	flag.PrintDefaults()
+}
+
+func newClient(opts options) (Client, error) {
+	switch opts.mode {
+	case ModeHTTP:
+		return newHttpClient(opts.server, opts.token, opts.timeout), nil
+	case ModeDB:
+		//Same as config.New, but a missing variable is an error instead of a panic
+		var config config.Config
+		if err := cleanenv.ReadConfig(opts.configPath, &config); err != nil {
+			return nil, fmt.Errorf("config - %w", err)
+		}
+		//Logs go to stderr, stdout is left for the result
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		return newDbClient(&config, logger)
+	}
+	return nil, fmt.Errorf("unknown mode %q", opts.mode)
+}
+
+func envOr(key string, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalFlags(t *testing.T) {
+	t.Setenv("PRCTL_SERVER", "http://prctl.test")
+	t.Setenv("PRCTL_TOKEN", "env-token")
+
+	t.Run("defaults", func(t *testing.T) {
+		var opts options
+		fs := flag.NewFlagSet("prctl", flag.ContinueOnError)
+		globalFlags(fs, &opts)
+		require.NoError(t, fs.Parse([]string{"team", "get"}))
+
+		assert.Equal(t, ModeHTTP, opts.mode)
+		assert.Equal(t, "http://prctl.test", opts.server)
+		assert.Equal(t, "env-token", opts.token)
+		assert.Equal(t, OutputTable, opts.output)
+		assert.Equal(t, 30*time.Second, opts.timeout)
+		assert.Equal(t, []string{"team", "get"}, fs.Args())
+	})
+
+	t.Run("flags win over the environment", func(t *testing.T) {
+		var opts options
+		fs := flag.NewFlagSet("prctl", flag.ContinueOnError)
+		globalFlags(fs, &opts)
+		require.NoError(t, fs.Parse([]string{"-mode", "db", "-token", "flag-token", "-output", "json", "-timeout", "5s", "pr", "list"}))
+
+		assert.Equal(t, ModeDB, opts.mode)
+		assert.Equal(t, "flag-token", opts.token)
+		assert.Equal(t, OutputJSON, opts.output)
+		assert.Equal(t, 5*time.Second, opts.timeout)
+		assert.Equal(t, []string{"pr", "list"}, fs.Args())
+	})
+}
+
+func TestRun_Arguments(t *testing.T) {
+	opts := options{mode: ModeHTTP, server: "http://127.0.0.1:0", output: OutputTable, timeout: time.Second}
+
+	tests := []struct {
+		name       string
+		opts       options
+		args       []string
+		wantErr    error
+		wantErrMsg string
+		wantStderr string
+		wantCode   int
+	}{
+		{name: "no command", opts: opts, args: nil, wantErr: errUsage, wantStderr: "Usage:", wantCode: 2},
+		{name: "no subcommand", opts: opts, args: []string{"team"}, wantErr: errUsage, wantStderr: "Usage:", wantCode: 2},
+		{name: "unknown command", opts: opts, args: []string{"team", "delete"}, wantErr: errUsage, wantStderr: `unknown command "team delete"`, wantCode: 2},
+		{name: "unknown flag", opts: opts, args: []string{"team", "get", "-size", "1"}, wantErr: errUsage, wantStderr: "flag provided but not defined: -size", wantCode: 2},
+		{name: "bad time", opts: opts, args: []string{"team", "stats", "-from", "yesterday"}, wantErr: errUsage, wantStderr: "expected RFC 3339", wantCode: 2},
+		{name: "bad integer", opts: opts, args: []string{"team", "add", "-min-reviewers", "two"}, wantErr: errUsage, wantStderr: "expected an integer", wantCode: 2},
+		{name: "help of a subcommand", opts: opts, args: []string{"pr", "merge", "-h"}, wantStderr: "-force", wantCode: 0},
+		{
+			name:       "unknown output format",
+			opts:       options{mode: ModeHTTP, output: "xml"},
+			args:       []string{"team", "get"},
+			wantErrMsg: `unknown output format "xml"`,
+			wantCode:   1,
+		},
+		{
+			name:       "unknown mode",
+			opts:       options{mode: "grpc", output: OutputJSON},
+			args:       []string{"team", "get", "-name", "backend"},
+			wantErrMsg: `unknown mode "grpc"`,
+			wantCode:   1,
+		},
+		{
+			name:       "missing arguments of a subcommand",
+			opts:       opts,
+			args:       []string{"tasks", "status"},
+			wantErrMsg: "exactly one task id is required",
+			wantCode:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := run(tt.opts, tt.args, &stdout, &stderr)
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantErrMsg != "":
+				assert.EqualError(t, err, tt.wantErrMsg)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Contains(t, stderr.String(), tt.wantStderr)
+			assert.Empty(t, stdout.String())
+
+			var exitStderr bytes.Buffer
+			assert.Equal(t, tt.wantCode, exitCode(&exitStderr, err))
+			if tt.wantCode == 1 {
+				assert.Equal(t, "prctl: "+tt.wantErrMsg+"\n", exitStderr.String())
+			} else {
+				//The usage is already printed
+				assert.Empty(t, exitStderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("team_name") != "backend" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"NOT_FOUND","message":"team not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"team_name":"backend","members":[{"user_id":"u1","username":"alice","is_active":true}]}`))
+	}))
+	defer server.Close()
+
+	opts := options{mode: ModeHTTP, server: server.URL, output: OutputJSON, timeout: time.Second}
+
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, run(opts, []string{"team", "get", "-name", "backend"}, &stdout, &stderr))
+	assert.JSONEq(t, `{"team_name":"backend","members":[{"user_id":"u1","username":"alice","is_active":true}]}`, stdout.String())
+
+	stdout.Reset()
+	err := run(opts, []string{"team", "get", "-name", "mobile"}, &stdout, &stderr)
+	require.Error(t, err)
+	assert.Empty(t, stdout.String())
+
+	var exitStderr bytes.Buffer
+	assert.Equal(t, 1, exitCode(&exitStderr, err))
+	assert.Equal(t, "prctl: NOT_FOUND: team not found (HTTP 404)\n", exitStderr.String())
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func printResult(w io.Writer, format string, v any) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return enc.Encode(v)
+	case OutputYAML:
+		return printYAML(w, v)
+	case OutputTable:
+		return printTable(w, v)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// The value goes through JSON, so YAML has the same field names, order and omitted fields as the API
+func printYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(&node)
+}
+
+// JSON is parsed as flow YAML with quoted strings, the encoder quotes the strings again only where needed
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// Structs are printed as "field value" lines, nested structs are flattened into dotted names
+// and lists of structs become separate tables with a column per field.
+func printTable(w io.Writer, v any) error {
+	rv := reflect.ValueOf(v)
+	t := rv.Type()
+
+	if isTable(t) {
+		return printRows(w, t.Elem(), rv)
+	}
+	if !isStruct(t) {
+		_, err := fmt.Fprintln(w, formatValue(rv))
+		return err
+	}
+
+	type table struct {
+		name  string
+		value reflect.Value
+		elem  reflect.Type
+	}
+	var tables []table
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	walk("", t, rv, func(name string, ft reflect.Type, fv reflect.Value) {
+		if isTable(ft) {
+			tables = append(tables, table{name: name, value: fv, elem: ft.Elem()})
+			return
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", name, formatValue(fv))
+	})
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		fmt.Fprintf(w, "\n%s:\n", table.name)
+		if err := printRows(w, table.elem, table.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printRows(w io.Writer, elem reflect.Type, rows reflect.Value) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	var header []string
+	walk("", elem, reflect.Value{}, func(name string, _ reflect.Type, _ reflect.Value) {
+		header = append(header, strings.ToUpper(name))
+	})
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	if rows.IsValid() {
+		for i := 0; i < rows.Len(); i++ {
+			var cells []string
+			walk("", elem, rows.Index(i), func(_ string, _ reflect.Type, fv reflect.Value) {
+				cells = append(cells, formatValue(fv))
+			})
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+	}
+	return tw.Flush()
+}
+
+// Calls fn for every field of the struct by its JSON name. The value is invalid under a nil pointer,
+// so all rows of a type have the same columns.
+func walk(prefix string, t reflect.Type, v reflect.Value, fn func(name string, t reflect.Type, v reflect.Value)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if v.IsValid() {
+			if v.IsNil() {
+				v = reflect.Value{}
+			} else {
+				v = v.Elem()
+			}
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		name = prefix + name
+
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+		if isStruct(field.Type) {
+			walk(name+".", field.Type, fv, fn)
+			continue
+		}
+		fn(name, field.Type, fv)
+	}
+}
+
+func isStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+func isTable(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && isStruct(t.Elem())
+}
+
+func formatValue(v reflect.Value) string {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return "-"
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "-"
+	}
+
+	switch {
+	case v.Type() == timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339)
+	case v.Type() == rawType:
+		if v.Len() == 0 {
+			return "-"
+		}
+		return string(v.Bytes())
+	case v.Kind() == reflect.Slice:
+		if v.Len() == 0 {
+			return "-"
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	case v.Kind() == reflect.String && v.Len() == 0:
+		return "-"
+	case v.Kind() == reflect.Struct:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		return string(data)
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -1,35 +1,48 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/Estriper0/avito_intership/internal/auth"
 	"github.com/Estriper0/avito_intership/internal/config"
 	"github.com/Estriper0/avito_intership/internal/handlers"
+	"github.com/Estriper0/avito_intership/internal/metrics"
+	"github.com/Estriper0/avito_intership/internal/models"
 	"github.com/Estriper0/avito_intership/internal/repository/db"
 	"github.com/Estriper0/avito_intership/internal/server"
 	"github.com/Estriper0/avito_intership/internal/service"
+	"github.com/Estriper0/avito_intership/internal/tracing"
+	"github.com/Estriper0/avito_intership/internal/worker"
 	"github.com/Estriper0/avito_intership/pkg/postgres"
 	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
 	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/jackc/pgx/v5/pgxpool"
-)
-
-const (
-	queueCap int = 10
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type App struct {
-	logger    *slog.Logger
-	config    *config.Config
-	db        *pgxpool.Pool
-	server    *server.Server
-	taskQueue chan handlers.Task
+	logger  *slog.Logger
+	config  *config.Config
+	db      *pgxpool.Pool
+	server  *server.Server
+	workers *worker.Pool
+	health  *service.HealthService
+	//Flushes the spans left in the exporter batch
+	shutdownTracing func(ctx context.Context) error
+	//Periodic tasks, e.g. reassignment at the start of absences
+	schedulers []*worker.Scheduler
 }
 
 func New(logger *slog.Logger, config *config.Config) *App {
@@ -38,51 +51,131 @@ func New(logger *slog.Logger, config *config.Config) *App {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), config.Tracing)
+	if err != nil {
+		panic(err)
+	}
+
 	router := gin.New()
+	router.Use(
+		//Scrapes and probes are not worth a trace
+		otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+			return r.URL.Path != "/metrics" && !strings.HasPrefix(r.URL.Path, "/health/")
+		})),
+		handlers.RequestId(),
+		handlers.AccessLog(logger),
+		handlers.Metrics(),
+		//Innermost, so the logs and the metrics see the INTERNAL response of a panic
+		handlers.Recovery(logger),
+	)
+
 	dbPool, err := postgres.New(config.DB.Url, config.DB.PoolSize)
 	if err != nil {
 		panic(err)
 	}
 
 	trManager := manager.Must(trmpgx.NewDefaultFactory(dbPool))
-	taskQueue := make(chan handlers.Task, queueCap)
 	validate := validator.New()
 
 	teamRepo := db.NewTeamRepo(dbPool, trmpgx.DefaultCtxGetter)
 	userRepo := db.NewUserRepo(dbPool, trmpgx.DefaultCtxGetter)
 	prRepo := db.NewPullRequestRepo(dbPool, trmpgx.DefaultCtxGetter)
+	jobRepo := db.NewJobRepo(dbPool, trmpgx.DefaultCtxGetter)
+	eventRepo := db.NewPrEventRepo(dbPool, trmpgx.DefaultCtxGetter)
+	webhookRepo := db.NewWebhookRepo(dbPool, trmpgx.DefaultCtxGetter)
+	integrationRepo := db.NewIntegrationRepo(dbPool, trmpgx.DefaultCtxGetter)
+
+	selectors, err := service.NewReviewerSelectors(config.Review, userRepo)
+	if err != nil {
+		panic(err)
+	}
+
+	outbox := service.NewOutbox(webhookRepo, jobRepo, config.Worker.MaxAttempts, logger)
+	webhookService := service.NewWebhookService(webhookRepo, &http.Client{Timeout: config.Webhook.Timeout}, logger)
+
+	prService := service.NewPullRequestService(prRepo, userRepo, teamRepo, eventRepo, selectors, outbox, trManager, logger)
+	teamService := service.NewTeamService(teamRepo, userRepo, prRepo, prService, outbox, trManager, logger)
+	userService := service.NewUserService(userRepo, teamRepo, prRepo, prService, outbox, trManager, logger)
+	jobService := service.NewJobService(jobRepo, teamRepo, userRepo, prService, config.Worker.MaxAttempts, logger)
+	integrationService := service.NewIntegrationService(integrationRepo, prRepo, prService, config.Integrations, trManager, logger)
+
+	//Only internal errors (e.g. lost connection to the database) and failed deliveries are worth retrying
+	workers := worker.New(jobRepo, config.Worker, logger, func(err error) bool {
+		return errors.Is(err, service.ErrInternal) || errors.Is(err, service.ErrWebhookDelivery)
+	})
+	workers.Register(models.JobReassignTeam, jobService.HandleReassignTeam)
+	workers.Register(models.JobWebhookDelivery, webhookService.HandleDelivery)
+
+	//Database collectors are read on every scrape of /metrics
+	prometheus.MustRegister(
+		metrics.NewPoolCollector(dbPool),
+		metrics.NewQueueCollector(jobRepo, logger),
+	)
+	handlers.NewMetricsHandler(router)
+
+	healthService := service.NewHealthService(db.NewHealthRepo(dbPool, trmpgx.DefaultCtxGetter), workers, migrationVersion, logger)
+	healthGroup := router.Group("/health")
+	handlers.NewHealthHandler(healthGroup, healthService)
+
+	schedulers := []*worker.Scheduler{
+		worker.NewScheduler("start_absences", config.Worker.AbsenceInterval, userService.StartAbsences, logger),
+		worker.NewScheduler("sweep_sla", config.Worker.SlaInterval, prService.SweepSla, logger),
+		worker.NewScheduler("fail_expired_jobs", config.Worker.ExpiredInterval, jobService.FailExpired, logger),
+	}
 
-	teamService := service.NewTeamService(teamRepo, userRepo, trManager, logger)
-	userService := service.NewUserService(userRepo, teamRepo, prRepo, logger)
-	prService := service.NewPullRequestService(prRepo, userRepo, teamRepo, trManager, logger)
+	//Inbound webhooks of the Git hostings are verified by their signatures instead of tokens
+	hostingGroup := router.Group("/integrations")
+	handlers.NewIntegrationHandler(hostingGroup, integrationService, validate)
 
-	teamGroup := router.Group("/team")
+	api := router.Group("", handlers.Authenticate(auth.New(config.Auth)))
+
+	teamGroup := api.Group("/team")
 	handlers.NewTeamHandler(teamGroup, teamService, validate)
 
-	userGroup := router.Group("/users")
+	userGroup := api.Group("/users")
 	handlers.NewUserHandler(userGroup, userService, validate)
 
-	prGroup := router.Group("pullRequest")
-	handlers.NewPullRequestHandler(prGroup, prService, validate, taskQueue)
+	prGroup := api.Group("pullRequest")
+	handlers.NewPullRequestHandler(prGroup, prService, jobService, validate)
+
+	webhookGroup := api.Group("/webhooks")
+	handlers.NewWebhookHandler(webhookGroup, webhookService, validate)
+
+	taskGroup := api.Group("/tasks")
+	handlers.NewTaskHandler(taskGroup, jobService, validate)
+
+	identityGroup := api.Group("/integrations/identities")
+	handlers.NewIdentityHandler(identityGroup, integrationService, validate)
+
+	statsGroup := api.Group("/stats")
+	handlers.NewStatsHandler(statsGroup, teamService, validate)
 
 	server := server.New(router, config)
 
 	return &App{
-		logger:    logger,
-		config:    config,
-		db:        dbPool,
-		server:    server,
-		taskQueue: taskQueue,
+		logger:          logger,
+		config:          config,
+		db:              dbPool,
+		server:          server,
+		workers:         workers,
+		health:          healthService,
+		shutdownTracing: shutdownTracing,
+		schedulers:      schedulers,
 	}
 }
 
 func (a *App) Run() {
-	//Closing the connection to the database and task chanel
+	//Closing the connection to the database
 	defer a.db.Close()
-	defer close(a.taskQueue)
 
 	a.logger.Info("Start application")
 
+	a.logger.Info(fmt.Sprintf("Starting %d workers", a.config.Worker.Count))
+	a.workers.Start(context.Background())
+	for _, scheduler := range a.schedulers {
+		scheduler.Start(context.Background())
+	}
+
 	a.logger.Info(fmt.Sprintf("Starting server on :%d", a.config.Server.Port))
 	go a.server.Run()
 
@@ -99,6 +192,13 @@ func (a *App) Run() {
 	}
 	a.logger.Info("Initiating graceful shutdown...")
 
+	//The balancer stops sending requests once readiness fails, the requests in flight are finished by Stop
+	a.health.Drain()
+	if a.config.Server.DrainDelay > 0 {
+		a.logger.Info(fmt.Sprintf("Draining traffic for %s", a.config.Server.DrainDelay))
+		time.Sleep(a.config.Server.DrainDelay)
+	}
+
 	//Graceful shutdown
 	err := a.server.Stop()
 	if err != nil {
@@ -106,5 +206,18 @@ func (a *App) Run() {
 	} else {
 		a.logger.Info("Server shutdown gracefully")
 	}
+
+	//Unfinished jobs stay in the database and are picked up after restart
+	for _, scheduler := range a.schedulers {
+		scheduler.Stop()
+	}
+	a.workers.Stop()
+	a.logger.Info("Workers stopped")
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Server.ShutdownTimeout)
+	defer cancel()
+	if err := a.shutdownTracing(ctx); err != nil {
+		a.logger.Error("Incorrect tracing shutdown", slog.String("error", err.Error()))
+	}
 	a.logger.Info("Stop application")
 }
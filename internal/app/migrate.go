@@ -7,9 +7,15 @@ import (
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
+const migrationsSource = "file://migrations"
+
+// Latest migration shipped with the build, the readiness probe compares the database with it
+var migrationVersion uint
+
 func init() {
 	dbURL, ok := os.LookupEnv("DB_URL")
 	if !ok {
@@ -17,7 +23,7 @@ func init() {
 	}
 
 	dbURL += "?sslmode=disable"
-	m, err := migrate.New("file://migrations", dbURL)
+	m, err := migrate.New(migrationsSource, dbURL)
 	if err != nil {
 		panic(fmt.Sprintf("app:init:migrate.New - %s", err.Error()))
 	}
@@ -27,4 +33,32 @@ func init() {
 	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		panic(fmt.Sprintf("app:init:m.Up - %s", err.Error()))
 	}
+
+	migrationVersion, err = latestMigration(migrationsSource)
+	if err != nil {
+		panic(fmt.Sprintf("app:init:latestMigration - %s", err.Error()))
+	}
+}
+
+func latestMigration(sourceUrl string) (uint, error) {
+	driver, err := source.Open(sourceUrl)
+	if err != nil {
+		return 0, err
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
 }
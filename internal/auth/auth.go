@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	RoleMember   = "member"
+	RoleTeamLead = "team-lead"
+	RoleAdmin    = "admin"
+)
+
+// Roles are ordered, a role grants access to everything allowed for the lower ones
+var roleRank = map[string]int{
+	RoleMember:   1,
+	RoleTeamLead: 2,
+	RoleAdmin:    3,
+}
+
+var (
+	ErrNoToken      = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Principal is the authenticated caller of the API.
+type Principal struct {
+	//Empty for static admin tokens
+	UserId string
+	Role   string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return roleRank[p.Role] >= roleRank[role]
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+type claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type Authenticator struct {
+	enabled     bool
+	adminTokens []string
+	secret      []byte
+}
+
+func New(cfg config.AuthConfig) *Authenticator {
+	return &Authenticator{
+		enabled:     cfg.Enabled,
+		adminTokens: cfg.AdminTokens,
+		secret:      []byte(cfg.JWTSecret),
+	}
+}
+
+// Authenticate resolves a bearer token: static admin tokens first, then HS256 JWTs
+// with the user id in "sub", the role in "role" and a mandatory "exp".
+func (a *Authenticator) Authenticate(token string) (*Principal, error) {
+	//Without auth every caller is an admin, only for local development
+	if !a.enabled {
+		return &Principal{Role: RoleAdmin}, nil
+	}
+	if token == "" {
+		return nil, ErrNoToken
+	}
+
+	for _, adminToken := range a.adminTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+			return &Principal{Role: RoleAdmin}, nil
+		}
+	}
+
+	if len(a.secret) == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(t *jwt.Token) (any, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if _, ok := roleRank[c.Role]; !ok || c.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return &Principal{
+		UserId: c.Subject,
+		Role:   c.Role,
+	}, nil
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the caller of the request.
+// Calls made outside of the HTTP API (e.g. background jobs) have no principal.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
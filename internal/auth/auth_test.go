@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "jwt-signing-key"
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	authenticator := New(config.AuthConfig{
+		Enabled:     true,
+		AdminTokens: []string{"admin-token"},
+		JWTSecret:   testSecret,
+	})
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *Principal
+		wantErr error
+	}{
+		{
+			name:    "no token",
+			token:   "",
+			wantErr: ErrNoToken,
+		},
+		{
+			name:  "static admin token",
+			token: "admin-token",
+			want:  &Principal{Role: RoleAdmin},
+		},
+		{
+			name:  "jwt",
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"sub": "u1", "role": RoleTeamLead, "exp": exp}),
+			want:  &Principal{UserId: "u1", Role: RoleTeamLead},
+		},
+		{
+			name:    "jwt without exp",
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"sub": "u1", "role": RoleMember}),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "expired jwt",
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"sub": "u1", "role": RoleMember, "exp": time.Now().Add(-time.Minute).Unix()}),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "wrong secret",
+			token:   sign(t, jwt.SigningMethodHS256, []byte("other-key"), jwt.MapClaims{"sub": "u1", "role": RoleMember, "exp": exp}),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "other signing method",
+			token:   sign(t, jwt.SigningMethodHS512, []byte(testSecret), jwt.MapClaims{"sub": "u1", "role": RoleMember, "exp": exp}),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "unsigned jwt",
+			token:   sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, jwt.MapClaims{"sub": "u1", "role": RoleAdmin, "exp": exp}),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "unknown role",
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"sub": "u1", "role": "owner", "exp": exp}),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "no subject",
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"role": RoleMember, "exp": exp}),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "garbage",
+			token:   "not-a-token",
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, principal)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, principal)
+		})
+	}
+}
+
+func TestAuthenticator_Authenticate_NoSecret(t *testing.T) {
+	authenticator := New(config.AuthConfig{Enabled: true, AdminTokens: []string{"admin-token"}})
+
+	//Without a secret any JWT is rejected, even one signed with the empty key
+	token := sign(t, jwt.SigningMethodHS256, []byte(""), jwt.MapClaims{"sub": "u1", "role": RoleMember, "exp": time.Now().Add(time.Hour).Unix()})
+	_, err := authenticator.Authenticate(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	principal, err := authenticator.Authenticate("admin-token")
+	require.NoError(t, err)
+	assert.True(t, principal.IsAdmin())
+}
+
+func TestAuthenticator_Authenticate_Disabled(t *testing.T) {
+	authenticator := New(config.AuthConfig{Enabled: false})
+
+	principal, err := authenticator.Authenticate("")
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{Role: RoleAdmin}, principal)
+}
+
+func TestPrincipal_HasRole(t *testing.T) {
+	member := &Principal{UserId: "u1", Role: RoleMember}
+	lead := &Principal{UserId: "u2", Role: RoleTeamLead}
+	admin := &Principal{Role: RoleAdmin}
+
+	assert.True(t, member.HasRole(RoleMember))
+	assert.False(t, member.HasRole(RoleTeamLead))
+	assert.True(t, lead.HasRole(RoleMember))
+	assert.True(t, lead.HasRole(RoleTeamLead))
+	assert.False(t, lead.HasRole(RoleAdmin))
+	assert.True(t, admin.HasRole(RoleTeamLead))
+	assert.True(t, admin.IsAdmin())
+	assert.False(t, lead.IsAdmin())
+	assert.False(t, (&Principal{Role: "owner"}).HasRole(RoleMember))
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	principal := &Principal{UserId: "u1", Role: RoleMember}
+	got, ok := FromContext(WithPrincipal(context.Background(), principal))
+	require.True(t, ok)
+	assert.Same(t, principal, got)
+}
@@ -9,9 +9,15 @@ import (
 )
 
 type Config struct {
-	App    AppConfig
-	Server ServerConfig `yaml:"server"`
-	DB     DBConfig     `yaml:"db"`
+	App          AppConfig
+	Server       ServerConfig       `yaml:"server"`
+	DB           DBConfig           `yaml:"db"`
+	Review       ReviewConfig       `yaml:"review"`
+	Auth         AuthConfig         `yaml:"auth"`
+	Worker       WorkerConfig       `yaml:"worker"`
+	Webhook      WebhookConfig      `yaml:"webhook"`
+	Integrations IntegrationsConfig `yaml:"integrations"`
+	Tracing      TracingConfig      `yaml:"tracing"`
 }
 
 type AppConfig struct {
@@ -23,6 +29,8 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration `env-required:"true" yaml:"read_timeout" env:"READ_TIMEOUT"`
 	WriteTimeout    time.Duration `env-required:"true" yaml:"write_timeout" env:"WRITE_TIMEOUT"`
 	ShutdownTimeout time.Duration `env-required:"true" yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	//How long the readiness probe fails before the server stops, so the balancer moves the traffic away
+	DrainDelay time.Duration `yaml:"drain_delay" env:"DRAIN_DELAY" env-default:"0s"`
 }
 
 type DBConfig struct {
@@ -35,6 +43,71 @@ type DBConfig struct {
 	PoolSize int32  `env-required:"true" yaml:"pool_size" env:"DB_POOL_SIZE"`
 }
 
+type ReviewConfig struct {
+	//Default reviewer selection strategy: random, least_loaded, round_robin, weighted
+	Strategy string `yaml:"strategy" env:"REVIEW_STRATEGY" env-default:"random"`
+	//Strategy overrides by team name
+	TeamStrategies map[string]string `yaml:"team_strategies"`
+	//User weights for the weighted strategy (default weight is 1)
+	Weights map[string]int `yaml:"weights"`
+	//Cap on concurrent open reviews per user for the least_loaded strategy (0 - no cap)
+	MaxOpenReviews int `yaml:"max_open_reviews" env:"REVIEW_MAX_OPEN_REVIEWS" env-default:"0"`
+	//Cap overrides by user_id: 0 - the user takes no reviews, a negative value - no cap
+	UserMaxOpenReviews map[string]int `yaml:"user_max_open_reviews"`
+}
+
+type AuthConfig struct {
+	//Without auth every request is treated as admin, only for local development
+	Enabled bool `yaml:"enabled" env:"AUTH_ENABLED" env-default:"true"`
+	//Static tokens granting the admin role
+	AdminTokens []string `yaml:"admin_tokens" env:"AUTH_ADMIN_TOKENS" env-separator:","`
+	//Key of the HMAC-signed (HS256) user tokens
+	JWTSecret string `yaml:"jwt_secret" env:"AUTH_JWT_SECRET"`
+}
+
+type WorkerConfig struct {
+	//Number of goroutines polling the jobs table
+	Count int `yaml:"count" env:"WORKER_COUNT" env-default:"5"`
+	//Delay between polls when there is nothing to do
+	PollInterval time.Duration `yaml:"poll_interval" env:"WORKER_POLL_INTERVAL" env-default:"1s"`
+	//How long a job stays leased before another worker may pick it up
+	LeaseTimeout time.Duration `yaml:"lease_timeout" env:"WORKER_LEASE_TIMEOUT" env-default:"5m"`
+	//Attempts before a job is marked failed
+	MaxAttempts int `yaml:"max_attempts" env:"WORKER_MAX_ATTEMPTS" env-default:"5"`
+	//Base delay of the exponential retry backoff and its cap
+	RetryBackoff    time.Duration `yaml:"retry_backoff" env:"WORKER_RETRY_BACKOFF" env-default:"1s"`
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff" env:"WORKER_MAX_RETRY_BACKOFF" env-default:"5m"`
+	//How often started absences are checked to reassign the reviews of absent users
+	AbsenceInterval time.Duration `yaml:"absence_interval" env:"WORKER_ABSENCE_INTERVAL" env-default:"1m"`
+	//How often reviews are checked against the review SLA of the teams
+	SlaInterval time.Duration `yaml:"sla_interval" env:"WORKER_SLA_INTERVAL" env-default:"1m"`
+	//How often jobs with an expired lease and no attempts left are marked failed
+	ExpiredInterval time.Duration `yaml:"expired_interval" env:"WORKER_EXPIRED_INTERVAL" env-default:"1m"`
+}
+
+type WebhookConfig struct {
+	//Timeout of one delivery request
+	Timeout time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT" env-default:"5s"`
+}
+
+type IntegrationsConfig struct {
+	//Secret of the GitHub webhook (X-Hub-Signature-256), the endpoint is disabled if empty
+	GitHubSecret string `yaml:"github_secret" env:"GITHUB_WEBHOOK_SECRET"`
+	//Secret token of the GitLab webhook (X-Gitlab-Token), the endpoint is disabled if empty
+	GitLabToken string `yaml:"gitlab_token" env:"GITLAB_WEBHOOK_TOKEN"`
+}
+
+type TracingConfig struct {
+	//Span exporter: none, stdout or otlp (gRPC collector)
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	//Address of the OTLP collector
+	Endpoint string `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4317"`
+	//Plain text connection to the collector, e.g. a local sidecar
+	Insecure bool `yaml:"insecure" env:"TRACING_INSECURE" env-default:"true"`
+	//Share of the recorded traces from 0 to 1, requests continuing a sampled trace are always recorded
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
 func New(configPath string) *Config {
 	var config Config
 
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate rejects requests without a valid bearer token and
+// puts the caller into the request context.
+func Authenticate(authenticator *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		principal, err := authenticator.Authenticate(token)
+		if err != nil {
+			respondWithError(c, http.StatusUnauthorized, ErrStatusUnauthorized, err)
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// RequireRole allows only callers with the role or a higher one.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c.Request.Context())
+		if !ok || !principal.HasRole(role) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, errors.New("requires "+role+" role"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func isAdmin(c *gin.Context) bool {
+	principal, ok := auth.FromContext(c.Request.Context())
+	return ok && principal.IsAdmin()
+}
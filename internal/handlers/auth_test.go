@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "jwt-signing-key"
+
+func token(t *testing.T, userId string, role string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  userId,
+		"role": role,
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return token
+}
+
+// Router with a route per role, the routes answer with the caller taken from the context
+func authRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Authenticate(auth.New(config.AuthConfig{
+		Enabled:     true,
+		AdminTokens: []string{"admin-token"},
+		JWTSecret:   testSecret,
+	})))
+
+	whoami := func(c *gin.Context) {
+		principal, _ := auth.FromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{"user_id": principal.UserId, "role": principal.Role, "admin": isAdmin(c)})
+	}
+	r.GET("/member", whoami)
+	r.GET("/lead", RequireRole(auth.RoleTeamLead), whoami)
+	r.GET("/admin", RequireRole(auth.RoleAdmin), whoami)
+	return r
+}
+
+func TestAuthenticate_RequireRole(t *testing.T) {
+	r := authRouter()
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "no header", path: "/member", wantStatus: http.StatusUnauthorized, wantCode: ErrStatusUnauthorized},
+		{name: "not a bearer token", path: "/member", header: "Basic dTE6cGFzcw==", wantStatus: http.StatusUnauthorized, wantCode: ErrStatusUnauthorized},
+		{name: "invalid token", path: "/member", header: "Bearer not-a-token", wantStatus: http.StatusUnauthorized, wantCode: ErrStatusUnauthorized},
+		{name: "member", path: "/member", header: "Bearer " + token(t, "u1", auth.RoleMember), wantStatus: http.StatusOK},
+		{name: "member on a lead route", path: "/lead", header: "Bearer " + token(t, "u1", auth.RoleMember), wantStatus: http.StatusForbidden, wantCode: ErrStatusForbidden},
+		{name: "lead", path: "/lead", header: "Bearer " + token(t, "u2", auth.RoleTeamLead), wantStatus: http.StatusOK},
+		{name: "lead on an admin route", path: "/admin", header: "Bearer " + token(t, "u2", auth.RoleTeamLead), wantStatus: http.StatusForbidden, wantCode: ErrStatusForbidden},
+		{name: "static admin token", path: "/admin", header: "Bearer admin-token", wantStatus: http.StatusOK},
+		{name: "admin jwt", path: "/lead", header: "Bearer " + token(t, "u3", auth.RoleAdmin), wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode == "" {
+				return
+			}
+			var body struct {
+				Error struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantCode, body.Error.Code)
+			assert.NotEmpty(t, body.Error.Message)
+		})
+	}
+}
+
+func TestAuthenticate_Principal(t *testing.T) {
+	r := authRouter()
+
+	tests := []struct {
+		header string
+		want   map[string]any
+	}{
+		{
+			header: "Bearer " + token(t, "u2", auth.RoleTeamLead),
+			want:   map[string]any{"user_id": "u2", "role": auth.RoleTeamLead, "admin": false},
+		},
+		{
+			header: "Bearer admin-token",
+			want:   map[string]any{"user_id": "", "role": auth.RoleAdmin, "admin": true},
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/lead", nil)
+		req.Header.Set("Authorization", tt.header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, tt.want, body)
+	}
+}
+
+func TestRequireRole_NoPrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	//A route registered without Authenticate is closed, not open
+	r.GET("/lead", RequireRole(auth.RoleTeamLead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lead", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,35 @@
+package dto
+
+const (
+	HealthStatusOk          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type MigrationsCheck struct {
+	HealthCheck
+	Version uint `json:"version"`
+	//Latest migration shipped with this build
+	Expected uint `json:"expected"`
+	Dirty    bool `json:"dirty"`
+}
+
+type WorkersCheck struct {
+	HealthCheck
+	Running bool `json:"running"`
+	Count   int  `json:"count"`
+	Busy    int  `json:"busy"`
+}
+
+type ReadinessResponse struct {
+	Status string `json:"status"`
+	//Set once the graceful shutdown has started
+	Draining   bool            `json:"draining"`
+	Database   HealthCheck     `json:"database"`
+	Migrations MigrationsCheck `json:"migrations"`
+	Workers    WorkersCheck    `json:"workers"`
+}
@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+// HostingDelivery is a raw inbound webhook request of a Git hosting
+type HostingDelivery struct {
+	Provider   string
+	DeliveryId string
+	Event      string
+	//X-Hub-Signature-256 for GitHub, X-Gitlab-Token for GitLab
+	Signature string
+	Body      []byte
+}
+
+type HostingDeliveryResponse struct {
+	//processed, duplicate or ignored
+	Status string `json:"status"`
+	Action string `json:"action,omitempty"`
+	PrId   string `json:"pull_request_id,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type IdentityRequest struct {
+	Provider string `json:"provider" validate:"required,oneof=github gitlab"`
+	Login    string `json:"login" validate:"required,max=100"`
+	UserId   string `json:"user_id" validate:"required,max=30"`
+}
+
+type IdentityDeleteRequest struct {
+	Provider string `json:"provider" validate:"required,oneof=github gitlab"`
+	Login    string `json:"login" validate:"required,max=100"`
+}
+
+type IdentityListRequest struct {
+	Provider string `form:"provider" validate:"omitempty,oneof=github gitlab"`
+}
+
+type Identity struct {
+	Provider  string    `json:"provider"`
+	Login     string    `json:"login"`
+	UserId    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -6,6 +6,10 @@ type PrCreateRequest struct {
 	PrId     string `json:"pull_request_id" validate:"required,max=30"`
 	PrName   string `json:"pull_request_name" validate:"required,max=200"`
 	AuthorId string `json:"author_id" validate:"required,max=30"`
+	//Drafts are created without reviewers
+	Draft bool `json:"draft"`
+	//Paths matched against the code owner rules of the author's team
+	ChangedFiles []string `json:"changed_files" validate:"max=1000,dive,required,max=1024"`
 }
 
 type PullRequest struct {
@@ -14,18 +18,28 @@ type PullRequest struct {
 	AuthorId          string   `json:"author_id"`
 	Status            string   `json:"status"`
 	AssignedReviewers []string `json:"assigned_reviewers"`
+	//Reviewers taken from the fallback teams of the author's team
+	ExternalReviewers []string `json:"external_reviewers,omitempty"`
+	UnderReviewed     bool     `json:"under_reviewed"`
 }
 
-type MergeRequest struct {
+type StatusChangeRequest struct {
 	PrId string `json:"pull_request_id" validate:"required,max=30"`
 }
 
+type MergeRequest struct {
+	PrId  string `json:"pull_request_id" validate:"required,max=30"`
+	Force bool   `json:"force"`
+}
+
 type MergeResponse struct {
 	PrId              string    `json:"pull_request_id"`
 	PrName            string    `json:"pull_request_name"`
 	AuthorId          string    `json:"author_id"`
 	Status            string    `json:"status"`
 	AssignedReviewers []string  `json:"assigned_reviewers"`
+	UnderReviewed     bool      `json:"under_reviewed"`
+	ForceMerged       bool      `json:"force_merged"`
 	MergedAt          time.Time `json:"mergedAt"`
 }
 
@@ -42,5 +56,84 @@ type ReassignResponse struct {
 type MassReassignResponse struct {
 	PrId          string `json:"pull_request_id"`
 	OldReviewerId string `json:"old_reviewer_id"`
-	NewReviewerId string `json:"new_reviewer_id"`
+	NewReviewerId string `json:"new_reviewer_id,omitempty"`
+	//Reviewer is left as is because there is no candidate
+	Skipped bool   `json:"skipped,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type SubmitReviewRequest struct {
+	PrId       string `json:"pull_request_id" validate:"required,max=30"`
+	ReviewerId string `json:"reviewer_id" validate:"required,max=30"`
+	Decision   string `json:"decision" validate:"required,oneof=APPROVED CHANGES_REQUESTED COMMENTED"`
+}
+
+type SubmitReviewResponse struct {
+	PrId       string    `json:"pull_request_id"`
+	ReviewerId string    `json:"reviewer_id"`
+	Decision   string    `json:"decision"`
+	DecidedAt  time.Time `json:"decided_at"`
+}
+
+type PullRequestDetails struct {
+	PrId              string           `json:"pull_request_id"`
+	PrName            string           `json:"pull_request_name"`
+	AuthorId          string           `json:"author_id"`
+	Status            string           `json:"status"`
+	AssignedReviewers []string         `json:"assigned_reviewers"`
+	ExternalReviewers []string         `json:"external_reviewers,omitempty"`
+	Reviews           []ReviewDecision `json:"reviews"`
+	ChangedFiles      []string         `json:"changed_files,omitempty"`
+	UnderReviewed     bool             `json:"under_reviewed"`
+	ForceMerged       bool             `json:"force_merged"`
+	CreatedAt         time.Time        `json:"created_at"`
+	MergedAt          *time.Time       `json:"merged_at"`
+	ClosedAt          *time.Time       `json:"closed_at"`
+}
+
+type ReviewDecision struct {
+	ReviewerId string     `json:"reviewer_id"`
+	Decision   string     `json:"decision,omitempty"`
+	DecidedAt  *time.Time `json:"decided_at,omitempty"`
+}
+
+type PrListRequest struct {
+	TeamName    string     `form:"team_name" validate:"omitempty,max=30"`
+	AuthorId    string     `form:"author_id" validate:"omitempty,max=30"`
+	ReviewerId  string     `form:"reviewer_id" validate:"omitempty,max=30"`
+	Status      string     `form:"status" validate:"omitempty,oneof=DRAFT OPEN MERGED CLOSED"`
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit       int        `form:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor      string     `form:"cursor" validate:"omitempty,max=200"`
+}
+
+type PrListItem struct {
+	PrId              string     `json:"pull_request_id"`
+	PrName            string     `json:"pull_request_name"`
+	AuthorId          string     `json:"author_id"`
+	Status            string     `json:"status"`
+	AssignedReviewers []string   `json:"assigned_reviewers"`
+	CreatedAt         time.Time  `json:"created_at"`
+	MergedAt          *time.Time `json:"merged_at"`
+	ClosedAt          *time.Time `json:"closed_at"`
+}
+
+type PrListResponse struct {
+	PullRequests []PrListItem `json:"pull_requests"`
+	NextCursor   string       `json:"next_cursor,omitempty"`
+}
+
+type PrEvent struct {
+	Type          string    `json:"type"`
+	Actor         string    `json:"actor"`
+	ReviewerId    string    `json:"reviewer_id,omitempty"`
+	OldReviewerId string    `json:"old_reviewer_id,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type PrHistoryResponse struct {
+	PrId   string    `json:"pull_request_id"`
+	Events []PrEvent `json:"events"`
 }
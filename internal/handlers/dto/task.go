@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type TaskProgress struct {
+	Processed int `json:"processed"`
+	Total     int `json:"total"`
+}
+
+type TaskResponse struct {
+	TaskId   string       `json:"task_id"`
+	Type     string       `json:"type"`
+	Status   string       `json:"status"`
+	Attempts int          `json:"attempts"`
+	Progress TaskProgress `json:"progress"`
+	//Job result, for reassign_team it is a list of MassReassignResponse
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
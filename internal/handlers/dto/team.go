@@ -1,8 +1,14 @@
 package dto
 
+import "time"
+
 type Team struct {
-	TeamName string    `json:"team_name" validate:"required,max=30"`
-	Members  []Members `json:"members" validate:"required,min=1"`
+	TeamName     string    `json:"team_name" validate:"required,max=30"`
+	Members      []Members `json:"members" validate:"required,min=1"`
+	MinReviewers *int      `json:"min_reviewers,omitempty" validate:"omitempty,min=0,max=10"`
+	MaxReviewers *int      `json:"max_reviewers,omitempty" validate:"omitempty,min=0,max=10"`
+	//Number of approvals needed to merge a PR of the team
+	RequiredApprovals *int `json:"required_approvals,omitempty" validate:"omitempty,min=0,max=10"`
 }
 
 type Members struct {
@@ -11,9 +17,148 @@ type Members struct {
 	IsActive bool   `json:"is_active" validate:"required"`
 }
 
+type TeamStatsPrRequest struct {
+	TeamName string `form:"team_name" validate:"required,max=30"`
+	//Window of the statistics, open if not passed
+	From *time.Time `form:"from"`
+	To   *time.Time `form:"to"`
+}
+
 type TeamStatsPrResponse struct {
-	Name     string `json:"team_name"`
-	TotalPr  int    `json:"total_pull_request"`
-	OpenPr   int    `json:"open_pull_request"`
-	MergedPr int    `json:"merged_pull_request"`
+	Name            string     `json:"team_name"`
+	From            *time.Time `json:"from,omitempty"`
+	To              *time.Time `json:"to,omitempty"`
+	TotalPr         int        `json:"total_pull_request"`
+	OpenPr          int        `json:"open_pull_request"`
+	MergedPr        int        `json:"merged_pull_request"`
+	DraftPr         int        `json:"draft_pull_request"`
+	ClosedPr        int        `json:"closed_pull_request"`
+	UnderReviewedPr int        `json:"under_reviewed_pull_request"`
+	//From created_at to merged_at of the PRs merged within the window
+	TimeToMerge DurationStats `json:"time_to_merge"`
+	//From created_at to the first review decision of the PRs created within the window
+	TimeToFirstReview DurationStats   `json:"time_to_first_review"`
+	MergedPerWeek     []WeeklyCount   `json:"merged_per_week"`
+	ReviewLoad        []MemberReviews `json:"review_load"`
+}
+
+// DurationStats is a distribution of durations in seconds, percentiles are null without data
+type DurationStats struct {
+	Count         int    `json:"count"`
+	MedianSeconds *int64 `json:"median_seconds"`
+	P90Seconds    *int64 `json:"p90_seconds"`
+}
+
+type WeeklyCount struct {
+	Week   time.Time `json:"week"`
+	Merged int       `json:"merged"`
+}
+
+type MemberReviews struct {
+	UserId      string `json:"user_id"`
+	Username    string `json:"username"`
+	Reviews     int    `json:"reviews"`
+	OpenReviews int    `json:"open_reviews"`
+}
+
+type TeamSettingsRequest struct {
+	TeamName     string `json:"team_name" validate:"required,max=30"`
+	MinReviewers *int   `json:"min_reviewers" validate:"required,min=0,max=10"`
+	MaxReviewers *int   `json:"max_reviewers" validate:"required,min=0,max=10"`
+	//Keeps the current value if not passed
+	RequiredApprovals *int `json:"required_approvals" validate:"omitempty,min=0,max=10"`
+	//Hours a reviewer has for a decision, 0 turns the SLA off. Keeps the current value if not passed
+	ReviewSlaHours *int `json:"review_sla_hours" validate:"omitempty,min=0,max=720"`
+	//Reassign reviewers who breached the SLA. Keeps the current value if not passed
+	SlaAutoReassign *bool `json:"sla_auto_reassign"`
+}
+
+type TeamSettingsResponse struct {
+	TeamName          string `json:"team_name"`
+	MinReviewers      int    `json:"min_reviewers"`
+	MaxReviewers      int    `json:"max_reviewers"`
+	RequiredApprovals int    `json:"required_approvals"`
+	ReviewSlaHours    int    `json:"review_sla_hours"`
+	SlaAutoReassign   bool   `json:"sla_auto_reassign"`
+}
+
+type TeamMembersRequest struct {
+	TeamName string    `json:"team_name" validate:"required,max=30"`
+	Members  []Members `json:"members" validate:"required,min=1,dive"`
+}
+
+type TeamMemberRemoveRequest struct {
+	TeamName string `json:"team_name" validate:"required,max=30"`
+	UserId   string `json:"user_id" validate:"required,max=30"`
+	//Reassign open reviews of the removed user
+	Reassign bool `json:"reassign"`
+}
+
+type TeamRenameRequest struct {
+	TeamName    string `json:"team_name" validate:"required,max=30"`
+	NewTeamName string `json:"new_team_name" validate:"required,max=30"`
+}
+
+type TeamDeleteRequest struct {
+	TeamName string `json:"team_name" validate:"required,max=30"`
+}
+
+type TeamDeleteResponse struct {
+	TeamName string `json:"team_name"`
+	//Former members which are left without a team
+	UsersId []string `json:"released_users_id"`
+}
+
+type TeamFallbacksRequest struct {
+	TeamName string `json:"team_name" validate:"required,max=30"`
+	//Ordered by priority, an empty list removes the fallbacks
+	FallbackTeams []string `json:"fallback_teams" validate:"max=10,unique,dive,required,max=30"`
+}
+
+type TeamFallbacksResponse struct {
+	TeamName      string   `json:"team_name"`
+	FallbackTeams []string `json:"fallback_teams"`
+}
+
+type CodeOwnerRule struct {
+	//CODEOWNERS-style glob, e.g. "/services/payments/" or "*.sql"
+	Pattern string   `json:"pattern" validate:"required,max=255"`
+	Users   []string `json:"users" validate:"max=50,unique,dive,required,max=30"`
+	Teams   []string `json:"teams" validate:"max=10,unique,dive,required,max=30"`
+}
+
+type CodeOwnersRequest struct {
+	TeamName string `json:"team_name" validate:"required,max=30"`
+	//The last matching rule takes precedence, an empty list removes the rules
+	Rules []CodeOwnerRule `json:"rules" validate:"max=100,dive"`
+}
+
+type CodeOwnersResponse struct {
+	TeamName string          `json:"team_name"`
+	Rules    []CodeOwnerRule `json:"rules"`
+}
+
+type SlaReportRequest struct {
+	//All teams if not passed
+	TeamName string `form:"team_name" validate:"max=30"`
+	//Window of the breach counters, open if not passed
+	From *time.Time `form:"from"`
+	To   *time.Time `form:"to"`
+}
+
+type SlaBreach struct {
+	PrId       string    `json:"pull_request_id"`
+	ReviewerId string    `json:"reviewer_id"`
+	AssignedAt time.Time `json:"assigned_at"`
+	BreachedAt time.Time `json:"breached_at"`
+}
+
+type TeamSlaReport struct {
+	TeamName       string `json:"team_name"`
+	ReviewSlaHours int    `json:"review_sla_hours"`
+	//Breaches and SLA reassignments within the window
+	Breaches   int `json:"breaches"`
+	Reassigned int `json:"reassigned"`
+	//Reviewers who breached the SLA and still have not decided
+	OpenBreaches []SlaBreach `json:"open_breaches"`
 }
@@ -1,8 +1,17 @@
 package dto
 
+import "time"
+
 type SetIsActiveRequest struct {
 	UserId   string `json:"user_id" validate:"required,max=30"`
 	IsActive *bool  `json:"is_active" validate:"required"`
+	//Reassign open reviews of the deactivated user
+	Reassign bool `json:"reassign"`
+}
+
+type SetIsActiveResponse struct {
+	User          *UserResponse          `json:"user"`
+	Reassignments []MassReassignResponse `json:"reassignments,omitempty"`
 }
 
 type UserResponse struct {
@@ -25,10 +34,70 @@ type UserStatsReviewResponse struct {
 	CountOpenReview int    `json:"count_open_review"`
 }
 
+type UserStatsRequest struct {
+	TeamName string `form:"team_name" validate:"max=30"`
+	//Optional bounds, to is exclusive
+	From   *time.Time `form:"from"`
+	To     *time.Time `form:"to"`
+	SortBy string     `form:"sort_by" validate:"omitempty,oneof=total_reviews open_reviews completed_reviews reassigned_away avg_review_seconds authored_pull_requests"`
+	Order  string     `form:"order" validate:"omitempty,oneof=asc desc"`
+}
+
+type UserStats struct {
+	UserId           string `json:"user_id"`
+	Username         string `json:"username"`
+	TeamName         string `json:"team_name"`
+	TotalReviews     int    `json:"total_reviews"`
+	OpenReviews      int    `json:"open_reviews"`
+	CompletedReviews int    `json:"completed_reviews"`
+	ReassignedAway   int    `json:"reassigned_away"`
+	//Null without decided reviews
+	AvgReviewSeconds *int64 `json:"avg_review_seconds"`
+	AuthoredPr       int    `json:"authored_pull_requests"`
+}
+
 type MassDeactivationRequest struct {
 	UsersId []string `json:"users_id" validate:"min=1"`
+	//Reassign open reviews of the deactivated users
+	Reassign bool `json:"reassign"`
 }
 
 type MassDeactivationResponse struct {
-	UsersId []string `json:"deactivated_users_id"`
+	UsersId       []string               `json:"deactivated_users_id"`
+	Reassignments []MassReassignResponse `json:"reassignments,omitempty"`
+}
+
+type UserMoveRequest struct {
+	UserId   string `json:"user_id" validate:"required,max=30"`
+	TeamName string `json:"team_name" validate:"required,max=30"`
+	//Reassign open reviews of the user in the old team
+	Reassign bool `json:"reassign"`
+}
+
+// UserTeamChangeResponse is returned when a user is moved to another team or removed from the team
+type UserTeamChangeResponse struct {
+	User          *UserResponse          `json:"user"`
+	Reassignments []MassReassignResponse `json:"reassignments,omitempty"`
+}
+
+type AbsenceRequest struct {
+	UserId   string    `json:"user_id" validate:"required,max=30"`
+	StartsAt time.Time `json:"starts_at" validate:"required"`
+	EndsAt   time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+	//Free text, e.g. vacation or sick leave
+	Reason string `json:"reason" validate:"max=100"`
+}
+
+type AbsenceDeleteRequest struct {
+	UserId    string `json:"user_id" validate:"required,max=30"`
+	AbsenceId int    `json:"absence_id" validate:"required,min=1"`
+}
+
+type Absence struct {
+	AbsenceId    int        `json:"absence_id"`
+	UserId       string     `json:"user_id"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       time.Time  `json:"ends_at"`
+	Reason       string     `json:"reason,omitempty"`
+	ReassignedAt *time.Time `json:"reassigned_at,omitempty"`
 }
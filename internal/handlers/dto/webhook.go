@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+type WebhookRequest struct {
+	Url string `json:"url" validate:"required,url,max=2048"`
+	//Generated if empty, returned only once on create
+	Secret   string   `json:"secret" validate:"omitempty,min=16,max=128"`
+	Events   []string `json:"events" validate:"required,min=1,dive,oneof=pr.created pr.reviewer_reassigned pr.merged pr.review_sla_breached user.deactivated team.created"`
+	IsActive *bool    `json:"is_active"`
+}
+
+type Webhook struct {
+	Id        int       `json:"id"`
+	Url       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	Id         int64     `json:"id"`
+	DeliveryId string    `json:"delivery_id"`
+	Event      string    `json:"event"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDeliveriesRequest struct {
+	Limit int `form:"limit" validate:"omitempty,min=1,max=100"`
+}
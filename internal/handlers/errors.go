@@ -5,14 +5,21 @@ import (
 )
 
 const (
-	ErrStatusTeamExists  = "TEAM_EXISTS"
-	ErrStatusPrExists    = "PR_EXISTS"
-	ErrStatusPrMerged    = "PR_MERGED"
-	ErrStatusNotAssigned = "NOT_ASSIGNED"
-	ErrStatusNoCandidate = "NO_CANDIDATE"
-	ErrStatusNotFound    = "NOT_FOUND"
-	ErrStatusInternal    = "INTERNAL"
-	ErrStatusBadRequest  = "BAD_REQUEST"
+	ErrStatusTeamExists   = "TEAM_EXISTS"
+	ErrStatusOtherTeam    = "USER_IN_OTHER_TEAM"
+	ErrStatusOpenPrs      = "HAS_OPEN_PRS"
+	ErrStatusPrExists     = "PR_EXISTS"
+	ErrStatusPrMerged     = "PR_MERGED"
+	ErrStatusPrNotOpen    = "PR_NOT_OPEN"
+	ErrStatusTransition   = "INVALID_TRANSITION"
+	ErrStatusNotAssigned  = "NOT_ASSIGNED"
+	ErrStatusNotApproved  = "NOT_APPROVED"
+	ErrStatusUnauthorized = "UNAUTHORIZED"
+	ErrStatusForbidden    = "FORBIDDEN"
+	ErrStatusNoCandidate  = "NO_CANDIDATE"
+	ErrStatusNotFound     = "NOT_FOUND"
+	ErrStatusInternal     = "INTERNAL"
+	ErrStatusBadRequest   = "BAD_REQUEST"
 )
 
 func respondWithError(c *gin.Context, code int, errStatus string, err error) {
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	healthService service.IHealthService
+}
+
+// NewHealthHandler registers the probes, they are served without a token
+func NewHealthHandler(g *gin.RouterGroup, healthService service.IHealthService) {
+	r := &HealthHandler{
+		healthService: healthService,
+	}
+
+	g.GET("/live", r.Live)
+	g.GET("/ready", r.Ready)
+}
+
+// Live answers while the process serves requests, dependencies are not checked
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"status": dto.HealthStatusOk,
+		},
+	)
+}
+
+func (h *HealthHandler) Ready(c *gin.Context) {
+	resp := h.healthService.Ready(c.Request.Context())
+	if resp.Status != dto.HealthStatusOk {
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
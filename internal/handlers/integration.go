@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type IntegrationHandler struct {
+	integrationService service.IIntegrationService
+	validate           *validator.Validate
+}
+
+// NewIntegrationHandler registers the inbound webhooks of the Git hostings.
+// They are authenticated by the signature of the hosting, not by a bearer token.
+func NewIntegrationHandler(g *gin.RouterGroup, integrationService service.IIntegrationService, validate *validator.Validate) {
+	r := &IntegrationHandler{
+		integrationService: integrationService,
+		validate:           validate,
+	}
+
+	g.POST("/github/webhook", r.GitHubWebhook)
+	g.POST("/gitlab/webhook", r.GitLabWebhook)
+}
+
+// NewIdentityHandler registers the management of the hosting logins mapping.
+func NewIdentityHandler(g *gin.RouterGroup, integrationService service.IIntegrationService, validate *validator.Validate) {
+	r := &IntegrationHandler{
+		integrationService: integrationService,
+		validate:           validate,
+	}
+
+	g.Use(RequireRole(auth.RoleAdmin))
+
+	g.GET("", r.ListIdentities)
+	g.PUT("", r.SetIdentity)
+	g.DELETE("", r.DeleteIdentity)
+}
+
+func (h *IntegrationHandler) GitHubWebhook(c *gin.Context) {
+	h.receive(c, &dto.HostingDelivery{
+		Provider:   models.ProviderGitHub,
+		DeliveryId: c.GetHeader("X-GitHub-Delivery"),
+		Event:      c.GetHeader("X-GitHub-Event"),
+		Signature:  c.GetHeader("X-Hub-Signature-256"),
+	})
+}
+
+func (h *IntegrationHandler) GitLabWebhook(c *gin.Context) {
+	h.receive(c, &dto.HostingDelivery{
+		Provider:   models.ProviderGitLab,
+		DeliveryId: c.GetHeader("X-Gitlab-Event-UUID"),
+		Event:      c.GetHeader("X-Gitlab-Event"),
+		Signature:  c.GetHeader("X-Gitlab-Token"),
+	})
+}
+
+func (h *IntegrationHandler) receive(c *gin.Context, delivery *dto.HostingDelivery) {
+	//The signature is computed over the raw body
+	body, err := c.GetRawData()
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	delivery.Body = body
+
+	resp, err := h.integrationService.Receive(c.Request.Context(), delivery)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSignature) {
+			respondWithError(c, http.StatusUnauthorized, ErrStatusUnauthorized, err)
+			return
+		} else if errors.Is(err, service.ErrInvalidPayload) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+			return
+		} else if errors.Is(err, service.ErrIntegrationDisabled) || errors.Is(err, service.ErrIdentityNotMapped) || errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrPullRequestALreadyExists) {
+			respondWithError(c, http.StatusConflict, ErrStatusPrExists, err)
+			return
+		} else if errors.Is(err, service.ErrNoCandidate) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNoCandidate, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *IntegrationHandler) ListIdentities(c *gin.Context) {
+	var req dto.IdentityListRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	identities, err := h.integrationService.ListIdentities(c.Request.Context(), &req)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"identities": identities,
+		},
+	)
+}
+
+func (h *IntegrationHandler) SetIdentity(c *gin.Context) {
+	var req dto.IdentityRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	identity, err := h.integrationService.SetIdentity(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"identity": identity,
+		},
+	)
+}
+
+func (h *IntegrationHandler) DeleteIdentity(c *gin.Context) {
+	var req dto.IdentityDeleteRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	err := h.integrationService.DeleteIdentity(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics counts requests and their latency by the route template, not by the raw path,
+// so path parameters and unknown paths don't grow the number of series.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.HttpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HttpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// NewMetricsHandler serves the default registry in the Prometheus text format
+func NewMetricsHandler(r *gin.Engine) {
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+}
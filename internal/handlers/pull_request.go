@@ -5,42 +5,37 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/Estriper0/avito_intership/internal/auth"
 	"github.com/Estriper0/avito_intership/internal/handlers/dto"
 	"github.com/Estriper0/avito_intership/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/google/uuid"
 )
 
-const (
-	countWorker int = 5
-)
-
-type Task struct {
-	Id       uuid.UUID
-	TeamName string
-}
-
 type PullRequestHandler struct {
-	prService service.IPullRequestService
-	taskQueue chan Task
-	validate  *validator.Validate
+	prService  service.IPullRequestService
+	jobService service.IJobService
+	validate   *validator.Validate
 }
 
-func NewPullRequestHandler(g *gin.RouterGroup, prService service.IPullRequestService, validate *validator.Validate, taskQueue chan Task) {
+func NewPullRequestHandler(g *gin.RouterGroup, prService service.IPullRequestService, jobService service.IJobService, validate *validator.Validate) {
 	r := &PullRequestHandler{
-		prService: prService,
-		taskQueue: taskQueue,
-		validate:  validate,
+		prService:  prService,
+		jobService: jobService,
+		validate:   validate,
 	}
 
-	//Start workers to handle heavy tasks
-	startWorkers(r, countWorker)
-
 	g.POST("/create", r.Create)
 	g.POST("/merge", r.Merge)
+	g.POST("/review", r.SubmitReview)
+	g.POST("/ready", r.Ready)
+	g.POST("/close", r.Close)
+	g.POST("/reopen", r.Reopen)
 	g.POST("/reassign", r.Reassign)
-	g.POST("/reassign/team", r.ReassignAllInactiveReviewersByTeam)
+	g.POST("/reassign/team", RequireRole(auth.RoleTeamLead), r.ReassignAllInactiveReviewersByTeam)
+	g.GET("/get", r.Get)
+	g.GET("/list", r.List)
+	g.GET("/history", r.History)
 }
 
 func (h *PullRequestHandler) Create(c *gin.Context) {
@@ -61,9 +56,15 @@ func (h *PullRequestHandler) Create(c *gin.Context) {
 		if errors.Is(err, service.ErrPullRequestALreadyExists) {
 			respondWithError(c, http.StatusConflict, ErrStatusPrExists, err)
 			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
 		} else if errors.Is(err, service.ErrNotFound) {
 			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
 			return
+		} else if errors.Is(err, service.ErrNoCandidate) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNoCandidate, err)
+			return
 		}
 		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
 		return
@@ -89,15 +90,126 @@ func (h *PullRequestHandler) Merge(c *gin.Context) {
 		return
 	}
 
-	pr, err := h.prService.Merge(c.Request.Context(), req.PrId)
+	//Only admins can bypass the approval policy
+	if req.Force && !isAdmin(c) {
+		respondWithError(c, http.StatusForbidden, ErrStatusForbidden, errors.New("force merge requires admin role"))
+		return
+	}
+
+	pr, err := h.prService.Merge(c.Request.Context(), &req)
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
 			return
+		} else if errors.Is(err, service.ErrNotApproved) {
+			respondWithError(c, http.StatusConflict, ErrStatusNotApproved, err)
+			return
+		} else if errors.Is(err, service.ErrInvalidTransition) {
+			respondWithError(c, http.StatusConflict, ErrStatusTransition, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"pr": pr,
+		},
+	)
+}
+
+func (h *PullRequestHandler) SubmitReview(c *gin.Context) {
+	var req dto.SubmitReviewRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.prService.SubmitReview(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrReviewOnMerged) {
+			respondWithError(c, http.StatusConflict, ErrStatusPrMerged, err)
+			return
+		} else if errors.Is(err, service.ErrNotAssigned) {
+			respondWithError(c, http.StatusConflict, ErrStatusNotAssigned, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrPullRequestNotOpen) {
+			respondWithError(c, http.StatusConflict, ErrStatusPrNotOpen, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"review": resp,
+		},
+	)
+}
+
+func (h *PullRequestHandler) Ready(c *gin.Context) {
+	h.changeStatus(c, h.prService.Ready)
+}
+
+func (h *PullRequestHandler) Close(c *gin.Context) {
+	h.changeStatus(c, h.prService.Close)
+}
+
+func (h *PullRequestHandler) Reopen(c *gin.Context) {
+	h.changeStatus(c, h.prService.Reopen)
+}
+
+// Common handling of the lifecycle transitions
+func (h *PullRequestHandler) changeStatus(c *gin.Context, change func(ctx context.Context, prId string) (*dto.PullRequest, error)) {
+	var req dto.StatusChangeRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	pr, err := change(c.Request.Context(), req.PrId)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrInvalidTransition) {
+			respondWithError(c, http.StatusConflict, ErrStatusTransition, err)
+			return
+		} else if errors.Is(err, service.ErrNoCandidate) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNoCandidate, err)
+			return
 		}
 		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
 		return
 	}
+
 	c.JSON(
 		http.StatusOK,
 		gin.H{
@@ -127,9 +239,15 @@ func (h *PullRequestHandler) Reassign(c *gin.Context) {
 		} else if errors.Is(err, service.ErrPullRequestMerged) {
 			respondWithError(c, http.StatusConflict, ErrStatusPrMerged, err)
 			return
+		} else if errors.Is(err, service.ErrPullRequestNotOpen) {
+			respondWithError(c, http.StatusConflict, ErrStatusPrNotOpen, err)
+			return
 		} else if errors.Is(err, service.ErrNoCandidate) {
 			respondWithError(c, http.StatusNotFound, ErrStatusNoCandidate, err)
 			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
 		}
 		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
 		return
@@ -148,24 +266,108 @@ func (h *PullRequestHandler) ReassignAllInactiveReviewersByTeam(c *gin.Context)
 		return
 	}
 
-	go func() {
-		h.taskQueue <- Task{Id: uuid.New(), TeamName: teamName}
-	}()
+	//Heavy task is stored in the jobs table and processed by the worker pool
+	taskId, err := h.jobService.EnqueueReassignTeam(c.Request.Context(), teamName)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
 
 	c.JSON(
 		http.StatusOK,
 		gin.H{
 			"message": "The task has been received",
+			"task_id": taskId,
 		},
 	)
 }
 
-func startWorkers(h *PullRequestHandler, workerCount int) {
-	for i := 0; i < workerCount; i++ {
-		go func() {
-			for task := range h.taskQueue {
-				h.prService.ReassignAllInactiveReviewersByTeam(context.Background(), task.TeamName)
-			}
-		}()
+func (h *PullRequestHandler) Get(c *gin.Context) {
+	prId, ok := c.GetQuery("pull_request_id")
+	if !ok {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
+		return
+	}
+
+	pr, err := h.prService.Get(c.Request.Context(), prId)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"pr": pr,
+		},
+	)
+}
+
+func (h *PullRequestHandler) List(c *gin.Context) {
+	var req dto.PrListRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
+		return
 	}
+
+	//An empty team name filters nothing, the parameter is left out to list all teams
+	if teamName, ok := c.GetQuery("team_name"); ok && teamName == "" {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("team_name must not be empty"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.prService.List(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		resp,
+	)
+}
+
+func (h *PullRequestHandler) History(c *gin.Context) {
+	prId, ok := c.GetQuery("pull_request_id")
+	if !ok {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
+		return
+	}
+
+	resp, err := h.prService.History(c.Request.Context(), prId)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		resp,
+	)
 }
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubPrService implements only List, a call of any other method panics
+type stubPrService struct {
+	service.IPullRequestService
+	listed []*dto.PrListRequest
+}
+
+func (s *stubPrService) List(ctx context.Context, req *dto.PrListRequest) (*dto.PrListResponse, error) {
+	s.listed = append(s.listed, req)
+	return &dto.PrListResponse{}, nil
+}
+
+func TestPullRequestHandler_List_TeamName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	prService := &stubPrService{}
+	r := gin.New()
+	NewPullRequestHandler(r.Group("/pullRequest"), prService, nil, validator.New())
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTeam   string
+	}{
+		{name: "team", query: "?team_name=backend", wantStatus: http.StatusOK, wantTeam: "backend"},
+		{name: "no team filter", query: "", wantStatus: http.StatusOK},
+		{name: "empty team", query: "?team_name=", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prService.listed = nil
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pullRequest/list"+tt.query, nil))
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				assert.Empty(t, prService.listed)
+				return
+			}
+			require.Len(t, prService.listed, 1)
+			assert.Equal(t, tt.wantTeam, prService.listed[0].TeamName)
+		})
+	}
+}
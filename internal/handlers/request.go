@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	HeaderRequestId = "X-Request-ID"
+
+	maxRequestIdLength = 128
+)
+
+// RequestId keeps the X-Request-ID of the caller or assigns a new one. The id is returned
+// in the response header and carried by the request context into the logs and the span.
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(HeaderRequestId)
+		if !validRequestId(requestId) {
+			requestId = uuid.NewString()
+		}
+
+		c.Header(HeaderRequestId, requestId)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("http.request_id", requestId))
+		c.Request = c.Request.WithContext(logger.WithRequestId(c.Request.Context(), requestId))
+		c.Next()
+	}
+}
+
+// The id gets into the logs and the response headers, so only short printable ids are accepted
+func validRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	return !strings.ContainsFunc(requestId, func(r rune) bool {
+		return r < '!' || r > '~'
+	})
+}
+
+// AccessLog writes a record per request. Server errors are logged as errors and client errors as warnings,
+// probes and scrapes only at the debug level.
+func AccessLog(log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case route == "/metrics" || strings.HasPrefix(route, "/health/"):
+			level = slog.LevelDebug
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		//The caller is known only after the authentication
+		if principal, ok := auth.FromContext(c.Request.Context()); ok {
+			attrs = append(attrs, slog.String("user_id", principal.UserId), slog.String("role", principal.Role))
+		}
+		log.LogAttrs(c.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
+
+// Recovery turns a panic of a handler into the INTERNAL error, the panic and its stack are logged
+func Recovery(log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			//The client has gone, there is nobody to answer
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			log.ErrorContext(c.Request.Context(), "handlers.Recovery - Panic",
+				slog.String("panic", fmt.Sprint(recovered)),
+				slog.String("stack", string(debug.Stack())),
+			)
+			if !c.Writer.Written() {
+				respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, errors.New("internal error"))
+			}
+			c.Abort()
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Estriper0/avito_intership/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type logRecord struct {
+	level     slog.Level
+	message   string
+	attrs     map[string]any
+	requestId string
+}
+
+// recordHandler keeps the records together with the request id carried by their context
+type recordHandler struct {
+	mu      sync.Mutex
+	records []logRecord
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordHandler) Handle(ctx context.Context, r slog.Record) error {
+	record := logRecord{level: r.Level, message: r.Message, attrs: make(map[string]any)}
+	record.requestId, _ = logger.RequestIdFromContext(ctx)
+	r.Attrs(func(attr slog.Attr) bool {
+		record.attrs[attr.Key] = attr.Value.Any()
+		return true
+	})
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record)
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordHandler) WithGroup(string) slog.Handler { return h }
+
+func (h *recordHandler) find(message string) []logRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var found []logRecord
+	for _, record := range h.records {
+		if record.message == message {
+			found = append(found, record)
+		}
+	}
+	return found
+}
+
+// Router with the middlewares in the order of the app
+func requestRouter(handler *recordHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	log := slog.New(handler)
+
+	r := gin.New()
+	r.Use(RequestId(), AccessLog(log), Recovery(log))
+	r.GET("/ok", func(c *gin.Context) {
+		requestId, _ := logger.RequestIdFromContext(c.Request.Context())
+		log.InfoContext(c.Request.Context(), "handled")
+		c.JSON(http.StatusOK, gin.H{"request_id": requestId})
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	r.GET("/bad", func(c *gin.Context) {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, assert.AnError)
+	})
+	return r
+}
+
+func TestRequestId(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "kept", incoming: "req-123", keep: true},
+		{name: "generated when absent", incoming: ""},
+		{name: "replaced when too long", incoming: strings.Repeat("a", maxRequestIdLength+1)},
+		{name: "replaced when not printable", incoming: "req 123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &recordHandler{}
+			r := requestRouter(handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+			if tt.incoming != "" {
+				req.Header.Set(HeaderRequestId, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			requestId := w.Header().Get(HeaderRequestId)
+			if tt.keep {
+				assert.Equal(t, tt.incoming, requestId)
+			} else {
+				_, err := uuid.Parse(requestId)
+				assert.NoError(t, err, "a new id is a UUID")
+			}
+
+			//The handler gets the same id as the caller
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, requestId, body["request_id"])
+
+			//Records of the handler and of the access log carry the id in their context
+			handled := handler.find("handled")
+			require.Len(t, handled, 1)
+			assert.Equal(t, requestId, handled[0].requestId)
+
+			access := handler.find("HTTP request")
+			require.Len(t, access, 1)
+			assert.Equal(t, requestId, access[0].requestId)
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	handler := &recordHandler{}
+	r := requestRouter(handler)
+
+	for _, path := range []string{"/ok", "/bad", "/missing"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	access := handler.find("HTTP request")
+	require.Len(t, access, 3)
+
+	assert.Equal(t, slog.LevelInfo, access[0].level)
+	assert.Equal(t, "/ok", access[0].attrs["route"])
+	assert.Equal(t, int64(http.StatusOK), access[0].attrs["status"])
+
+	assert.Equal(t, slog.LevelWarn, access[1].level)
+	assert.Equal(t, int64(http.StatusBadRequest), access[1].attrs["status"])
+
+	assert.Equal(t, "unmatched", access[2].attrs["route"])
+	assert.Equal(t, "/missing", access[2].attrs["path"])
+}
+
+func TestRecovery(t *testing.T) {
+	handler := &recordHandler{}
+	r := requestRouter(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(HeaderRequestId, "req-panic")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, ErrStatusInternal, body.Error.Code)
+	//The panic value is not shown to the client
+	assert.Equal(t, "internal error", body.Error.Message)
+
+	panics := handler.find("handlers.Recovery - Panic")
+	require.Len(t, panics, 1)
+	assert.Equal(t, slog.LevelError, panics[0].level)
+	assert.Equal(t, "boom", panics[0].attrs["panic"])
+	assert.NotEmpty(t, panics[0].attrs["stack"])
+	assert.Equal(t, "req-panic", panics[0].requestId)
+
+	//The access log sees the INTERNAL response
+	access := handler.find("HTTP request")
+	require.Len(t, access, 1)
+	assert.Equal(t, slog.LevelError, access[0].level)
+	assert.Equal(t, int64(http.StatusInternalServerError), access[0].attrs["status"])
+}
+
+func TestRecovery_AbortHandler(t *testing.T) {
+	handler := &recordHandler{}
+	r := requestRouter(handler)
+	r.GET("/abort", func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	//http.Server handles ErrAbortHandler itself by dropping the connection
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+	assert.Empty(t, handler.find("handlers.Recovery - Panic"))
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type StatsHandler struct {
+	teamService service.ITeamService
+	validate    *validator.Validate
+}
+
+// NewStatsHandler registers the reports spanning several teams.
+func NewStatsHandler(g *gin.RouterGroup, teamService service.ITeamService, validate *validator.Validate) {
+	r := &StatsHandler{
+		teamService: teamService,
+		validate:    validate,
+	}
+
+	g.GET("/sla", r.SlaReport)
+}
+
+func (h *StatsHandler) SlaReport(c *gin.Context) {
+	var req dto.SlaReportRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	teams, err := h.teamService.SlaReport(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrInvalidPeriod) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"teams": teams,
+		},
+	)
+}
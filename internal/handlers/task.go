@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Estriper0/avito_intership/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type TaskHandler struct {
+	jobService service.IJobService
+	validate   *validator.Validate
+}
+
+func NewTaskHandler(g *gin.RouterGroup, jobService service.IJobService, validate *validator.Validate) {
+	r := &TaskHandler{
+		jobService: jobService,
+		validate:   validate,
+	}
+
+	g.GET("/:id", r.Get)
+}
+
+func (h *TaskHandler) Get(c *gin.Context) {
+	taskId := c.Param("id")
+
+	if err := h.validate.Var(taskId, "uuid"); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid task id"))
+		return
+	}
+
+	task, err := h.jobService.Get(c.Request.Context(), taskId)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		task,
+	)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/Estriper0/avito_intership/internal/auth"
 	"github.com/Estriper0/avito_intership/internal/handlers/dto"
 	"github.com/Estriper0/avito_intership/internal/service"
 	"github.com/gin-gonic/gin"
@@ -21,9 +22,18 @@ func NewTeamHandler(g *gin.RouterGroup, teamService service.ITeamService, valida
 		validate:    validate,
 	}
 
-	g.POST("/add", r.Add)
+	g.POST("/add", RequireRole(auth.RoleTeamLead), r.Add)
 	g.GET("/get", r.Get)
 	g.GET("/stats/pull_request", r.GetStatsPR)
+	g.POST("/settings", RequireRole(auth.RoleTeamLead), r.UpdateSettings)
+	g.POST("/members/add", RequireRole(auth.RoleTeamLead), r.AddMembers)
+	g.POST("/members/remove", RequireRole(auth.RoleTeamLead), r.RemoveMember)
+	g.POST("/rename", RequireRole(auth.RoleTeamLead), r.Rename)
+	g.POST("/delete", RequireRole(auth.RoleAdmin), r.Delete)
+	g.GET("/fallbacks", r.GetFallbacks)
+	g.POST("/fallbacks", RequireRole(auth.RoleTeamLead), r.SetFallbacks)
+	g.GET("/codeowners", r.GetCodeOwners)
+	g.POST("/codeowners", RequireRole(auth.RoleTeamLead), r.SetCodeOwners)
 }
 
 func (h *TeamHandler) Add(c *gin.Context) {
@@ -44,6 +54,12 @@ func (h *TeamHandler) Add(c *gin.Context) {
 		if errors.Is(err, service.ErrTeamAlreadyExists) {
 			respondWithError(c, http.StatusBadRequest, ErrStatusTeamExists, err)
 			return
+		} else if errors.Is(err, service.ErrInvalidReviewersSettings) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+			return
+		} else if errors.Is(err, service.ErrUserInOtherTeam) {
+			respondWithError(c, http.StatusConflict, ErrStatusOtherTeam, err)
+			return
 		}
 		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
 		return
@@ -81,13 +97,226 @@ func (h *TeamHandler) Get(c *gin.Context) {
 }
 
 func (h *TeamHandler) GetStatsPR(c *gin.Context) {
+	var req dto.TeamStatsPrRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.teamService.GetStatsPR(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrInvalidPeriod) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		resp,
+	)
+}
+
+func (h *TeamHandler) UpdateSettings(c *gin.Context) {
+	var req dto.TeamSettingsRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.teamService.UpdateSettings(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrInvalidReviewersSettings) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"team": resp,
+		},
+	)
+}
+
+func (h *TeamHandler) AddMembers(c *gin.Context) {
+	var req dto.TeamMembersRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	team, err := h.teamService.AddMembers(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrUserInOtherTeam) {
+			respondWithError(c, http.StatusConflict, ErrStatusOtherTeam, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"team": team,
+		},
+	)
+}
+
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	var req dto.TeamMemberRemoveRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.teamService.RemoveMember(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) || errors.Is(err, service.ErrNotTeamMember) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrOpenPullRequests) {
+			respondWithError(c, http.StatusConflict, ErrStatusOpenPrs, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		resp,
+	)
+}
+
+func (h *TeamHandler) Rename(c *gin.Context) {
+	var req dto.TeamRenameRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.teamService.Rename(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrTeamAlreadyExists) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusTeamExists, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"team": resp,
+		},
+	)
+}
+
+func (h *TeamHandler) Delete(c *gin.Context) {
+	var req dto.TeamDeleteRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.teamService.Delete(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrOpenPullRequests) {
+			respondWithError(c, http.StatusConflict, ErrStatusOpenPrs, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		resp,
+	)
+}
+
+func (h *TeamHandler) GetFallbacks(c *gin.Context) {
 	teamName, ok := c.GetQuery("team_name")
 	if !ok {
 		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
 		return
 	}
 
-	resp, err := h.teamService.GetStatsPR(c.Request.Context(), teamName)
+	resp, err := h.teamService.GetFallbacks(c.Request.Context(), teamName)
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
@@ -99,6 +328,107 @@ func (h *TeamHandler) GetStatsPR(c *gin.Context) {
 
 	c.JSON(
 		http.StatusOK,
-		resp,
+		gin.H{
+			"team": resp,
+		},
+	)
+}
+
+func (h *TeamHandler) SetFallbacks(c *gin.Context) {
+	var req dto.TeamFallbacksRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.teamService.SetFallbacks(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrInvalidFallbacks) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"team": resp,
+		},
+	)
+}
+
+func (h *TeamHandler) GetCodeOwners(c *gin.Context) {
+	teamName, ok := c.GetQuery("team_name")
+	if !ok {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
+		return
+	}
+
+	resp, err := h.teamService.GetCodeOwners(c.Request.Context(), teamName)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"team": resp,
+		},
+	)
+}
+
+func (h *TeamHandler) SetCodeOwners(c *gin.Context) {
+	var req dto.CodeOwnersRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.teamService.SetCodeOwners(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrInvalidCodeOwners) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"team": resp,
+		},
 	)
 }
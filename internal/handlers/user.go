@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/Estriper0/avito_intership/internal/auth"
 	"github.com/Estriper0/avito_intership/internal/handlers/dto"
 	"github.com/Estriper0/avito_intership/internal/service"
 	"github.com/gin-gonic/gin"
@@ -21,10 +22,16 @@ func NewUserHandler(g *gin.RouterGroup, userService service.IUserService, valida
 		validate:    validate,
 	}
 
-	g.POST("/setIsActive", r.SetIsActive)
+	g.POST("/setIsActive", RequireRole(auth.RoleTeamLead), r.SetIsActive)
 	g.GET("/getReview", r.GetReview)
 	g.GET("/stats/review", r.GetStatsReview)
-	g.POST("/massDeactivation", r.MassDeactivation)
+	g.GET("/stats", r.GetReviewStats)
+	g.POST("/massDeactivation", RequireRole(auth.RoleAdmin), r.MassDeactivation)
+	g.POST("/move", RequireRole(auth.RoleTeamLead), r.Move)
+	//Members manage their own absences, the service checks the caller
+	g.POST("/availability", r.AddAbsence)
+	g.GET("/availability", r.GetAbsences)
+	g.DELETE("/availability", r.DeleteAbsence)
 }
 
 func (h *UserHandler) SetIsActive(c *gin.Context) {
@@ -40,20 +47,21 @@ func (h *UserHandler) SetIsActive(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.SetIsActive(c.Request.Context(), &req)
+	resp, err := h.userService.SetIsActive(c.Request.Context(), &req)
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
 			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
 		}
 		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
 		return
 	}
 	c.JSON(
 		http.StatusOK,
-		gin.H{
-			"user": user,
-		},
+		resp,
 	)
 }
 
@@ -92,6 +100,42 @@ func (h *UserHandler) GetStatsReview(c *gin.Context) {
 	)
 }
 
+func (h *UserHandler) GetReviewStats(c *gin.Context) {
+	var req dto.UserStatsRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.userService.GetReviewStats(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrInvalidPeriod) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"users": resp,
+		},
+	)
+}
+
 func (h *UserHandler) MassDeactivation(c *gin.Context) {
 	var req dto.MassDeactivationRequest
 
@@ -119,3 +163,124 @@ func (h *UserHandler) MassDeactivation(c *gin.Context) {
 		resp,
 	)
 }
+
+func (h *UserHandler) Move(c *gin.Context) {
+	var req dto.UserMoveRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.userService.Move(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		resp,
+	)
+}
+
+func (h *UserHandler) AddAbsence(c *gin.Context) {
+	var req dto.AbsenceRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	absence, err := h.userService.AddAbsence(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		} else if errors.Is(err, service.ErrInvalidAbsence) {
+			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+	c.JSON(
+		http.StatusCreated,
+		gin.H{
+			"absence": absence,
+		},
+	)
+}
+
+func (h *UserHandler) GetAbsences(c *gin.Context) {
+	userId, ok := c.GetQuery("user_id")
+	if !ok {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
+		return
+	}
+
+	absences, err := h.userService.GetAbsences(c.Request.Context(), userId)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"user_id":  userId,
+			"absences": absences,
+		},
+	)
+}
+
+func (h *UserHandler) DeleteAbsence(c *gin.Context) {
+	var req dto.AbsenceDeleteRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	err := h.userService.DeleteAbsence(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		} else if errors.Is(err, service.ErrForbidden) {
+			respondWithError(c, http.StatusForbidden, ErrStatusForbidden, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
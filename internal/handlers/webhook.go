@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type WebhookHandler struct {
+	webhookService service.IWebhookService
+	validate       *validator.Validate
+}
+
+func NewWebhookHandler(g *gin.RouterGroup, webhookService service.IWebhookService, validate *validator.Validate) {
+	r := &WebhookHandler{
+		webhookService: webhookService,
+		validate:       validate,
+	}
+
+	//Subscriptions hold signing secrets, so they are managed by admins only
+	g.Use(RequireRole(auth.RoleAdmin))
+
+	g.POST("", r.Create)
+	g.GET("", r.List)
+	g.GET("/:id", r.Get)
+	g.PUT("/:id", r.Update)
+	g.DELETE("/:id", r.Delete)
+	g.GET("/:id/deliveries", r.GetDeliveries)
+}
+
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req dto.WebhookRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	webhook, err := h.webhookService.Create(c.Request.Context(), &req)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated,
+		gin.H{
+			"webhook": webhook,
+		},
+	)
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	webhooks, err := h.webhookService.List(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"webhooks": webhooks,
+		},
+	)
+}
+
+func (h *WebhookHandler) Get(c *gin.Context) {
+	webhookId, ok := webhookIdParam(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Get(c.Request.Context(), webhookId)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"webhook": webhook,
+		},
+	)
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	webhookId, ok := webhookIdParam(c)
+	if !ok {
+		return
+	}
+
+	var req dto.WebhookRequest
+
+	if err := c.Bind(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	webhook, err := h.webhookService.Update(c.Request.Context(), webhookId, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"webhook": webhook,
+		},
+	)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	webhookId, ok := webhookIdParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.webhookService.Delete(c.Request.Context(), webhookId)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	webhookId, ok := webhookIdParam(c)
+	if !ok {
+		return
+	}
+
+	var req dto.WebhookDeliveriesRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid query params"))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(c.Request.Context(), webhookId, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"deliveries": deliveries,
+		},
+	)
+}
+
+// Parses the webhook id from the path, responds with BAD_REQUEST if it is invalid
+func webhookIdParam(c *gin.Context) (int, bool) {
+	webhookId, err := strconv.Atoi(c.Param("id"))
+	if err != nil || webhookId <= 0 {
+		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("invalid webhook id"))
+		return 0, false
+	}
+	return webhookId, true
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIdKey struct{}
+
+// WithRequestId puts the id of the HTTP request into the context, it is added to every log record of the request
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+func RequestIdFromContext(ctx context.Context) (string, bool) {
+	requestId, ok := ctx.Value(requestIdKey{}).(string)
+	return requestId, ok
+}
+
+// contextHandler adds the values carried by the context of the record, the request id and the trace ids,
+// so the logs written with the *Context methods can be matched to the requests and the traces.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestId, ok := RequestIdFromContext(ctx); ok {
+		r.AddAttrs(slog.String("request_id", requestId))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	buf.Reset()
+	return record
+}
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(contextHandler{slog.NewJSONHandler(&buf, nil)})
+
+	traceId, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanId, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	ctx := WithRequestId(context.Background(), "req-1")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceId,
+		SpanID:  spanId,
+	}))
+
+	log.InfoContext(ctx, "with context")
+	record := decode(t, &buf)
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, traceId.String(), record["trace_id"])
+	assert.Equal(t, spanId.String(), record["span_id"])
+
+	log.InfoContext(context.Background(), "without context")
+	record = decode(t, &buf)
+	assert.NotContains(t, record, "request_id")
+	assert.NotContains(t, record, "trace_id")
+
+	//Derived loggers keep adding the values
+	log.With(slog.String("component", "worker")).InfoContext(ctx, "derived")
+	record = decode(t, &buf)
+	assert.Equal(t, "worker", record["component"])
+	assert.Equal(t, "req-1", record["request_id"])
+}
+
+func TestRequestIdFromContext(t *testing.T) {
+	_, ok := RequestIdFromContext(context.Background())
+	assert.False(t, ok)
+
+	requestId, ok := RequestIdFromContext(WithRequestId(context.Background(), "req-1"))
+	require.True(t, ok)
+	assert.Equal(t, "req-1", requestId)
+}
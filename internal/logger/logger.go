@@ -10,23 +10,23 @@ import (
 func GetLogger(env string) *slog.Logger {
 	switch env {
 	case "local":
-		return slog.New(
+		return slog.New(contextHandler{
 			tint.NewHandler(
 				os.Stdout,
 				&tint.Options{
 					Level: slog.LevelDebug,
 				},
 			),
-		)
+		})
 	case "prod":
-		return slog.New(
+		return slog.New(contextHandler{
 			slog.NewJSONHandler(
 				os.Stdout,
 				&slog.HandlerOptions{
 					Level: slog.LevelInfo,
 				},
 			),
-		)
+		})
 	}
 	return nil
 }
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The queue is counted by the database on every scrape, a slow scrape must not hang
+const queueCountTimeout = 5 * time.Second
+
+// QueueCollector exports the depth of the jobs queue shared by all replicas.
+type QueueCollector struct {
+	jobRepo repository.IJobRepo
+	logger  *slog.Logger
+	depth   *prometheus.Desc
+}
+
+func NewQueueCollector(jobRepo repository.IJobRepo, logger *slog.Logger) *QueueCollector {
+	return &QueueCollector{
+		jobRepo: jobRepo,
+		logger:  logger,
+		depth: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "jobs", "queue_depth"),
+			"Jobs waiting in the queue or being processed by job type and status.",
+			[]string{"type", "status"},
+			nil,
+		),
+	}
+}
+
+func (c *QueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+}
+
+func (c *QueueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueCountTimeout)
+	defer cancel()
+
+	counts, err := c.jobRepo.CountPending(ctx)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "metrics.QueueCollector.Collect:jobRepo.CountPending - Internal error", slog.String("error", err.Error()))
+		return
+	}
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(count.Count), count.Type, count.Status)
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "pr_reviewer"
+
+// Collectors are registered in the default registry served on /metrics
+var (
+	HttpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HttpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	PullRequestsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_created_total",
+		Help:      "Created pull requests.",
+	})
+
+	PullRequestsMerged = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_merged_total",
+		Help:      "Merged pull requests.",
+	})
+
+	//Cause is the reason written to the PR history, e.g. manual, deactivation or sla
+	Reassignments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reassignments_total",
+		Help:      "Reviewer reassignments by cause.",
+	}, []string{"cause"})
+
+	//Operation is assign for new reviewers or reassign for a replacement
+	NoCandidate = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "no_candidate_total",
+		Help:      "Reviewer selections without any candidate.",
+	}, []string{"operation"})
+
+	WorkerBusy = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "worker",
+		Name:      "busy_seconds_total",
+		Help:      "Time the workers spent processing jobs by job type.",
+	}, []string{"type"})
+
+	WorkerJobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "worker",
+		Name:      "jobs_processed_total",
+		Help:      "Processed jobs by job type and result: succeeded, retried, failed or lost (the lease was taken by another worker).",
+	}, []string{"type", "result"})
+)
@@ -0,0 +1,10 @@
+package models
+
+// CodeOwnerRule maps a CODEOWNERS-style pattern of changed files to their owners
+type CodeOwnerRule struct {
+	Pattern string
+	UsersId []string
+	TeamsId []int
+	//Names of the owner teams, filled on read
+	TeamNames []string
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Supported Git hostings
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Identity maps a login on the Git hosting to our user
+type Identity struct {
+	Provider  string
+	Login     string
+	UserId    string
+	CreatedAt time.Time
+}
+
+type IntegrationDelivery struct {
+	Provider   string
+	DeliveryId string
+	Event      string
+	Action     string
+	PrId       string
+}
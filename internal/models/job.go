@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+const (
+	JobReassignTeam    = "reassign_team"
+	JobWebhookDelivery = "webhook_delivery"
+)
+
+// JobCount is the number of jobs of a type in a status
+type JobCount struct {
+	Type   string
+	Status string
+	Count  int
+}
+
+type Job struct {
+	Id          string
+	Type        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LastError   string
+	Result      json.RawMessage
+	//Progress of the job, e.g. processed reviewer slots
+	ProgressProcessed int
+	ProgressTotal     int
+	//Trace of the request that enqueued the job, continued by the worker
+	TraceContext map[string]string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// WorkerStatus is the state of the job workers of this replica
+type WorkerStatus struct {
+	Running bool
+	Count   int
+	//Workers processing a job right now
+	Busy int
+}
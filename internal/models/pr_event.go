@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+const (
+	EventCreated       = "created"
+	EventStatusChanged = "status_changed"
+	EventAssigned      = "assigned"
+	EventReassigned    = "reassigned"
+	EventReviewed      = "reviewed"
+	EventMerged        = "merged"
+	//The reviewer made no decision within the review SLA of the team
+	EventSlaBreached = "sla_breached"
+)
+
+// Reasons of the reassigned event
+const (
+	ReasonManual       = "manual"
+	ReasonMass         = "mass"
+	ReasonDeactivation = "deactivation"
+	//The reviewer moved to another team or was removed from the team
+	ReasonTeamChange = "team_change"
+	ReasonForce      = "force"
+	//The reviewer is taken from a fallback team of the author's team, also set on the assigned event
+	ReasonFallback = "fallback"
+	//The reviewer owns changed files of the PR, set on the assigned event
+	ReasonCodeOwner = "code_owner"
+	//The absence of the reviewer started
+	ReasonAbsence = "absence"
+	//The reviewer breached the review SLA
+	ReasonSla = "sla"
+)
+
+// Actors of changes made without a user, e.g. static admin tokens or background jobs
+const (
+	ActorAdmin  = "admin"
+	ActorSystem = "system"
+)
+
+type PrEvent struct {
+	Id            int64
+	PrId          string
+	Type          string
+	Actor         string
+	ReviewerId    string
+	OldReviewerId string
+	Reason        string
+	CreatedAt     time.Time
+}
@@ -2,16 +2,60 @@ package models
 
 import "time"
 
+// Ids of the rows seeded into pull_requests_statuses
+const (
+	StatusOpen   = 1
+	StatusMerged = 2
+	StatusDraft  = 3
+	StatusClosed = 4
+)
+
+const (
+	DecisionApproved         = "APPROVED"
+	DecisionChangesRequested = "CHANGES_REQUESTED"
+	DecisionCommented        = "COMMENTED"
+)
+
 type PullRequest struct {
+	PrId        string
+	Name        string
+	AuthorId    string
+	StatusId    int
+	CreatedAt   time.Time
+	MergedAt    *time.Time
+	ClosedAt    *time.Time
+	ForceMerged bool
+}
+
+type PullRequestFilter struct {
+	TeamName    string
+	AuthorId    string
+	ReviewerId  string
+	Status      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	//Keyset pagination: PRs strictly after (AfterCreatedAt, AfterPrId) in the list order
+	AfterCreatedAt *time.Time
+	AfterPrId      string
+	Limit          int
+}
+
+type Review struct {
 	PrId      string
-	Name      string
-	AuthorId  string
-	StatusId  int
-	CreatedAt time.Time
-	MergedAt  time.Time
+	UserId    string
+	Decision  string
+	DecidedAt *time.Time
+}
+
+// ReviewerChange replaces the old reviewer of the PR with the new one
+type ReviewerChange struct {
+	PrId          string
+	OldReviewerId string
+	NewReviewerId string
 }
 
 type InactiveReviewers struct {
-	PrId   string
-	UserId string
+	PrId     string
+	UserId   string
+	AuthorId string
 }
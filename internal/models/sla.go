@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// SlaBreach is a reviewer who has made no decision within the review SLA of the author's team
+type SlaBreach struct {
+	PrId       string
+	ReviewerId string
+	AuthorId   string
+	TeamId     int
+	//Taken from the team settings, the breach is reassigned by the sweeper
+	AutoReassign bool
+	AssignedAt   time.Time
+	//Nil until the sweeper marks the breach
+	BreachedAt *time.Time
+}
+
+type TeamSlaStats struct {
+	TeamId         int
+	Name           string
+	ReviewSlaHours int
+	Breaches       int
+	Reassigned     int
+}
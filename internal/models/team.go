@@ -1,13 +1,48 @@
 package models
 
+import "time"
+
 type Team struct {
-	Id   int
-	Name string
+	Id                int
+	Name              string
+	MinReviewers      int
+	MaxReviewers      int
+	RequiredApprovals int
+	//Hours a reviewer has for a decision, 0 - no SLA
+	ReviewSlaHours int
+	//Reassign reviewers who breached the SLA
+	SlaAutoReassign bool
 }
 
 type TeamStatsPR struct {
-	Name     string
-	TotalPr  int
-	OpenPr   int
-	MergedPr int
+	Name            string
+	TotalPr         int
+	OpenPr          int
+	MergedPr        int
+	DraftPr         int
+	ClosedPr        int
+	UnderReviewedPr int
+}
+
+// TeamLeadTimes holds the percentiles in seconds, they are nil if there is nothing to measure
+type TeamLeadTimes struct {
+	MergedCount       int
+	MergeMedian       *float64
+	MergeP90          *float64
+	ReviewedCount     int
+	FirstReviewMedian *float64
+	FirstReviewP90    *float64
+}
+
+type WeeklyCount struct {
+	//Monday of the week, UTC
+	Week  time.Time
+	Count int
+}
+
+type ReviewLoad struct {
+	UserId      string
+	Username    string
+	Reviews     int
+	OpenReviews int
 }
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type User struct {
 	UserId   string
 	Username string
@@ -12,3 +14,37 @@ type UserStatsReview struct {
 	Username        string
 	CountOpenReview int
 }
+
+// UserReviewStats is the review history of a user within a period
+type UserReviewStats struct {
+	UserId           string
+	Username         string
+	TeamName         string
+	TotalReviews     int
+	OpenReviews      int
+	CompletedReviews int
+	ReassignedAway   int
+	//From the assignment to the decision, nil without decided reviews
+	AvgReviewSeconds *float64
+	AuthoredPr       int
+}
+
+type UserStatsFilter struct {
+	TeamName string
+	From     *time.Time
+	To       *time.Time
+	SortBy   string
+	Desc     bool
+}
+
+// Absence is a period when the user is not a review candidate, e.g. a vacation
+type Absence struct {
+	Id       int
+	UserId   string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Reason   string
+	//Set once the open reviews of the user are reassigned
+	ReassignedAt *time.Time
+	CreatedAt    time.Time
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Events which can be subscribed to
+const (
+	WebhookPrCreated            = "pr.created"
+	WebhookPrReviewerReassigned = "pr.reviewer_reassigned"
+	WebhookPrMerged             = "pr.merged"
+	WebhookPrReviewSlaBreached  = "pr.review_sla_breached"
+	WebhookUserDeactivated      = "user.deactivated"
+	WebhookTeamCreated          = "team.created"
+)
+
+type Webhook struct {
+	Id        int
+	Url       string
+	Secret    string
+	Events    []string
+	IsActive  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type WebhookDelivery struct {
+	Id         int64
+	WebhookId  int
+	JobId      string
+	Event      string
+	Attempt    int
+	StatusCode int
+	Error      string
+	DurationMs int64
+	CreatedAt  time.Time
+}
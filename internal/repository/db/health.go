@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Estriper0/avito_intership/internal/repository"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type HealthRepo struct {
+	db     *pgxpool.Pool
+	getter *trmpgx.CtxGetter
+}
+
+func NewHealthRepo(db *pgxpool.Pool, c *trmpgx.CtxGetter) *HealthRepo {
+	return &HealthRepo{
+		db:     db,
+		getter: c,
+	}
+}
+
+// Ping acquires a connection of the pool and checks it
+func (r *HealthRepo) Ping(ctx context.Context) error {
+	if err := r.db.Ping(ctx); err != nil {
+		return fmt.Errorf("db:HealthRepo.Ping - %s", err.Error())
+	}
+	return nil
+}
+
+// GetMigrationVersion returns the version written by golang-migrate, dirty is set by a failed migration
+func (r *HealthRepo) GetMigrationVersion(ctx context.Context) (uint, bool, error) {
+	query := `
+		SELECT version, dirty
+		FROM schema_migrations
+		LIMIT 1
+	`
+	var version int64
+	var dirty bool
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query).Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, repository.ErrNotFound
+		}
+		return 0, false, fmt.Errorf("db:HealthRepo.GetMigrationVersion:QueryRow - %s", err.Error())
+	}
+
+	return uint(version), dirty, nil
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IntegrationRepo struct {
+	db     *pgxpool.Pool
+	getter *trmpgx.CtxGetter
+}
+
+func NewIntegrationRepo(db *pgxpool.Pool, c *trmpgx.CtxGetter) *IntegrationRepo {
+	return &IntegrationRepo{
+		db:     db,
+		getter: c,
+	}
+}
+
+// SetIdentity creates the mapping or points the existing login to another user.
+func (r *IntegrationRepo) SetIdentity(ctx context.Context, identity *models.Identity) (*models.Identity, error) {
+	query := `
+		INSERT INTO identity_mappings (provider, login, user_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (provider, login) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING provider, login, user_id, created_at
+	`
+
+	var i models.Identity
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, identity.Provider, identity.Login, identity.UserId).Scan(
+		&i.Provider,
+		&i.Login,
+		&i.UserId,
+		&i.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:IntegrationRepo.SetIdentity:QueryRow - %s", err.Error())
+	}
+	return &i, nil
+}
+
+func (r *IntegrationRepo) GetUserIdByLogin(ctx context.Context, provider string, login string) (string, error) {
+	query := `
+		SELECT user_id
+		FROM identity_mappings
+		WHERE provider = $1 AND login = $2
+	`
+
+	var userId string
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, provider, login).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", repository.ErrNotFound
+		}
+		return "", fmt.Errorf("db:IntegrationRepo.GetUserIdByLogin:QueryRow - %s", err.Error())
+	}
+	return userId, nil
+}
+
+// GetIdentities returns the mappings of the provider, all of them if the provider is empty.
+func (r *IntegrationRepo) GetIdentities(ctx context.Context, provider string) ([]models.Identity, error) {
+	query := `
+		SELECT provider, login, user_id, created_at
+		FROM identity_mappings
+		WHERE $1 = '' OR provider = $1
+		ORDER BY provider, login
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, provider)
+	if err != nil {
+		return nil, fmt.Errorf("db:IntegrationRepo.GetIdentities:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	identities := make([]models.Identity, 0)
+	for rows.Next() {
+		var i models.Identity
+		if err := rows.Scan(&i.Provider, &i.Login, &i.UserId, &i.CreatedAt); err != nil {
+			return nil, fmt.Errorf("db:IntegrationRepo.GetIdentities:Scan - %s", err.Error())
+		}
+		identities = append(identities, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:IntegrationRepo.GetIdentities:rows - %s", err.Error())
+	}
+	return identities, nil
+}
+
+func (r *IntegrationRepo) DeleteIdentity(ctx context.Context, provider string, login string) error {
+	query := `
+		DELETE FROM identity_mappings WHERE provider = $1 AND login = $2
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, provider, login)
+	if err != nil {
+		return fmt.Errorf("db:IntegrationRepo.DeleteIdentity:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// AddDelivery records an inbound delivery, ErrAlreadyExists means it was processed before.
+// A concurrent replay waits on the primary key until the first one is committed or rolled back.
+func (r *IntegrationRepo) AddDelivery(ctx context.Context, delivery *models.IntegrationDelivery) error {
+	query := `
+		INSERT INTO integration_deliveries (provider, delivery_id, event, action, pr_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		ON CONFLICT (provider, delivery_id) DO NOTHING
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, delivery.Provider, delivery.DeliveryId, delivery.Event, delivery.Action, delivery.PrId)
+	if err != nil {
+		return fmt.Errorf("db:IntegrationRepo.AddDelivery:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrAlreadyExists
+	}
+	return nil
+}
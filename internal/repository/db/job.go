@@ -0,0 +1,275 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const jobColumns = `id::text, type, payload, status, attempts, max_attempts, run_at, COALESCE(last_error, ''), result, progress_processed, progress_total, trace_context, created_at, updated_at`
+
+type JobRepo struct {
+	db     *pgxpool.Pool
+	getter *trmpgx.CtxGetter
+}
+
+func NewJobRepo(db *pgxpool.Pool, c *trmpgx.CtxGetter) *JobRepo {
+	return &JobRepo{
+		db:     db,
+		getter: c,
+	}
+}
+
+func (r *JobRepo) Create(ctx context.Context, job *models.Job) (*models.Job, error) {
+	query := `
+		INSERT INTO jobs (id, type, payload, max_attempts, trace_context)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + jobColumns
+
+	traceContext := job.TraceContext
+	if traceContext == nil {
+		traceContext = map[string]string{}
+	}
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	created, err := scanJob(conn.QueryRow(ctx, query, job.Id, job.Type, job.Payload, job.MaxAttempts, traceContext))
+	if err != nil {
+		return nil, fmt.Errorf("db:JobRepo.Create:QueryRow - %s", err.Error())
+	}
+	return created, nil
+}
+
+func (r *JobRepo) GetById(ctx context.Context, jobId string) (*models.Job, error) {
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE id = $1
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	job, err := scanJob(conn.QueryRow(ctx, query, jobId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:JobRepo.GetById:QueryRow - %s", err.Error())
+	}
+	return job, nil
+}
+
+// Lease takes the oldest due job and marks it running until the lease expires.
+// Jobs of crashed workers become available again once their lease is over, unless the attempts are used up.
+func (r *JobRepo) Lease(ctx context.Context, leaseFor time.Duration) (*models.Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'running',
+			attempts = attempts + 1,
+			locked_until = NOW() + $1::float8 * INTERVAL '1 millisecond',
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = 'queued' AND run_at <= NOW())
+				OR (status = 'running' AND locked_until < NOW() AND attempts < max_attempts)
+			ORDER BY run_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	job, err := scanJob(conn.QueryRow(ctx, query, leaseFor.Milliseconds()))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:JobRepo.Lease:QueryRow - %s", err.Error())
+	}
+	return job, nil
+}
+
+// The attempt counter is the lease token: every lease increments it, so the updates below
+// succeed only for the worker holding the current lease, others get ErrLeaseLost.
+const leaseOwner = `id = $1 AND status = 'running' AND attempts = $2`
+
+// Extend prolongs the lease of a running job while its handler works
+func (r *JobRepo) Extend(ctx context.Context, jobId string, attempt int, leaseFor time.Duration) error {
+	query := `
+		UPDATE jobs
+		SET locked_until = NOW() + $3::float8 * INTERVAL '1 millisecond', updated_at = NOW()
+		WHERE ` + leaseOwner
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, jobId, attempt, leaseFor.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("db:JobRepo.Extend:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrLeaseLost
+	}
+	return nil
+}
+
+func (r *JobRepo) Complete(ctx context.Context, jobId string, attempt int, result json.RawMessage) error {
+	query := `
+		UPDATE jobs
+		SET status = 'succeeded', result = $3, last_error = NULL, locked_until = NULL, updated_at = NOW()
+		WHERE ` + leaseOwner
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, jobId, attempt, result)
+	if err != nil {
+		return fmt.Errorf("db:JobRepo.Complete:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrLeaseLost
+	}
+	return nil
+}
+
+func (r *JobRepo) Retry(ctx context.Context, jobId string, attempt int, lastError string, runAt time.Time) error {
+	query := `
+		UPDATE jobs
+		SET status = 'queued', last_error = $3, run_at = $4, locked_until = NULL, updated_at = NOW()
+		WHERE ` + leaseOwner
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, jobId, attempt, lastError, runAt)
+	if err != nil {
+		return fmt.Errorf("db:JobRepo.Retry:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrLeaseLost
+	}
+	return nil
+}
+
+func (r *JobRepo) Fail(ctx context.Context, jobId string, attempt int, lastError string) error {
+	query := `
+		UPDATE jobs
+		SET status = 'failed', last_error = $3, locked_until = NULL, updated_at = NOW()
+		WHERE ` + leaseOwner
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, jobId, attempt, lastError)
+	if err != nil {
+		return fmt.Errorf("db:JobRepo.Fail:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrLeaseLost
+	}
+	return nil
+}
+
+// FailExpired marks failed the running jobs whose lease is over after the last attempt,
+// e.g. a job killing its worker every time. Returns the ids of the failed jobs.
+func (r *JobRepo) FailExpired(ctx context.Context) ([]string, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'failed', last_error = 'lease expired on the last attempt', locked_until = NULL, updated_at = NOW()
+		WHERE status = 'running' AND locked_until < NOW() AND attempts >= max_attempts
+		RETURNING id
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("db:JobRepo.FailExpired:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var jobsId []string
+	for rows.Next() {
+		var jobId string
+		if err := rows.Scan(&jobId); err != nil {
+			return nil, fmt.Errorf("db:JobRepo.FailExpired:Scan - %s", err.Error())
+		}
+		jobsId = append(jobsId, jobId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:JobRepo.FailExpired:rows - %s", err.Error())
+	}
+	return jobsId, nil
+}
+
+// UpdateProgress always writes outside the current transaction,
+// so the progress of a job is visible before the job transaction is committed.
+func (r *JobRepo) UpdateProgress(ctx context.Context, jobId string, processed int, total int) error {
+	query := `
+		UPDATE jobs
+		SET progress_processed = $2, progress_total = $3, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	tag, err := r.db.Exec(ctx, query, jobId, processed, total)
+	if err != nil {
+		return fmt.Errorf("db:JobRepo.UpdateProgress:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func scanJob(row pgx.Row) (*models.Job, error) {
+	var job models.Job
+	err := row.Scan(
+		&job.Id,
+		&job.Type,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&job.LastError,
+		&job.Result,
+		&job.ProgressProcessed,
+		&job.ProgressTotal,
+		&job.TraceContext,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CountPending counts the queued and running jobs by type and status
+func (r *JobRepo) CountPending(ctx context.Context) ([]models.JobCount, error) {
+	query := `
+		SELECT type, status, COUNT(*)
+		FROM jobs
+		WHERE status IN ('queued', 'running')
+		GROUP BY type, status
+		ORDER BY type, status
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("db:JobRepo.CountPending:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	counts := make([]models.JobCount, 0)
+	for rows.Next() {
+		var count models.JobCount
+		if err := rows.Scan(&count.Type, &count.Status, &count.Count); err != nil {
+			return nil, fmt.Errorf("db:JobRepo.CountPending:Scan - %s", err.Error())
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:JobRepo.CountPending:rows - %s", err.Error())
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Estriper0/avito_intership/internal/models"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lib/pq"
+)
+
+type PrEventRepo struct {
+	db     *pgxpool.Pool
+	getter *trmpgx.CtxGetter
+}
+
+func NewPrEventRepo(db *pgxpool.Pool, c *trmpgx.CtxGetter) *PrEventRepo {
+	return &PrEventRepo{
+		db:     db,
+		getter: c,
+	}
+}
+
+// Add writes the events with a single statement, empty strings are stored as NULL.
+func (r *PrEventRepo) Add(ctx context.Context, events []models.PrEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO pr_events (pr_id, type, actor, reviewer_id, old_reviewer_id, reason)
+		SELECT pr_id, type, actor, NULLIF(reviewer_id, ''), NULLIF(old_reviewer_id, ''), NULLIF(reason, '')
+		FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[])
+			AS e(pr_id, type, actor, reviewer_id, old_reviewer_id, reason)
+	`
+
+	prIds := make([]string, 0, len(events))
+	types := make([]string, 0, len(events))
+	actors := make([]string, 0, len(events))
+	reviewersId := make([]string, 0, len(events))
+	oldReviewersId := make([]string, 0, len(events))
+	reasons := make([]string, 0, len(events))
+	for _, event := range events {
+		prIds = append(prIds, event.PrId)
+		types = append(types, event.Type)
+		actors = append(actors, event.Actor)
+		reviewersId = append(reviewersId, event.ReviewerId)
+		oldReviewersId = append(oldReviewersId, event.OldReviewerId)
+		reasons = append(reasons, event.Reason)
+	}
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	_, err := conn.Exec(
+		ctx,
+		query,
+		pq.Array(prIds),
+		pq.Array(types),
+		pq.Array(actors),
+		pq.Array(reviewersId),
+		pq.Array(oldReviewersId),
+		pq.Array(reasons),
+	)
+	if err != nil {
+		return fmt.Errorf("db:PrEventRepo.Add:Exec - %s", err.Error())
+	}
+	return nil
+}
+
+func (r *PrEventRepo) GetByPrId(ctx context.Context, prId string) ([]models.PrEvent, error) {
+	query := `
+		SELECT id, pr_id, type, actor, COALESCE(reviewer_id, ''), COALESCE(old_reviewer_id, ''), COALESCE(reason, ''), created_at
+		FROM pr_events
+		WHERE pr_id = $1
+		ORDER BY id
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, prId)
+	if err != nil {
+		return nil, fmt.Errorf("db:PrEventRepo.GetByPrId:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var events []models.PrEvent
+	for rows.Next() {
+		var event models.PrEvent
+		err := rows.Scan(
+			&event.Id,
+			&event.PrId,
+			&event.Type,
+			&event.Actor,
+			&event.ReviewerId,
+			&event.OldReviewerId,
+			&event.Reason,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:PrEventRepo.GetByPrId:Scan - %s", err.Error())
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:PrEventRepo.GetByPrId:rows - %s", err.Error())
+	}
+	return events, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lib/pq"
 )
 
 type PullRequestRepo struct {
@@ -27,14 +28,20 @@ func NewPullRequestRepo(db *pgxpool.Pool, c *trmpgx.CtxGetter) *PullRequestRepo
 
 func (r *PullRequestRepo) Create(ctx context.Context, pr *models.PullRequest) (*models.PullRequest, error) {
 	query := `
-		INSERT INTO pull_requests (pr_id, name, author_id) 
-		VALUES ($1, $2, $3) 
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id) 
+		VALUES ($1, $2, $3, $4) 
 		RETURNING pr_id, name, author_id, status_id
 	`
 	var p models.PullRequest
 
+	//New PRs are open unless another status is passed
+	statusId := pr.StatusId
+	if statusId == 0 {
+		statusId = models.StatusOpen
+	}
+
 	conn := r.getter.DefaultTrOrDB(ctx, r.db)
-	err := conn.QueryRow(ctx, query, pr.PrId, pr.Name, pr.AuthorId).Scan(
+	err := conn.QueryRow(ctx, query, pr.PrId, pr.Name, pr.AuthorId, statusId).Scan(
 		&p.PrId,
 		&p.Name,
 		&p.AuthorId,
@@ -66,11 +73,11 @@ func (r *PullRequestRepo) AddReviewers(ctx context.Context, prId string, reviewe
 			var pgErr *pgconn.PgError
 			if errors.As(err, &pgErr) {
 				switch pgErr.Code {
-                case "23505":
-                    return repository.ErrAlreadyExists
-                case "23503":
-                    return repository.ErrNotFound
-                }
+				case "23505":
+					return repository.ErrAlreadyExists
+				case "23503":
+					return repository.ErrNotFound
+				}
 			}
 			return fmt.Errorf("db:PullRequestRepo.AddReviewers:Exec - %s", err.Error())
 		}
@@ -107,23 +114,104 @@ func (r *PullRequestRepo) GetReviewers(ctx context.Context, prId string) ([]stri
 	return reviewersId, nil
 }
 
-func (r *PullRequestRepo) Merge(ctx context.Context, prId string) (*models.PullRequest, error) {
+func (r *PullRequestRepo) AddFiles(ctx context.Context, prId string, paths []string) error {
+	query := `
+		INSERT INTO pull_requests_files (pr_id, path)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	_, err := conn.Exec(ctx, query, prId, pq.Array(paths))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return repository.ErrNotFound
+		}
+		return fmt.Errorf("db:PullRequestRepo.AddFiles:Exec - %s", err.Error())
+	}
+	return nil
+}
+
+func (r *PullRequestRepo) GetFiles(ctx context.Context, prId string) ([]string, error) {
+	query := `
+		SELECT path FROM pull_requests_files WHERE pr_id = $1 ORDER BY path
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, prId)
+	if err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetFiles:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		err := rows.Scan(&path)
+		if err != nil {
+			return nil, fmt.Errorf("db:PullRequestRepo.GetFiles:Scan - %s", err.Error())
+		}
+		paths = append(paths, path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetFiles:rows - %s", err.Error())
+	}
+	return paths, nil
+}
+
+// GetExternalReviewers returns the reviewers who are not in the team of the PR author
+func (r *PullRequestRepo) GetExternalReviewers(ctx context.Context, prId string) ([]string, error) {
+	query := `
+		SELECT r.user_id
+		FROM pull_requests_reviewers r
+		JOIN pull_requests p ON p.pr_id = r.pr_id
+		JOIN users a ON a.user_id = p.author_id
+		JOIN users u ON u.user_id = r.user_id
+		WHERE r.pr_id = $1 AND u.team_id IS DISTINCT FROM a.team_id
+		ORDER BY r.user_id
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, prId)
+	if err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetExternalReviewers:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var reviewersId []string
+	for rows.Next() {
+		var reviewerId string
+		err := rows.Scan(&reviewerId)
+		if err != nil {
+			return nil, fmt.Errorf("db:PullRequestRepo.GetExternalReviewers:Scan - %s", err.Error())
+		}
+		reviewersId = append(reviewersId, reviewerId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetExternalReviewers:rows - %s", err.Error())
+	}
+	return reviewersId, nil
+}
+
+func (r *PullRequestRepo) Merge(ctx context.Context, prId string, force bool) (*models.PullRequest, error) {
 	query := `
 		UPDATE pull_requests 
-		SET status_id = 2, merged_at = COALESCE(merged_at, NOW()) 
+		SET status_id = 2, merged_at = COALESCE(merged_at, NOW()), force_merged = force_merged OR $2 
 		WHERE pr_id = $1 
-		RETURNING *
+		RETURNING pr_id, name, author_id, status_id, created_at, merged_at, force_merged
 	`
 	var p models.PullRequest
 
 	conn := r.getter.DefaultTrOrDB(ctx, r.db)
-	err := conn.QueryRow(ctx, query, prId).Scan(
+	err := conn.QueryRow(ctx, query, prId, force).Scan(
 		&p.PrId,
 		&p.Name,
 		&p.AuthorId,
 		&p.StatusId,
 		&p.CreatedAt,
 		&p.MergedAt,
+		&p.ForceMerged,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -191,7 +279,7 @@ func (r *PullRequestRepo) GetStatusById(ctx context.Context, statusId int) (stri
 
 func (r *PullRequestRepo) GetById(ctx context.Context, prId string) (*models.PullRequest, error) {
 	query := `
-		SELECT pr_id, name, author_id, status_id
+		SELECT pr_id, name, author_id, status_id, created_at, merged_at, closed_at, force_merged
 		FROM pull_requests 
 		WHERE pr_id = $1
 	`
@@ -203,6 +291,10 @@ func (r *PullRequestRepo) GetById(ctx context.Context, prId string) (*models.Pul
 		&pr.Name,
 		&pr.AuthorId,
 		&pr.StatusId,
+		&pr.CreatedAt,
+		&pr.MergedAt,
+		&pr.ClosedAt,
+		&pr.ForceMerged,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -214,10 +306,114 @@ func (r *PullRequestRepo) GetById(ctx context.Context, prId string) (*models.Pul
 	return &pr, nil
 }
 
+func (r *PullRequestRepo) List(ctx context.Context, filter *models.PullRequestFilter) ([]models.PullRequest, error) {
+	query := `
+		SELECT pr.pr_id, pr.name, pr.author_id, pr.status_id, pr.created_at, pr.merged_at, pr.closed_at, pr.force_merged
+		FROM pull_requests as pr
+		JOIN pull_requests_statuses as s
+		ON s.id = pr.status_id
+		LEFT JOIN users as u
+		ON u.user_id = pr.author_id
+		LEFT JOIN teams as t
+		ON t.id = u.team_id
+		WHERE 1 = 1
+	`
+
+	//Adding only the filters which are set
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	//Authors removed from their team have no team, their PRs are listed only without the team filter
+	if filter.TeamName != "" {
+		query += " AND t.name = " + arg(filter.TeamName)
+	}
+	if filter.AuthorId != "" {
+		query += " AND pr.author_id = " + arg(filter.AuthorId)
+	}
+	if filter.ReviewerId != "" {
+		query += " AND EXISTS (SELECT 1 FROM pull_requests_reviewers as prr WHERE prr.pr_id = pr.pr_id AND prr.user_id = " + arg(filter.ReviewerId) + ")"
+	}
+	if filter.Status != "" {
+		query += " AND s.status = " + arg(filter.Status)
+	}
+	if filter.CreatedFrom != nil {
+		query += " AND pr.created_at >= " + arg(*filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query += " AND pr.created_at < " + arg(*filter.CreatedTo)
+	}
+	if filter.AfterCreatedAt != nil {
+		query += fmt.Sprintf(" AND (pr.created_at, pr.pr_id) < (%s, %s)", arg(*filter.AfterCreatedAt), arg(filter.AfterPrId))
+	}
+	query += " ORDER BY pr.created_at DESC, pr.pr_id DESC LIMIT " + arg(filter.Limit)
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.List:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var pullRequests []models.PullRequest
+	for rows.Next() {
+		var pr models.PullRequest
+		err := rows.Scan(
+			&pr.PrId,
+			&pr.Name,
+			&pr.AuthorId,
+			&pr.StatusId,
+			&pr.CreatedAt,
+			&pr.MergedAt,
+			&pr.ClosedAt,
+			&pr.ForceMerged,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:PullRequestRepo.List:Scan - %s", err.Error())
+		}
+		pullRequests = append(pullRequests, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.List:rows - %s", err.Error())
+	}
+	return pullRequests, nil
+}
+
+func (r *PullRequestRepo) GetReviewersByPrIds(ctx context.Context, prIds []string) (map[string][]string, error) {
+	query := `
+		SELECT pr_id, user_id 
+		FROM pull_requests_reviewers 
+		WHERE pr_id = ANY($1)
+		ORDER BY pr_id, user_id
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, pq.Array(prIds))
+	if err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetReviewersByPrIds:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	reviewers := make(map[string][]string, len(prIds))
+	for rows.Next() {
+		var prId, userId string
+		err := rows.Scan(&prId, &userId)
+		if err != nil {
+			return nil, fmt.Errorf("db:PullRequestRepo.GetReviewersByPrIds:Scan - %s", err.Error())
+		}
+		reviewers[prId] = append(reviewers[prId], userId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetReviewersByPrIds:rows - %s", err.Error())
+	}
+	return reviewers, nil
+}
+
 func (r *PullRequestRepo) UpdateReviewer(ctx context.Context, prId string, oldReviewer string, newReviewer string) (string, error) {
 	query := `
 		UPDATE pull_requests_reviewers 
-		SET user_id = $1 
+		SET user_id = $1, decision = NULL, decided_at = NULL, assigned_at = NOW(), sla_breached_at = NULL
 		WHERE pr_id = $2 AND user_id = $3 
 		RETURNING user_id
 	`
@@ -235,12 +431,48 @@ func (r *PullRequestRepo) UpdateReviewer(ctx context.Context, prId string, oldRe
 	return reviewerId, nil
 }
 
+// UpdateReviewers replaces several reviewers with one statement, the reviews start over as with UpdateReviewer.
+// ErrNotFound is returned if any old reviewer is not assigned to its PR.
+func (r *PullRequestRepo) UpdateReviewers(ctx context.Context, changes []models.ReviewerChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE pull_requests_reviewers AS prr
+		SET user_id = c.new_reviewer_id, decision = NULL, decided_at = NULL, assigned_at = NOW(), sla_breached_at = NULL
+		FROM unnest($1::text[], $2::text[], $3::text[]) AS c(pr_id, old_reviewer_id, new_reviewer_id)
+		WHERE prr.pr_id = c.pr_id AND prr.user_id = c.old_reviewer_id
+	`
+
+	prIds := make([]string, 0, len(changes))
+	oldReviewersId := make([]string, 0, len(changes))
+	newReviewersId := make([]string, 0, len(changes))
+	for _, change := range changes {
+		prIds = append(prIds, change.PrId)
+		oldReviewersId = append(oldReviewersId, change.OldReviewerId)
+		newReviewersId = append(newReviewersId, change.NewReviewerId)
+	}
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, pq.Array(prIds), pq.Array(oldReviewersId), pq.Array(newReviewersId))
+	if err != nil {
+		return fmt.Errorf("db:PullRequestRepo.UpdateReviewers:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() != int64(len(changes)) {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
 func (r *PullRequestRepo) GetAllInactiveReviewersByTeam(ctx context.Context, teamId int) ([]models.InactiveReviewers, error) {
 	query := `
 		SELECT prr.pr_id, prr.user_id 
 		FROM pull_requests_reviewers as prr
 		JOIN users as u
 		ON u.user_id = prr.user_id AND u.is_active = false AND u.team_id = $1
+		JOIN pull_requests as pr
+		ON pr.pr_id = prr.pr_id AND pr.status_id = 1
 	`
 
 	conn := r.getter.DefaultTrOrDB(ctx, r.db)
@@ -267,3 +499,277 @@ func (r *PullRequestRepo) GetAllInactiveReviewersByTeam(ctx context.Context, tea
 	}
 	return reviewers, nil
 }
+
+// GetOpenReviewsByUsers returns the reviews of the users on OPEN PRs,
+// only on the PRs of the authors in the team unless authorTeamId is 0.
+func (r *PullRequestRepo) GetOpenReviewsByUsers(ctx context.Context, usersId []string, authorTeamId int) ([]models.InactiveReviewers, error) {
+	query := `
+		SELECT prr.pr_id, prr.user_id, pr.author_id
+		FROM pull_requests_reviewers as prr
+		JOIN pull_requests as pr
+		ON pr.pr_id = prr.pr_id AND pr.status_id = 1
+		JOIN users as a
+		ON a.user_id = pr.author_id
+		WHERE prr.user_id = ANY($1) AND ($2::int = 0 OR a.team_id = $2)
+		ORDER BY prr.pr_id, prr.user_id
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, pq.Array(usersId), authorTeamId)
+	if err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetOpenReviewsByUsers:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var reviews []models.InactiveReviewers
+	for rows.Next() {
+		var review models.InactiveReviewers
+		err := rows.Scan(
+			&review.PrId,
+			&review.UserId,
+			&review.AuthorId,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:PullRequestRepo.GetOpenReviewsByUsers:Scan - %s", err.Error())
+		}
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetOpenReviewsByUsers:rows - %s", err.Error())
+	}
+	return reviews, nil
+}
+
+// CountUnfinishedByAuthors counts open and draft PRs of the authors.
+func (r *PullRequestRepo) CountUnfinishedByAuthors(ctx context.Context, authorsId []string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM pull_requests
+		WHERE author_id = ANY($1) AND status_id IN (1, 3)
+	`
+	var count int
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, pq.Array(authorsId)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("db:PullRequestRepo.CountUnfinishedByAuthors:QueryRow - %s", err.Error())
+	}
+	return count, nil
+}
+
+func (r *PullRequestRepo) SetDecision(ctx context.Context, prId string, userId string, decision string) (*models.Review, error) {
+	query := `
+		UPDATE pull_requests_reviewers 
+		SET decision = $1, decided_at = NOW() 
+		WHERE pr_id = $2 AND user_id = $3 
+		RETURNING pr_id, user_id, decision, decided_at
+	`
+	var review models.Review
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, decision, prId, userId).Scan(
+		&review.PrId,
+		&review.UserId,
+		&review.Decision,
+		&review.DecidedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:PullRequestRepo.SetDecision:QueryRow - %s", err.Error())
+	}
+
+	return &review, nil
+}
+
+func (r *PullRequestRepo) GetReviews(ctx context.Context, prId string) ([]models.Review, error) {
+	query := `
+		SELECT pr_id, user_id, COALESCE(decision, ''), decided_at 
+		FROM pull_requests_reviewers 
+		WHERE pr_id = $1
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, prId)
+	if err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetReviews:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var reviews []models.Review
+	for rows.Next() {
+		var review models.Review
+		err := rows.Scan(
+			&review.PrId,
+			&review.UserId,
+			&review.Decision,
+			&review.DecidedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:PullRequestRepo.GetReviews:Scan - %s", err.Error())
+		}
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetReviews:rows - %s", err.Error())
+	}
+	return reviews, nil
+}
+
+func (r *PullRequestRepo) UpdateStatus(ctx context.Context, prId string, statusId int) (*models.PullRequest, error) {
+	query := `
+		UPDATE pull_requests 
+		SET status_id = $1, closed_at = CASE WHEN $1 = 4 THEN NOW() END 
+		WHERE pr_id = $2 
+		RETURNING pr_id, name, author_id, status_id
+	`
+	var p models.PullRequest
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, statusId, prId).Scan(
+		&p.PrId,
+		&p.Name,
+		&p.AuthorId,
+		&p.StatusId,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:PullRequestRepo.UpdateStatus:QueryRow - %s", err.Error())
+	}
+
+	return &p, nil
+}
+
+// ResetAssignedAt restarts the review SLA of the reviewers, e.g. when a closed PR is reopened
+func (r *PullRequestRepo) ResetAssignedAt(ctx context.Context, prId string) error {
+	query := `
+		UPDATE pull_requests_reviewers
+		SET assigned_at = NOW(), sla_breached_at = NULL
+		WHERE pr_id = $1 AND decision IS NULL
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	_, err := conn.Exec(ctx, query, prId)
+	if err != nil {
+		return fmt.Errorf("db:PullRequestRepo.ResetAssignedAt:Exec - %s", err.Error())
+	}
+	return nil
+}
+
+// GetDueSlaBreaches locks the reviewers of OPEN PRs who have made no decision within the SLA of the author's team
+// and are not marked yet. Rows locked by another replica are skipped, so every breach is processed once.
+// GetDueSlaBreaches returns up to limit unmarked reviews past the SLA, the oldest first.
+func (r *PullRequestRepo) GetDueSlaBreaches(ctx context.Context, limit int) ([]models.SlaBreach, error) {
+	query := `
+		SELECT prr.pr_id, prr.user_id, pr.author_id, t.id, t.sla_auto_reassign, prr.assigned_at
+		FROM pull_requests_reviewers AS prr
+		JOIN pull_requests AS pr
+		ON pr.pr_id = prr.pr_id AND pr.status_id = 1
+		JOIN users AS a
+		ON a.user_id = pr.author_id
+		JOIN teams AS t
+		ON t.id = a.team_id AND t.review_sla_hours > 0
+		WHERE prr.decision IS NULL AND prr.sla_breached_at IS NULL AND
+		prr.assigned_at <= NOW() - make_interval(hours => t.review_sla_hours)
+		ORDER BY prr.assigned_at, prr.pr_id, prr.user_id
+		LIMIT $1
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetDueSlaBreaches:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var breaches []models.SlaBreach
+	for rows.Next() {
+		var breach models.SlaBreach
+		err := rows.Scan(
+			&breach.PrId,
+			&breach.ReviewerId,
+			&breach.AuthorId,
+			&breach.TeamId,
+			&breach.AutoReassign,
+			&breach.AssignedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:PullRequestRepo.GetDueSlaBreaches:Scan - %s", err.Error())
+		}
+		breaches = append(breaches, breach)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetDueSlaBreaches:rows - %s", err.Error())
+	}
+	return breaches, nil
+}
+
+// MarkSlaBreached marks the review as breached, ErrNotFound means it has been marked
+// or decided since it was selected.
+func (r *PullRequestRepo) MarkSlaBreached(ctx context.Context, breach *models.SlaBreach) error {
+	query := `
+		UPDATE pull_requests_reviewers
+		SET sla_breached_at = NOW()
+		WHERE pr_id = $1 AND user_id = $2 AND decision IS NULL AND sla_breached_at IS NULL
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, breach.PrId, breach.ReviewerId)
+	if err != nil {
+		return fmt.Errorf("db:PullRequestRepo.MarkSlaBreached:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetOpenSlaBreaches returns the marked breaches where the reviewer still has made no decision,
+// teamId 0 returns the breaches of all teams.
+func (r *PullRequestRepo) GetOpenSlaBreaches(ctx context.Context, teamId int) ([]models.SlaBreach, error) {
+	query := `
+		SELECT prr.pr_id, prr.user_id, pr.author_id, COALESCE(a.team_id, 0), prr.assigned_at, prr.sla_breached_at
+		FROM pull_requests_reviewers AS prr
+		JOIN pull_requests AS pr
+		ON pr.pr_id = prr.pr_id AND pr.status_id = 1
+		JOIN users AS a
+		ON a.user_id = pr.author_id
+		WHERE prr.sla_breached_at IS NOT NULL AND prr.decision IS NULL AND ($1 = 0 OR a.team_id = $1)
+		ORDER BY prr.sla_breached_at, prr.pr_id, prr.user_id
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, teamId)
+	if err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetOpenSlaBreaches:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var breaches []models.SlaBreach
+	for rows.Next() {
+		var breach models.SlaBreach
+		err := rows.Scan(
+			&breach.PrId,
+			&breach.ReviewerId,
+			&breach.AuthorId,
+			&breach.TeamId,
+			&breach.AssignedAt,
+			&breach.BreachedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:PullRequestRepo.GetOpenSlaBreaches:Scan - %s", err.Error())
+		}
+		breaches = append(breaches, breach)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:PullRequestRepo.GetOpenSlaBreaches:rows - %s", err.Error())
+	}
+	return breaches, nil
+}
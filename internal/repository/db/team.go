@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Estriper0/avito_intership/internal/models"
 	"github.com/Estriper0/avito_intership/internal/repository"
@@ -11,6 +12,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lib/pq"
 )
 
 type TeamRepo struct {
@@ -27,12 +29,14 @@ func NewTeamRepo(db *pgxpool.Pool, c *trmpgx.CtxGetter) *TeamRepo {
 
 func (r *TeamRepo) Create(ctx context.Context, team *models.Team) (int, error) {
 	query := `
-		INSERT INTO teams (name) VALUES ($1) RETURNING id
+		INSERT INTO teams (name, min_reviewers, max_reviewers, required_approvals, review_sla_hours, sla_auto_reassign)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
 	`
 	var id int
 
 	conn := r.getter.DefaultTrOrDB(ctx, r.db)
-	err := conn.QueryRow(ctx, query, team.Name).Scan(&id)
+	err := conn.QueryRow(ctx, query, team.Name, team.MinReviewers, team.MaxReviewers, team.RequiredApprovals, team.ReviewSlaHours, team.SlaAutoReassign).Scan(&id)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -64,6 +68,262 @@ func (r *TeamRepo) GetIdByName(ctx context.Context, teamName string) (int, error
 	return id, nil
 }
 
+func (r *TeamRepo) GetById(ctx context.Context, teamId int) (*models.Team, error) {
+	query := `
+		SELECT id, name, min_reviewers, max_reviewers, required_approvals, review_sla_hours, sla_auto_reassign FROM teams WHERE id = $1
+	`
+	var team models.Team
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, teamId).Scan(
+		&team.Id,
+		&team.Name,
+		&team.MinReviewers,
+		&team.MaxReviewers,
+		&team.RequiredApprovals,
+		&team.ReviewSlaHours,
+		&team.SlaAutoReassign,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:TeamRepo.GetById:QueryRow - %s", err.Error())
+	}
+
+	return &team, nil
+}
+
+func (r *TeamRepo) GetByName(ctx context.Context, teamName string) (*models.Team, error) {
+	query := `
+		SELECT id, name, min_reviewers, max_reviewers, required_approvals, review_sla_hours, sla_auto_reassign FROM teams WHERE name = $1
+	`
+	var team models.Team
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, teamName).Scan(
+		&team.Id,
+		&team.Name,
+		&team.MinReviewers,
+		&team.MaxReviewers,
+		&team.RequiredApprovals,
+		&team.ReviewSlaHours,
+		&team.SlaAutoReassign,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:TeamRepo.GetByName:QueryRow - %s", err.Error())
+	}
+
+	return &team, nil
+}
+
+func (r *TeamRepo) UpdateSettings(ctx context.Context, team *models.Team) (*models.Team, error) {
+	query := `
+		UPDATE teams 
+		SET min_reviewers = $1, max_reviewers = $2, required_approvals = $3, review_sla_hours = $4, sla_auto_reassign = $5
+		WHERE name = $6
+		RETURNING id, name, min_reviewers, max_reviewers, required_approvals, review_sla_hours, sla_auto_reassign
+	`
+	var t models.Team
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, team.MinReviewers, team.MaxReviewers, team.RequiredApprovals, team.ReviewSlaHours, team.SlaAutoReassign, team.Name).Scan(
+		&t.Id,
+		&t.Name,
+		&t.MinReviewers,
+		&t.MaxReviewers,
+		&t.RequiredApprovals,
+		&t.ReviewSlaHours,
+		&t.SlaAutoReassign,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:TeamRepo.UpdateSettings:QueryRow - %s", err.Error())
+	}
+
+	return &t, nil
+}
+
+func (r *TeamRepo) Rename(ctx context.Context, teamName string, newTeamName string) (*models.Team, error) {
+	query := `
+		UPDATE teams
+		SET name = $2
+		WHERE name = $1
+		RETURNING id, name, min_reviewers, max_reviewers, required_approvals, review_sla_hours, sla_auto_reassign
+	`
+	var t models.Team
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, teamName, newTeamName).Scan(
+		&t.Id,
+		&t.Name,
+		&t.MinReviewers,
+		&t.MaxReviewers,
+		&t.RequiredApprovals,
+		&t.ReviewSlaHours,
+		&t.SlaAutoReassign,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("db:TeamRepo.Rename:QueryRow - %s", err.Error())
+	}
+
+	return &t, nil
+}
+
+func (r *TeamRepo) Delete(ctx context.Context, teamId int) error {
+	query := `
+		DELETE FROM teams WHERE id = $1
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, teamId)
+	if err != nil {
+		return fmt.Errorf("db:TeamRepo.Delete:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// SetFallbacks replaces the fallback teams, their order is the order of use
+func (r *TeamRepo) SetFallbacks(ctx context.Context, teamId int, fallbacksId []int) error {
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	_, err := conn.Exec(ctx, `DELETE FROM team_fallbacks WHERE team_id = $1`, teamId)
+	if err != nil {
+		return fmt.Errorf("db:TeamRepo.SetFallbacks:Exec - %s", err.Error())
+	}
+	if len(fallbacksId) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO team_fallbacks (team_id, fallback_team_id, position)
+		SELECT $1, f.id, f.position FROM unnest($2::int[]) WITH ORDINALITY AS f(id, position)
+	`
+	_, err = conn.Exec(ctx, query, teamId, fallbacksId)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return repository.ErrNotFound
+		}
+		return fmt.Errorf("db:TeamRepo.SetFallbacks:Exec - %s", err.Error())
+	}
+	return nil
+}
+
+func (r *TeamRepo) GetFallbacks(ctx context.Context, teamId int) ([]models.Team, error) {
+	query := `
+		SELECT t.id, t.name, t.min_reviewers, t.max_reviewers, t.required_approvals, t.review_sla_hours, t.sla_auto_reassign
+		FROM team_fallbacks f
+		JOIN teams t ON t.id = f.fallback_team_id
+		WHERE f.team_id = $1
+		ORDER BY f.position
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, teamId)
+	if err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetFallbacks:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var teams []models.Team
+	for rows.Next() {
+		var team models.Team
+		err := rows.Scan(
+			&team.Id,
+			&team.Name,
+			&team.MinReviewers,
+			&team.MaxReviewers,
+			&team.RequiredApprovals,
+			&team.ReviewSlaHours,
+			&team.SlaAutoReassign,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:TeamRepo.GetFallbacks:Scan - %s", err.Error())
+		}
+		teams = append(teams, team)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetFallbacks:rows - %s", err.Error())
+	}
+
+	return teams, nil
+}
+
+// SetCodeOwners replaces the code owner rules of the team, their order is kept
+func (r *TeamRepo) SetCodeOwners(ctx context.Context, teamId int, rules []models.CodeOwnerRule) error {
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	_, err := conn.Exec(ctx, `DELETE FROM code_owner_rules WHERE team_id = $1`, teamId)
+	if err != nil {
+		return fmt.Errorf("db:TeamRepo.SetCodeOwners:Exec - %s", err.Error())
+	}
+
+	query := `
+		INSERT INTO code_owner_rules (team_id, position, pattern, users_id, teams_id) VALUES ($1, $2, $3, $4, $5)
+	`
+	for i, rule := range rules {
+		_, err := conn.Exec(ctx, query, teamId, i+1, rule.Pattern, pq.Array(rule.UsersId), pq.Array(rule.TeamsId))
+		if err != nil {
+			return fmt.Errorf("db:TeamRepo.SetCodeOwners:Exec - %s", err.Error())
+		}
+	}
+	return nil
+}
+
+func (r *TeamRepo) GetCodeOwners(ctx context.Context, teamId int) ([]models.CodeOwnerRule, error) {
+	query := `
+		SELECT
+			r.pattern,
+			r.users_id,
+			r.teams_id,
+			ARRAY(SELECT t.name FROM teams t WHERE t.id = ANY(r.teams_id) ORDER BY t.name)
+		FROM code_owner_rules r
+		WHERE r.team_id = $1
+		ORDER BY r.position
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, teamId)
+	if err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetCodeOwners:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var rules []models.CodeOwnerRule
+	for rows.Next() {
+		var rule models.CodeOwnerRule
+		err := rows.Scan(
+			&rule.Pattern,
+			&rule.UsersId,
+			&rule.TeamsId,
+			&rule.TeamNames,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:TeamRepo.GetCodeOwners:Scan - %s", err.Error())
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetCodeOwners:rows - %s", err.Error())
+	}
+
+	return rules, nil
+}
+
 func (r *TeamRepo) GetNameById(ctx context.Context, teamId int) (string, error) {
 	query := `
 		SELECT name FROM teams WHERE id = $1
@@ -82,28 +342,41 @@ func (r *TeamRepo) GetNameById(ctx context.Context, teamId int) (string, error)
 	return teamName, nil
 }
 
-func (r *TeamRepo) GetStatsPRByName(ctx context.Context, teamName string) (*models.TeamStatsPR, error) {
+// GetStatsPRByName counts the PRs of the team members created within [from, to), nil bounds are open.
+func (r *TeamRepo) GetStatsPRByName(ctx context.Context, teamName string, from *time.Time, to *time.Time) (*models.TeamStatsPR, error) {
 	query := `
 		SELECT 
 			t.name, 
 			COUNT(pr.status_id),
 			COUNT(pr.status_id) FILTER (WHERE status_id = 1),
-			COUNT(pr.status_id) FILTER (WHERE status_id = 2)
+			COUNT(pr.status_id) FILTER (WHERE status_id = 2),
+			COUNT(pr.status_id) FILTER (WHERE status_id = 3),
+			COUNT(pr.status_id) FILTER (WHERE status_id = 4),
+			COUNT(pr.status_id) FILTER (
+				WHERE status_id = 1 AND (
+					SELECT COUNT(*) FROM pull_requests_reviewers as prr WHERE prr.pr_id = pr.pr_id
+				) < t.min_reviewers
+			)
 		FROM teams as t 
-		JOIN users as u
-		ON u.team_id = t.id AND t.name = $1
+		LEFT JOIN users as u
+		ON u.team_id = t.id
 		LEFT JOIN pull_requests as pr
-		ON pr.author_id = u.user_id
-		GROUP BY t.name;
+		ON pr.author_id = u.user_id AND
+		($2::timestamptz IS NULL OR pr.created_at >= $2) AND ($3::timestamptz IS NULL OR pr.created_at < $3)
+		WHERE t.name = $1
+		GROUP BY t.name, t.min_reviewers;
 	`
 	var team models.TeamStatsPR
 
 	conn := r.getter.DefaultTrOrDB(ctx, r.db)
-	err := conn.QueryRow(ctx, query, teamName).Scan(
+	err := conn.QueryRow(ctx, query, teamName, from, to).Scan(
 		&team.Name,
 		&team.TotalPr,
 		&team.OpenPr,
 		&team.MergedPr,
+		&team.DraftPr,
+		&team.ClosedPr,
+		&team.UnderReviewedPr,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -114,3 +387,182 @@ func (r *TeamRepo) GetStatsPRByName(ctx context.Context, teamName string) (*mode
 
 	return &team, nil
 }
+
+// GetLeadTimes computes the median and p90 of the time to merge of the team PRs merged within [from, to)
+// and of the time to the first review decision of the team PRs created within [from, to).
+func (r *TeamRepo) GetLeadTimes(ctx context.Context, teamId int, from *time.Time, to *time.Time) (*models.TeamLeadTimes, error) {
+	query := `
+		WITH team_prs AS (
+			SELECT pr.pr_id, pr.created_at, pr.merged_at
+			FROM pull_requests AS pr
+			JOIN users AS u
+			ON u.user_id = pr.author_id AND u.team_id = $1
+		),
+		merged AS (
+			SELECT EXTRACT(EPOCH FROM merged_at - created_at)::float8 AS seconds
+			FROM team_prs
+			WHERE merged_at IS NOT NULL AND
+			($2::timestamptz IS NULL OR merged_at >= $2) AND ($3::timestamptz IS NULL OR merged_at < $3)
+		),
+		reviewed AS (
+			SELECT EXTRACT(EPOCH FROM MIN(e.created_at) - tp.created_at)::float8 AS seconds
+			FROM team_prs AS tp
+			JOIN pr_events AS e
+			ON e.pr_id = tp.pr_id AND e.type = 'reviewed'
+			WHERE ($2::timestamptz IS NULL OR tp.created_at >= $2) AND ($3::timestamptz IS NULL OR tp.created_at < $3)
+			GROUP BY tp.pr_id, tp.created_at
+		)
+		SELECT
+			(SELECT COUNT(*) FROM merged),
+			(SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY seconds) FROM merged),
+			(SELECT percentile_cont(0.9) WITHIN GROUP (ORDER BY seconds) FROM merged),
+			(SELECT COUNT(*) FROM reviewed),
+			(SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY seconds) FROM reviewed),
+			(SELECT percentile_cont(0.9) WITHIN GROUP (ORDER BY seconds) FROM reviewed)
+	`
+	var times models.TeamLeadTimes
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, teamId, from, to).Scan(
+		&times.MergedCount,
+		&times.MergeMedian,
+		&times.MergeP90,
+		&times.ReviewedCount,
+		&times.FirstReviewMedian,
+		&times.FirstReviewP90,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetLeadTimes:QueryRow - %s", err.Error())
+	}
+
+	return &times, nil
+}
+
+// GetMergedPerWeek counts the team PRs merged within [from, to) by weeks, weeks without merges
+// between the first and the last merge are returned with zero.
+func (r *TeamRepo) GetMergedPerWeek(ctx context.Context, teamId int, from *time.Time, to *time.Time) ([]models.WeeklyCount, error) {
+	query := `
+		WITH merged AS (
+			SELECT date_trunc('week', pr.merged_at AT TIME ZONE 'UTC') AS week, COUNT(*) AS merged
+			FROM pull_requests AS pr
+			JOIN users AS u
+			ON u.user_id = pr.author_id AND u.team_id = $1
+			WHERE pr.merged_at IS NOT NULL AND
+			($2::timestamptz IS NULL OR pr.merged_at >= $2) AND ($3::timestamptz IS NULL OR pr.merged_at < $3)
+			GROUP BY 1
+		)
+		SELECT w.week, COALESCE(m.merged, 0)
+		FROM generate_series((SELECT MIN(week) FROM merged), (SELECT MAX(week) FROM merged), INTERVAL '1 week') AS w(week)
+		LEFT JOIN merged AS m
+		ON m.week = w.week
+		ORDER BY w.week
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, teamId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetMergedPerWeek:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	weeks := make([]models.WeeklyCount, 0)
+	for rows.Next() {
+		var week models.WeeklyCount
+		if err := rows.Scan(&week.Week, &week.Count); err != nil {
+			return nil, fmt.Errorf("db:TeamRepo.GetMergedPerWeek:Scan - %s", err.Error())
+		}
+		weeks = append(weeks, week)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetMergedPerWeek:rows - %s", err.Error())
+	}
+
+	return weeks, nil
+}
+
+// GetReviewLoad counts the reviews of every team member on the PRs created within [from, to),
+// members without reviews are returned with zero. The busiest members come first.
+func (r *TeamRepo) GetReviewLoad(ctx context.Context, teamId int, from *time.Time, to *time.Time) ([]models.ReviewLoad, error) {
+	query := `
+		SELECT u.user_id, u.username, COUNT(pr.pr_id), COUNT(pr.pr_id) FILTER (WHERE pr.status_id = 1)
+		FROM users AS u
+		LEFT JOIN pull_requests_reviewers AS prr
+		ON prr.user_id = u.user_id
+		LEFT JOIN pull_requests AS pr
+		ON pr.pr_id = prr.pr_id AND
+		($2::timestamptz IS NULL OR pr.created_at >= $2) AND ($3::timestamptz IS NULL OR pr.created_at < $3)
+		WHERE u.team_id = $1
+		GROUP BY u.user_id, u.username
+		ORDER BY COUNT(pr.pr_id) DESC, u.user_id
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, teamId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetReviewLoad:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	load := make([]models.ReviewLoad, 0)
+	for rows.Next() {
+		var l models.ReviewLoad
+		if err := rows.Scan(&l.UserId, &l.Username, &l.Reviews, &l.OpenReviews); err != nil {
+			return nil, fmt.Errorf("db:TeamRepo.GetReviewLoad:Scan - %s", err.Error())
+		}
+		load = append(load, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetReviewLoad:rows - %s", err.Error())
+	}
+
+	return load, nil
+}
+
+// GetSlaStats counts the SLA breaches and the reassignments they caused on the PRs of the team members
+// within [from, to), nil bounds are open. teamId 0 returns all teams.
+func (r *TeamRepo) GetSlaStats(ctx context.Context, teamId int, from *time.Time, to *time.Time) ([]models.TeamSlaStats, error) {
+	query := `
+		SELECT t.id, t.name, t.review_sla_hours,
+			COUNT(e.id) FILTER (WHERE e.type = 'sla_breached'),
+			COUNT(e.id) FILTER (WHERE e.type = 'reassigned' AND e.reason = 'sla')
+		FROM teams AS t
+		LEFT JOIN users AS a
+		ON a.team_id = t.id
+		LEFT JOIN pull_requests AS pr
+		ON pr.author_id = a.user_id
+		LEFT JOIN pr_events AS e
+		ON e.pr_id = pr.pr_id AND e.type IN ('sla_breached', 'reassigned') AND
+		($2::timestamptz IS NULL OR e.created_at >= $2) AND ($3::timestamptz IS NULL OR e.created_at < $3)
+		WHERE $1 = 0 OR t.id = $1
+		GROUP BY t.id, t.name, t.review_sla_hours
+		ORDER BY t.name
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, teamId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetSlaStats:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var stats []models.TeamSlaStats
+	for rows.Next() {
+		var stat models.TeamSlaStats
+		err := rows.Scan(
+			&stat.TeamId,
+			&stat.Name,
+			&stat.ReviewSlaHours,
+			&stat.Breaches,
+			&stat.Reassigned,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:TeamRepo.GetSlaStats:Scan - %s", err.Error())
+		}
+		stats = append(stats, stat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:TeamRepo.GetSlaStats:rows - %s", err.Error())
+	}
+
+	return stats, nil
+}
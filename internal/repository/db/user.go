@@ -9,6 +9,7 @@ import (
 	"github.com/Estriper0/avito_intership/internal/repository"
 	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/lib/pq"
 )
@@ -81,7 +82,8 @@ func (r *UserRepo) GetAllByTeam(ctx context.Context, teamId int) ([]models.User,
 
 func (r *UserRepo) UpdateIsActive(ctx context.Context, userId string, isActive bool) (*models.User, error) {
 	query := `
-		UPDATE users SET is_active = $1 WHERE user_id = $2 RETURNING *
+		UPDATE users SET is_active = $1 WHERE user_id = $2
+		RETURNING user_id, username, COALESCE(team_id, 0), is_active
 	`
 	var user models.User
 
@@ -116,12 +118,80 @@ func (r *UserRepo) ExistsById(ctx context.Context, userId string) (bool, error)
 	return exists, nil
 }
 
+func (r *UserRepo) GetById(ctx context.Context, userId string) (*models.User, error) {
+	query := `
+		SELECT user_id, username, COALESCE(team_id, 0), is_active
+		FROM users 
+		WHERE user_id = $1
+	`
+	var user models.User
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, userId).Scan(
+		&user.UserId,
+		&user.Username,
+		&user.TeamId,
+		&user.IsActive,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:UserRepo.GetById:QueryRow - %s", err.Error())
+	}
+
+	return &user, nil
+}
+
+// UpdateTeam moves the user to the team, teamId 0 leaves the user without a team.
+func (r *UserRepo) UpdateTeam(ctx context.Context, userId string, teamId int) (*models.User, error) {
+	query := `
+		UPDATE users SET team_id = NULLIF($1, 0) WHERE user_id = $2
+		RETURNING user_id, username, COALESCE(team_id, 0), is_active
+	`
+	var user models.User
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, teamId, userId).Scan(
+		&user.UserId,
+		&user.Username,
+		&user.TeamId,
+		&user.IsActive,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:UserRepo.UpdateTeam:QueryRow - %s", err.Error())
+	}
+
+	return &user, nil
+}
+
+// ClearTeam leaves all members of the team without a team.
+func (r *UserRepo) ClearTeam(ctx context.Context, teamId int) error {
+	query := `
+		UPDATE users SET team_id = NULL WHERE team_id = $1
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	_, err := conn.Exec(ctx, query, teamId)
+	if err != nil {
+		return fmt.Errorf("db:UserRepo.ClearTeam:Exec - %s", err.Error())
+	}
+	return nil
+}
+
 func (r *UserRepo) GetActiveTeamMembersById(ctx context.Context, userId string) ([]models.User, error) {
 	query := `
 		SELECT user_id, username, team_id, is_active 
 		FROM users 
 		WHERE team_id = (SELECT team_id FROM users WHERE user_id = $1) AND
-		is_active = true AND user_id != $1
+		is_active = true AND user_id != $1 AND
+		NOT EXISTS (
+			SELECT 1 FROM user_absences AS ua
+			WHERE ua.user_id = users.user_id AND ua.starts_at <= NOW() AND ua.ends_at > NOW()
+		)
 	`
 
 	conn := r.getter.DefaultTrOrDB(ctx, r.db)
@@ -152,6 +222,43 @@ func (r *UserRepo) GetActiveTeamMembersById(ctx context.Context, userId string)
 	return users, nil
 }
 
+// GetByIdsOrTeams returns the users listed by id together with the members of the teams, active or not
+func (r *UserRepo) GetByIdsOrTeams(ctx context.Context, usersId []string, teamsId []int) ([]models.User, error) {
+	query := `
+		SELECT user_id, username, COALESCE(team_id, 0), is_active
+		FROM users
+		WHERE user_id = ANY($1) OR team_id = ANY($2)
+		ORDER BY user_id
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, pq.Array(usersId), pq.Array(teamsId))
+	if err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetByIdsOrTeams:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		err := rows.Scan(
+			&user.UserId,
+			&user.Username,
+			&user.TeamId,
+			&user.IsActive,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:UserRepo.GetByIdsOrTeams:Scan - %s", err.Error())
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetByIdsOrTeams:rows - %s", err.Error())
+	}
+
+	return users, nil
+}
+
 func (r *UserRepo) GetStatsReview(ctx context.Context) ([]models.UserStatsReview, error) {
 	query := `
 		SELECT u.user_id, u.username, COUNT(prr.pr_id) 
@@ -190,6 +297,94 @@ func (r *UserRepo) GetStatsReview(ctx context.Context) ([]models.UserStatsReview
 	return users, nil
 }
 
+// Sort keys of GetReviewStats, only these expressions get into ORDER BY
+var userStatsOrder = map[string]string{
+	"total_reviews":          "total_reviews",
+	"open_reviews":           "open_reviews",
+	"completed_reviews":      "completed_reviews",
+	"reassigned_away":        "reassigned_away",
+	"avg_review_seconds":     "avg_review_seconds",
+	"authored_pull_requests": "authored",
+}
+
+// GetReviewStats returns the review history of every user, users without reviews included.
+// Reviews and authored PRs are counted by PRs created within [from, to), reassignments by the event time.
+func (r *UserRepo) GetReviewStats(ctx context.Context, filter *models.UserStatsFilter) ([]models.UserReviewStats, error) {
+	order, ok := userStatsOrder[filter.SortBy]
+	if !ok {
+		order = userStatsOrder["total_reviews"]
+	}
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT u.user_id, u.username, COALESCE(t.name, ''),
+			rv.total_reviews, rv.open_reviews, rv.completed_reviews, ra.reassigned_away, rv.avg_review_seconds, a.authored
+		FROM users AS u
+		LEFT JOIN teams AS t
+		ON t.id = u.team_id
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS total_reviews,
+				COUNT(*) FILTER (WHERE pr.status_id = 1) AS open_reviews,
+				COUNT(*) FILTER (WHERE prr.decision IN ('APPROVED', 'CHANGES_REQUESTED')) AS completed_reviews,
+				(AVG(EXTRACT(EPOCH FROM prr.decided_at - prr.assigned_at)) FILTER (WHERE prr.decision IN ('APPROVED', 'CHANGES_REQUESTED')))::float8 AS avg_review_seconds
+			FROM pull_requests_reviewers AS prr
+			JOIN pull_requests AS pr
+			ON pr.pr_id = prr.pr_id
+			WHERE prr.user_id = u.user_id AND
+			($2::timestamptz IS NULL OR pr.created_at >= $2) AND ($3::timestamptz IS NULL OR pr.created_at < $3)
+		) AS rv
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS reassigned_away
+			FROM pr_events AS e
+			WHERE e.old_reviewer_id = u.user_id AND e.type = 'reassigned' AND
+			($2::timestamptz IS NULL OR e.created_at >= $2) AND ($3::timestamptz IS NULL OR e.created_at < $3)
+		) AS ra
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS authored
+			FROM pull_requests AS pr
+			WHERE pr.author_id = u.user_id AND
+			($2::timestamptz IS NULL OR pr.created_at >= $2) AND ($3::timestamptz IS NULL OR pr.created_at < $3)
+		) AS a
+		WHERE $1 = '' OR t.name = $1
+		ORDER BY %s %s NULLS LAST, u.user_id
+	`, order, direction)
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, filter.TeamName, filter.From, filter.To)
+	if err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetReviewStats:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	users := make([]models.UserReviewStats, 0)
+	for rows.Next() {
+		var user models.UserReviewStats
+		err := rows.Scan(
+			&user.UserId,
+			&user.Username,
+			&user.TeamName,
+			&user.TotalReviews,
+			&user.OpenReviews,
+			&user.CompletedReviews,
+			&user.ReassignedAway,
+			&user.AvgReviewSeconds,
+			&user.AuthoredPr,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:UserRepo.GetReviewStats:Scan - %s", err.Error())
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetReviewStats:rows - %s", err.Error())
+	}
+
+	return users, nil
+}
+
 func (r *UserRepo) MassDeactivation(ctx context.Context, usersId []string) ([]string, error) {
 	query := `
 		UPDATE users 
@@ -224,3 +419,251 @@ func (r *UserRepo) MassDeactivation(ctx context.Context, usersId []string) ([]st
 
 	return ids, nil
 }
+
+// GetActiveIds returns the active users among the given ones and locks them until the end of the transaction,
+// so concurrent deactivations of a user see its new state.
+func (r *UserRepo) GetActiveIds(ctx context.Context, usersId []string) ([]string, error) {
+	query := `
+		SELECT user_id
+		FROM users
+		WHERE user_id = ANY($1) AND is_active
+		ORDER BY user_id
+		FOR UPDATE
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, pq.Array(usersId))
+	if err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetActiveIds:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("db:UserRepo.GetActiveIds:Scan - %s", err.Error())
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetActiveIds:rows - %s", err.Error())
+	}
+
+	return ids, nil
+}
+
+func (r *UserRepo) CountOpenReviews(ctx context.Context, usersId []string) (map[string]int, error) {
+	query := `
+		SELECT prr.user_id, COUNT(*) 
+		FROM pull_requests_reviewers as prr
+		JOIN pull_requests as pr
+		ON prr.pr_id = pr.pr_id AND pr.status_id = 1
+		WHERE prr.user_id = ANY($1)
+		GROUP BY prr.user_id
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, pq.Array(usersId))
+	if err != nil {
+		return nil, fmt.Errorf("db:UserRepo.CountOpenReviews:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	//Users without open reviews are not returned by the query, so they stay at zero
+	counts := make(map[string]int, len(usersId))
+	for _, id := range usersId {
+		counts[id] = 0
+	}
+	for rows.Next() {
+		var id string
+		var count int
+		err := rows.Scan(&id, &count)
+		if err != nil {
+			return nil, fmt.Errorf("db:UserRepo.CountOpenReviews:Scan - %s", err.Error())
+		}
+		counts[id] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:UserRepo.CountOpenReviews:rows - %s", err.Error())
+	}
+
+	return counts, nil
+}
+
+func (r *UserRepo) AddAbsence(ctx context.Context, absence *models.Absence) (*models.Absence, error) {
+	query := `
+		INSERT INTO user_absences (user_id, starts_at, ends_at, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, starts_at, ends_at, reason, reassigned_at, created_at
+	`
+	var a models.Absence
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	err := conn.QueryRow(ctx, query, absence.UserId, absence.StartsAt, absence.EndsAt, absence.Reason).Scan(
+		&a.Id,
+		&a.UserId,
+		&a.StartsAt,
+		&a.EndsAt,
+		&a.Reason,
+		&a.ReassignedAt,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:UserRepo.AddAbsence:QueryRow - %s", err.Error())
+	}
+
+	return &a, nil
+}
+
+// GetAbsences returns the current and upcoming absences of the user, finished ones are omitted
+func (r *UserRepo) GetAbsences(ctx context.Context, userId string) ([]models.Absence, error) {
+	query := `
+		SELECT id, user_id, starts_at, ends_at, reason, reassigned_at, created_at
+		FROM user_absences
+		WHERE user_id = $1 AND ends_at > NOW()
+		ORDER BY starts_at, id
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetAbsences:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	absences := make([]models.Absence, 0)
+	for rows.Next() {
+		var a models.Absence
+		err := rows.Scan(
+			&a.Id,
+			&a.UserId,
+			&a.StartsAt,
+			&a.EndsAt,
+			&a.Reason,
+			&a.ReassignedAt,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:UserRepo.GetAbsences:Scan - %s", err.Error())
+		}
+		absences = append(absences, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetAbsences:rows - %s", err.Error())
+	}
+
+	return absences, nil
+}
+
+func (r *UserRepo) DeleteAbsence(ctx context.Context, userId string, absenceId int) error {
+	query := `
+		DELETE FROM user_absences WHERE id = $1 AND user_id = $2
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, absenceId, userId)
+	if err != nil {
+		return fmt.Errorf("db:UserRepo.DeleteAbsence:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetAbsentIds returns the users which are absent right now
+func (r *UserRepo) GetAbsentIds(ctx context.Context, usersId []string) ([]string, error) {
+	query := `
+		SELECT DISTINCT user_id
+		FROM user_absences
+		WHERE user_id = ANY($1) AND starts_at <= NOW() AND ends_at > NOW()
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, pq.Array(usersId))
+	if err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetAbsentIds:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("db:UserRepo.GetAbsentIds:Scan - %s", err.Error())
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetAbsentIds:rows - %s", err.Error())
+	}
+
+	return ids, nil
+}
+
+// GetStartedAbsences returns up to limit absences which have started but whose reviews are not reassigned yet,
+// the earliest first.
+func (r *UserRepo) GetStartedAbsences(ctx context.Context, limit int) ([]models.Absence, error) {
+	query := `
+		SELECT id, user_id, starts_at, ends_at, reason, reassigned_at, created_at
+		FROM user_absences
+		WHERE reassigned_at IS NULL AND starts_at <= NOW() AND ends_at > NOW()
+		ORDER BY starts_at, id
+		LIMIT $1
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetStartedAbsences:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var absences []models.Absence
+	for rows.Next() {
+		var a models.Absence
+		err := rows.Scan(
+			&a.Id,
+			&a.UserId,
+			&a.StartsAt,
+			&a.EndsAt,
+			&a.Reason,
+			&a.ReassignedAt,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:UserRepo.GetStartedAbsences:Scan - %s", err.Error())
+		}
+		absences = append(absences, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:UserRepo.GetStartedAbsences:rows - %s", err.Error())
+	}
+
+	return absences, nil
+}
+
+// MarkAbsencesReassigned marks the absences as reassigned, ErrNotFound means all of them
+// have been marked by another replica since they were selected.
+func (r *UserRepo) MarkAbsencesReassigned(ctx context.Context, absencesId []int) error {
+	query := `
+		UPDATE user_absences SET reassigned_at = NOW() WHERE id = ANY($1) AND reassigned_at IS NULL
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, pq.Array(absencesId))
+	if err != nil {
+		return fmt.Errorf("db:UserRepo.MarkAbsencesReassigned:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lib/pq"
+)
+
+const webhookColumns = `id, url, secret, events, is_active, created_at, updated_at`
+
+type WebhookRepo struct {
+	db     *pgxpool.Pool
+	getter *trmpgx.CtxGetter
+}
+
+func NewWebhookRepo(db *pgxpool.Pool, c *trmpgx.CtxGetter) *WebhookRepo {
+	return &WebhookRepo{
+		db:     db,
+		getter: c,
+	}
+}
+
+func (r *WebhookRepo) Create(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	query := `
+		INSERT INTO webhooks (url, secret, events, is_active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + webhookColumns
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	created, err := scanWebhook(conn.QueryRow(ctx, query, webhook.Url, webhook.Secret, pq.Array(webhook.Events), webhook.IsActive))
+	if err != nil {
+		return nil, fmt.Errorf("db:WebhookRepo.Create:QueryRow - %s", err.Error())
+	}
+	return created, nil
+}
+
+func (r *WebhookRepo) GetById(ctx context.Context, webhookId int) (*models.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE id = $1
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	webhook, err := scanWebhook(conn.QueryRow(ctx, query, webhookId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:WebhookRepo.GetById:QueryRow - %s", err.Error())
+	}
+	return webhook, nil
+}
+
+func (r *WebhookRepo) GetAll(ctx context.Context) ([]models.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		ORDER BY id
+	`
+
+	return r.query(ctx, "GetAll", query)
+}
+
+func (r *WebhookRepo) Update(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	query := `
+		UPDATE webhooks
+		SET url = $2, secret = $3, events = $4, is_active = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + webhookColumns
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	updated, err := scanWebhook(conn.QueryRow(ctx, query, webhook.Id, webhook.Url, webhook.Secret, pq.Array(webhook.Events), webhook.IsActive))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:WebhookRepo.Update:QueryRow - %s", err.Error())
+	}
+	return updated, nil
+}
+
+func (r *WebhookRepo) Delete(ctx context.Context, webhookId int) error {
+	query := `
+		DELETE FROM webhooks WHERE id = $1
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	tag, err := conn.Exec(ctx, query, webhookId)
+	if err != nil {
+		return fmt.Errorf("db:WebhookRepo.Delete:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetSubscribed returns active webhooks subscribed to the event.
+func (r *WebhookRepo) GetSubscribed(ctx context.Context, event string) ([]models.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE is_active = true AND $1 = ANY(events)
+		ORDER BY id
+	`
+
+	return r.query(ctx, "GetSubscribed", query, event)
+}
+
+func (r *WebhookRepo) AddDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, job_id, event, attempt, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, ''), $7)
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	_, err := conn.Exec(
+		ctx,
+		query,
+		delivery.WebhookId,
+		delivery.JobId,
+		delivery.Event,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.DurationMs,
+	)
+	if err != nil {
+		return fmt.Errorf("db:WebhookRepo.AddDelivery:Exec - %s", err.Error())
+	}
+	return nil
+}
+
+func (r *WebhookRepo) GetDeliveries(ctx context.Context, webhookId int, limit int) ([]models.WebhookDelivery, error) {
+	query := `
+		SELECT id, webhook_id, job_id::text, event, attempt, COALESCE(status_code, 0), COALESCE(error, ''), duration_ms, created_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, webhookId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("db:WebhookRepo.GetDeliveries:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		err := rows.Scan(
+			&delivery.Id,
+			&delivery.WebhookId,
+			&delivery.JobId,
+			&delivery.Event,
+			&delivery.Attempt,
+			&delivery.StatusCode,
+			&delivery.Error,
+			&delivery.DurationMs,
+			&delivery.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:WebhookRepo.GetDeliveries:Scan - %s", err.Error())
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:WebhookRepo.GetDeliveries:rows - %s", err.Error())
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepo) query(ctx context.Context, method string, query string, args ...any) ([]models.Webhook, error) {
+	conn := r.getter.DefaultTrOrDB(ctx, r.db)
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:WebhookRepo.%s:Query - %s", method, err.Error())
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("db:WebhookRepo.%s:Scan - %s", method, err.Error())
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db:WebhookRepo.%s:rows - %s", method, err.Error())
+	}
+	return webhooks, nil
+}
+
+func scanWebhook(row pgx.Row) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := row.Scan(
+		&webhook.Id,
+		&webhook.Url,
+		&webhook.Secret,
+		&webhook.Events,
+		&webhook.IsActive,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
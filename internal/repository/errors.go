@@ -5,4 +5,6 @@ import "errors"
 var (
 	ErrAlreadyExists = errors.New("record exists")
 	ErrNotFound      = errors.New("not found")
+	//The job was leased again by another worker or is not running anymore
+	ErrLeaseLost = errors.New("job lease lost")
 )
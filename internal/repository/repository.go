@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/Estriper0/avito_intership/internal/models"
 )
@@ -11,26 +13,109 @@ type IUserRepo interface {
 	GetAllByTeam(ctx context.Context, teamId int) ([]models.User, error)
 	UpdateIsActive(ctx context.Context, userId string, isActive bool) (*models.User, error)
 	ExistsById(ctx context.Context, userId string) (bool, error)
+	GetById(ctx context.Context, userId string) (*models.User, error)
 	GetActiveTeamMembersById(ctx context.Context, userId string) ([]models.User, error)
 	GetStatsReview(ctx context.Context) ([]models.UserStatsReview, error)
+	GetReviewStats(ctx context.Context, filter *models.UserStatsFilter) ([]models.UserReviewStats, error)
 	MassDeactivation(ctx context.Context, usersId []string) ([]string, error)
+	GetActiveIds(ctx context.Context, usersId []string) ([]string, error)
+	CountOpenReviews(ctx context.Context, usersId []string) (map[string]int, error)
+	UpdateTeam(ctx context.Context, userId string, teamId int) (*models.User, error)
+	ClearTeam(ctx context.Context, teamId int) error
+	GetByIdsOrTeams(ctx context.Context, usersId []string, teamsId []int) ([]models.User, error)
+	AddAbsence(ctx context.Context, absence *models.Absence) (*models.Absence, error)
+	GetAbsences(ctx context.Context, userId string) ([]models.Absence, error)
+	DeleteAbsence(ctx context.Context, userId string, absenceId int) error
+	GetAbsentIds(ctx context.Context, usersId []string) ([]string, error)
+	GetStartedAbsences(ctx context.Context, limit int) ([]models.Absence, error)
+	MarkAbsencesReassigned(ctx context.Context, absencesId []int) error
 }
 
 type ITeamRepo interface {
 	Create(ctx context.Context, team *models.Team) (int, error)
 	GetIdByName(ctx context.Context, teamName string) (int, error)
+	GetById(ctx context.Context, teamId int) (*models.Team, error)
+	GetByName(ctx context.Context, teamName string) (*models.Team, error)
+	UpdateSettings(ctx context.Context, team *models.Team) (*models.Team, error)
 	GetNameById(ctx context.Context, teamId int) (string, error)
-	GetStatsPRByName(ctx context.Context, teamName string) (*models.TeamStatsPR, error)
+	GetStatsPRByName(ctx context.Context, teamName string, from *time.Time, to *time.Time) (*models.TeamStatsPR, error)
+	GetLeadTimes(ctx context.Context, teamId int, from *time.Time, to *time.Time) (*models.TeamLeadTimes, error)
+	GetMergedPerWeek(ctx context.Context, teamId int, from *time.Time, to *time.Time) ([]models.WeeklyCount, error)
+	GetReviewLoad(ctx context.Context, teamId int, from *time.Time, to *time.Time) ([]models.ReviewLoad, error)
+	Rename(ctx context.Context, teamName string, newTeamName string) (*models.Team, error)
+	Delete(ctx context.Context, teamId int) error
+	SetFallbacks(ctx context.Context, teamId int, fallbacksId []int) error
+	GetFallbacks(ctx context.Context, teamId int) ([]models.Team, error)
+	SetCodeOwners(ctx context.Context, teamId int, rules []models.CodeOwnerRule) error
+	GetCodeOwners(ctx context.Context, teamId int) ([]models.CodeOwnerRule, error)
+	GetSlaStats(ctx context.Context, teamId int, from *time.Time, to *time.Time) ([]models.TeamSlaStats, error)
 }
 
 type IPullRequestRepo interface {
 	Create(ctx context.Context, pr *models.PullRequest) (*models.PullRequest, error)
 	AddReviewers(ctx context.Context, prId string, reviewersId []string) error
 	GetReviewers(ctx context.Context, prId string) ([]string, error)
-	Merge(ctx context.Context, prId string) (*models.PullRequest, error)
+	Merge(ctx context.Context, prId string, force bool) (*models.PullRequest, error)
 	GetAllReviewByUserId(ctx context.Context, userId string) ([]models.PullRequest, error)
 	GetStatusById(ctx context.Context, statusId int) (string, error)
 	GetById(ctx context.Context, prId string) (*models.PullRequest, error)
 	UpdateReviewer(ctx context.Context, prId string, oldReviewer string, newReviewer string) (string, error)
+	UpdateReviewers(ctx context.Context, changes []models.ReviewerChange) error
 	GetAllInactiveReviewersByTeam(ctx context.Context, teamId int) ([]models.InactiveReviewers, error)
+	SetDecision(ctx context.Context, prId string, userId string, decision string) (*models.Review, error)
+	GetReviews(ctx context.Context, prId string) ([]models.Review, error)
+	UpdateStatus(ctx context.Context, prId string, statusId int) (*models.PullRequest, error)
+	List(ctx context.Context, filter *models.PullRequestFilter) ([]models.PullRequest, error)
+	GetReviewersByPrIds(ctx context.Context, prIds []string) (map[string][]string, error)
+	GetOpenReviewsByUsers(ctx context.Context, usersId []string, authorTeamId int) ([]models.InactiveReviewers, error)
+	CountUnfinishedByAuthors(ctx context.Context, authorsId []string) (int, error)
+	GetExternalReviewers(ctx context.Context, prId string) ([]string, error)
+	AddFiles(ctx context.Context, prId string, paths []string) error
+	GetFiles(ctx context.Context, prId string) ([]string, error)
+	ResetAssignedAt(ctx context.Context, prId string) error
+	GetDueSlaBreaches(ctx context.Context, limit int) ([]models.SlaBreach, error)
+	MarkSlaBreached(ctx context.Context, breach *models.SlaBreach) error
+	GetOpenSlaBreaches(ctx context.Context, teamId int) ([]models.SlaBreach, error)
+}
+
+type IPrEventRepo interface {
+	Add(ctx context.Context, events []models.PrEvent) error
+	GetByPrId(ctx context.Context, prId string) ([]models.PrEvent, error)
+}
+
+type IJobRepo interface {
+	Create(ctx context.Context, job *models.Job) (*models.Job, error)
+	GetById(ctx context.Context, jobId string) (*models.Job, error)
+	Lease(ctx context.Context, leaseFor time.Duration) (*models.Job, error)
+	Extend(ctx context.Context, jobId string, attempt int, leaseFor time.Duration) error
+	Complete(ctx context.Context, jobId string, attempt int, result json.RawMessage) error
+	Retry(ctx context.Context, jobId string, attempt int, lastError string, runAt time.Time) error
+	Fail(ctx context.Context, jobId string, attempt int, lastError string) error
+	FailExpired(ctx context.Context) ([]string, error)
+	UpdateProgress(ctx context.Context, jobId string, processed int, total int) error
+	CountPending(ctx context.Context) ([]models.JobCount, error)
+}
+
+type IWebhookRepo interface {
+	Create(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	GetById(ctx context.Context, webhookId int) (*models.Webhook, error)
+	GetAll(ctx context.Context) ([]models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	Delete(ctx context.Context, webhookId int) error
+	GetSubscribed(ctx context.Context, event string) ([]models.Webhook, error)
+	AddDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, webhookId int, limit int) ([]models.WebhookDelivery, error)
+}
+
+type IIntegrationRepo interface {
+	SetIdentity(ctx context.Context, identity *models.Identity) (*models.Identity, error)
+	GetUserIdByLogin(ctx context.Context, provider string, login string) (string, error)
+	GetIdentities(ctx context.Context, provider string) ([]models.Identity, error)
+	DeleteIdentity(ctx context.Context, provider string, login string) error
+	AddDelivery(ctx context.Context, delivery *models.IntegrationDelivery) error
+}
+
+type IHealthRepo interface {
+	Ping(ctx context.Context) error
+	GetMigrationVersion(ctx context.Context) (uint, bool, error)
 }
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/tracing"
+)
+
+// Users manage their own absences, team leads manage absences of their team members
+func (s *UserService) canManageAbsences(ctx context.Context, userId string) (bool, error) {
+	if principal, ok := auth.FromContext(ctx); ok && principal.UserId == userId {
+		return true, nil
+	}
+
+	user, err := s.userRepo.GetById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			//Only admins get to the repository, which reports the user as not found
+			return callerLeadsTeam(ctx, s.userRepo, 0)
+		}
+		return false, err
+	}
+	return callerLeadsTeam(ctx, s.userRepo, user.TeamId)
+}
+
+func absenceToDto(absence *models.Absence) dto.Absence {
+	return dto.Absence{
+		AbsenceId:    absence.Id,
+		UserId:       absence.UserId,
+		StartsAt:     absence.StartsAt,
+		EndsAt:       absence.EndsAt,
+		Reason:       absence.Reason,
+		ReassignedAt: absence.ReassignedAt,
+	}
+}
+
+// AddAbsence plans a period when the user is not a review candidate.
+// Open reviews of the user are reassigned by the scheduler once the absence starts.
+func (s *UserService) AddAbsence(ctx context.Context, req *dto.AbsenceRequest) (*dto.Absence, error) {
+	ctx, span := tracing.Start(ctx, "UserService.AddAbsence")
+	defer span.End()
+
+	ok, err := s.canManageAbsences(ctx, req.UserId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "UserService.AddAbsence:canManageAbsences - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+	if !req.EndsAt.After(time.Now()) {
+		return nil, ErrInvalidAbsence
+	}
+
+	absence, err := s.userRepo.AddAbsence(ctx, &models.Absence{
+		UserId:   req.UserId,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "UserService.AddAbsence:userRepo.AddAbsence - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	resp := absenceToDto(absence)
+	return &resp, nil
+}
+
+func (s *UserService) GetAbsences(ctx context.Context, userId string) ([]dto.Absence, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAbsences")
+	defer span.End()
+
+	exists, err := s.userRepo.ExistsById(ctx, userId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "UserService.GetAbsences:userRepo.ExistsById - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	absences, err := s.userRepo.GetAbsences(ctx, userId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "UserService.GetAbsences:userRepo.GetAbsences - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	resp := make([]dto.Absence, 0, len(absences))
+	for i := range absences {
+		resp = append(resp, absenceToDto(&absences[i]))
+	}
+	return resp, nil
+}
+
+// DeleteAbsence cancels the absence, a started one ends immediately.
+// Reviews reassigned at its start are not given back.
+func (s *UserService) DeleteAbsence(ctx context.Context, req *dto.AbsenceDeleteRequest) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteAbsence")
+	defer span.End()
+
+	ok, err := s.canManageAbsences(ctx, req.UserId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "UserService.DeleteAbsence:canManageAbsences - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+	if !ok {
+		return ErrForbidden
+	}
+
+	err = s.userRepo.DeleteAbsence(ctx, req.UserId, req.AbsenceId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "UserService.DeleteAbsence:userRepo.DeleteAbsence - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+	return nil
+}
+
+// Absences handled by one tick, the rest are left to the next ones
+const absenceBatch int = 100
+
+// StartAbsences reassigns the open reviews of the users whose absence has started, it is run by the scheduler.
+// Users become candidates again without any action, as soon as the absence ends.
+// Each user is committed on their own, a failing one is logged and retried on the next tick.
+func (s *UserService) StartAbsences(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "UserService.StartAbsences")
+	defer span.End()
+
+	absences, err := s.userRepo.GetStartedAbsences(ctx, absenceBatch)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "UserService.StartAbsences:userRepo.GetStartedAbsences - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+
+	usersId := make([]string, 0, len(absences))
+	absencesId := make(map[string][]int, len(absences))
+	for _, absence := range absences {
+		if _, ok := absencesId[absence.UserId]; !ok {
+			usersId = append(usersId, absence.UserId)
+		}
+		absencesId[absence.UserId] = append(absencesId[absence.UserId], absence.Id)
+	}
+
+	for _, userId := range usersId {
+		err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+			return s.startAbsence(ctx, userId, absencesId[userId])
+		})
+		if err != nil {
+			s.logger.WarnContext(ctx, "UserService.StartAbsences - Absence is not started",
+				slog.String("user_id", userId),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+	return nil
+}
+
+func (s *UserService) startAbsence(ctx context.Context, userId string, absencesId []int) error {
+	//Marking first locks the absences, so another replica does not reassign the same reviews
+	err := s.userRepo.MarkAbsencesReassigned(ctx, absencesId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		s.logger.ErrorContext(ctx, "UserService.StartAbsences:userRepo.MarkAbsencesReassigned - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+
+	reassignments, err := s.prService.ReassignOpenReviewsOfUsers(ctx, []string{userId}, models.ReasonAbsence)
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Absence started", slog.String("user_id", userId), slog.Int("reassignments", len(reassignments)))
+	return nil
+}
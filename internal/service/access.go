@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/repository"
+)
+
+// Team of the caller. Admins and calls outside the HTTP API reach every team, so restricted is false for them.
+// Callers without a team or unknown to the service get team 0, which matches no team.
+func callerTeamId(ctx context.Context, userRepo repository.IUserRepo) (teamId int, restricted bool, err error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || principal.IsAdmin() {
+		return 0, false, nil
+	}
+
+	caller, err := userRepo.GetById(ctx, principal.UserId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, true, nil
+		}
+		return 0, true, err
+	}
+	return caller.TeamId, true, nil
+}
+
+// Checks that the caller sees the team: members see only the team they are in
+func callerInTeam(ctx context.Context, userRepo repository.IUserRepo, teamId int) (bool, error) {
+	callerTeam, restricted, err := callerTeamId(ctx, userRepo)
+	if err != nil {
+		return false, err
+	}
+	return !restricted || (callerTeam != 0 && callerTeam == teamId), nil
+}
+
+// Checks that the caller manages the team: team leads manage only the team they are in
+func callerLeadsTeam(ctx context.Context, userRepo repository.IUserRepo, teamId int) (bool, error) {
+	if principal, ok := auth.FromContext(ctx); ok && !principal.HasRole(auth.RoleTeamLead) {
+		return false, nil
+	}
+	return callerInTeam(ctx, userRepo, teamId)
+}
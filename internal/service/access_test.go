@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallerTeamAccess(t *testing.T) {
+	userRepo := &stubUserRepo{
+		byTeam: map[int][]models.User{
+			1: users("lead1", "member1"),
+			2: users("lead2"),
+			//Users removed from their team
+			0: users("lead0"),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		teamId    int
+		wantSees  bool
+		wantLeads bool
+	}{
+		{name: "no principal", teamId: 1, wantSees: true, wantLeads: true},
+		{name: "static admin", principal: &auth.Principal{Role: auth.RoleAdmin}, teamId: 1, wantSees: true, wantLeads: true},
+		{name: "lead of the team", principal: &auth.Principal{UserId: "lead1", Role: auth.RoleTeamLead}, teamId: 1, wantSees: true, wantLeads: true},
+		{name: "lead of another team", principal: &auth.Principal{UserId: "lead2", Role: auth.RoleTeamLead}, teamId: 1},
+		{name: "member of the team", principal: &auth.Principal{UserId: "member1", Role: auth.RoleMember}, teamId: 1, wantSees: true},
+		{name: "lead without a team", principal: &auth.Principal{UserId: "lead0", Role: auth.RoleTeamLead}, teamId: 0},
+		{name: "lead unknown to the service", principal: &auth.Principal{UserId: "ghost", Role: auth.RoleTeamLead}, teamId: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+
+			sees, err := callerInTeam(ctx, userRepo, tt.teamId)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSees, sees)
+
+			leads, err := callerLeadsTeam(ctx, userRepo, tt.teamId)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLeads, leads)
+		})
+	}
+}
+
+func TestUserService_CanManageAbsences(t *testing.T) {
+	s := &UserService{userRepo: &stubUserRepo{
+		byTeam: map[int][]models.User{
+			1: users("lead1", "member1", "member2"),
+			2: users("lead2"),
+		},
+	}}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		userId    string
+		want      bool
+	}{
+		{name: "own absences", principal: &auth.Principal{UserId: "member1", Role: auth.RoleMember}, userId: "member1", want: true},
+		{name: "absences of a teammate", principal: &auth.Principal{UserId: "member1", Role: auth.RoleMember}, userId: "member2"},
+		{name: "lead of the team", principal: &auth.Principal{UserId: "lead1", Role: auth.RoleTeamLead}, userId: "member2", want: true},
+		{name: "lead of another team", principal: &auth.Principal{UserId: "lead2", Role: auth.RoleTeamLead}, userId: "member2"},
+		{name: "admin", principal: &auth.Principal{Role: auth.RoleAdmin}, userId: "member2", want: true},
+		{name: "admin and an unknown user", principal: &auth.Principal{Role: auth.RoleAdmin}, userId: "ghost", want: true},
+		{name: "lead and an unknown user", principal: &auth.Principal{UserId: "lead1", Role: auth.RoleTeamLead}, userId: "ghost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+			ok, err := s.canManageAbsences(ctx, tt.userId)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ok)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Estriper0/avito_intership/internal/models"
+)
+
+// compileOwnerPattern turns a CODEOWNERS pattern into a regexp matching file paths.
+// As in CODEOWNERS, "*" and "?" do not cross "/", "**" does, a pattern starting with "/" or
+// with "/" in the middle is anchored to the root, otherwise it matches at any depth,
+// and a pattern matching a directory matches everything inside it.
+func compileOwnerPattern(pattern string) (*regexp.Regexp, error) {
+	p := strings.TrimSuffix(pattern, "/")
+	anchored := strings.HasPrefix(p, "/") || strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return nil, ErrInvalidCodeOwners
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	b.WriteString("(?:/.*)?$")
+
+	return regexp.Compile(b.String())
+}
+
+// ownerGroup holds the owners of the changed files matched by one rule,
+// one of them has to review the PR
+type ownerGroup struct {
+	pattern string
+	owners  []models.User
+}
+
+// Matches the changed files of the PR against the code owner rules of the team.
+// The last matching rule takes precedence for a file, as in CODEOWNERS.
+func (s *PullRequestService) ownerGroups(ctx context.Context, team *models.Team, prId string) ([]ownerGroup, error) {
+	files, err := s.prRepo.GetFiles(ctx, prId)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+
+	rules, err := s.teamRepo.GetCodeOwners(ctx, team.Id)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(rules))
+	for _, rule := range rules {
+		pattern, err := compileOwnerPattern(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	matched := make([]bool, len(rules))
+	for _, file := range files {
+		file = strings.TrimPrefix(file, "/")
+		for i := len(patterns) - 1; i >= 0; i-- {
+			if patterns[i].MatchString(file) {
+				matched[i] = true
+				break
+			}
+		}
+	}
+
+	var groups []ownerGroup
+	for i, rule := range rules {
+		if !matched[i] {
+			continue
+		}
+		owners, err := s.userRepo.GetByIdsOrTeams(ctx, rule.UsersId, rule.TeamsId)
+		if err != nil {
+			return nil, err
+		}
+		//Absent owners can neither be picked nor cover the files
+		owners, err = s.withoutAbsent(ctx, owners)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, ownerGroup{pattern: rule.Pattern, owners: owners})
+	}
+	return groups, nil
+}
+
+// Picks an owner for the groups not covered by the reviewers, owners of more such groups come first
+// and among them the team strategy decides. Returns nil if no group needs an owner or nobody can take it.
+func (s *PullRequestService) pickOwner(ctx context.Context, team *models.Team, groups []ownerGroup, reviewers []string, exclude []string) (*models.User, error) {
+	score := make(map[string]int)
+	var candidates []models.User
+	for _, group := range groups {
+		covered := slices.ContainsFunc(group.owners, func(owner models.User) bool {
+			return slices.Contains(reviewers, owner.UserId)
+		})
+		if covered {
+			continue
+		}
+		for _, owner := range group.owners {
+			if !owner.IsActive || slices.Contains(exclude, owner.UserId) {
+				continue
+			}
+			if score[owner.UserId] == 0 {
+				candidates = append(candidates, owner)
+			}
+			score[owner.UserId]++
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	best := 0
+	for _, owner := range candidates {
+		best = max(best, score[owner.UserId])
+	}
+	candidates = slices.DeleteFunc(candidates, func(owner models.User) bool {
+		return score[owner.UserId] < best
+	})
+	slices.SortFunc(candidates, func(a, b models.User) int {
+		return strings.Compare(a.UserId, b.UserId)
+	})
+
+	selected, err := s.selectReviewers(ctx, team, candidates, 1)
+	if err != nil && !errors.Is(err, ErrNoCandidate) {
+		return nil, err
+	}
+	//Owners are required, so an owner at the open review cap is still taken
+	if len(selected) == 0 {
+		return &candidates[0], nil
+	}
+	for i := range candidates {
+		if candidates[i].UserId == selected[0] {
+			return &candidates[i], nil
+		}
+	}
+	return &candidates[0], nil
+}
+
+// Picks an owner to replace the old reviewer, if the code ownership covered only by the old reviewer would be lost.
+// The groups are the owner groups of the PR, the old reviewer and the other current reviewers are not candidates.
+func (s *PullRequestService) replacingOwner(ctx context.Context, team *models.Team, groups []ownerGroup, authorId string, reviewers []string, oldReviewerId string) (*models.User, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	remaining := slices.DeleteFunc(slices.Clone(reviewers), func(reviewer string) bool {
+		return reviewer == oldReviewerId
+	})
+	return s.pickOwner(ctx, team, groups, remaining, append([]string{authorId}, reviewers...))
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+// The list cursor is an opaque token with the sort key of the last returned PR.
+func encodeCursor(createdAt time.Time, prId string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + prId))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	createdAt, prId, ok := strings.Cut(string(raw), "|")
+	if !ok || prId == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	return t, prId, nil
+}
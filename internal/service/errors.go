@@ -4,10 +4,34 @@ import "errors"
 
 var (
 	ErrTeamAlreadyExists        = errors.New("team_name already exists")
+	ErrInvalidReviewersSettings = errors.New("min_reviewers must not exceed max_reviewers")
 	ErrPullRequestALreadyExists = errors.New("pr id already exists")
+	ErrUserInOtherTeam          = errors.New("user belongs to another team, use /users/move")
+	ErrNotTeamMember            = errors.New("user is not a member of the team")
+	ErrOpenPullRequests         = errors.New("there are open pull requests or reviews")
+	ErrInvalidFallbacks         = errors.New("team cannot be its own fallback")
+	ErrInvalidCodeOwners        = errors.New("invalid code owner rule")
+	ErrInvalidAbsence           = errors.New("absence must end in the future")
 
-	ErrPullRequestMerged = errors.New("cannot reassign on merged PR")
-	ErrNoCandidate       = errors.New("no candidate for reassign")
+	ErrPullRequestMerged  = errors.New("cannot reassign on merged PR")
+	ErrNoCandidate        = errors.New("no candidate for reassign")
+	ErrReviewOnMerged     = errors.New("cannot review merged PR")
+	ErrNotAssigned        = errors.New("reviewer is not assigned to this PR")
+	ErrNotApproved        = errors.New("PR does not meet the team approval policy")
+	ErrPullRequestNotOpen = errors.New("PR is not open")
+	ErrInvalidTransition  = errors.New("invalid PR status transition")
+
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidPeriod = errors.New("from must be before to")
+
+	ErrForbidden = errors.New("not allowed for the caller")
+
+	ErrWebhookDelivery = errors.New("webhook delivery failed")
+
+	ErrIntegrationDisabled = errors.New("integration is not configured")
+	ErrInvalidSignature    = errors.New("invalid webhook signature")
+	ErrInvalidPayload      = errors.New("invalid webhook payload")
+	ErrIdentityNotMapped   = errors.New("hosting login is not mapped to a user")
 
 	ErrNotFound = errors.New("resource not found")
 	ErrInternal = errors.New("internal error")
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+)
+
+// The probe must answer before the orchestrator gives up on it
+const readinessTimeout = 2 * time.Second
+
+// IWorkerStatus is implemented by the job worker pool
+type IWorkerStatus interface {
+	Status() models.WorkerStatus
+}
+
+// HealthService answers the liveness and readiness probes.
+type HealthService struct {
+	healthRepo repository.IHealthRepo
+	workers    IWorkerStatus
+	//Latest migration shipped with this build
+	migrationVersion uint
+	draining         atomic.Bool
+	logger           *slog.Logger
+}
+
+func NewHealthService(healthRepo repository.IHealthRepo, workers IWorkerStatus, migrationVersion uint, logger *slog.Logger) *HealthService {
+	return &HealthService{
+		healthRepo:       healthRepo,
+		workers:          workers,
+		migrationVersion: migrationVersion,
+		logger:           logger,
+	}
+}
+
+// Drain fails the readiness probe from now on, so the traffic is moved away before the server stops
+func (s *HealthService) Drain() {
+	s.draining.Store(true)
+}
+
+// Ready checks the database, its schema and the workers. The replica is ready only if all checks pass
+// and the shutdown has not started. A schema migrated further by a newer replica is accepted.
+func (s *HealthService) Ready(ctx context.Context) *dto.ReadinessResponse {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	resp := &dto.ReadinessResponse{
+		Status:   dto.HealthStatusOk,
+		Draining: s.draining.Load(),
+		Database: dto.HealthCheck{Status: dto.HealthStatusOk},
+		Migrations: dto.MigrationsCheck{
+			HealthCheck: dto.HealthCheck{Status: dto.HealthStatusOk},
+			Expected:    s.migrationVersion,
+		},
+	}
+
+	if err := s.healthRepo.Ping(ctx); err != nil {
+		s.logger.WarnContext(ctx, "HealthService.Ready:healthRepo.Ping - Database unavailable", slog.String("error", err.Error()))
+		resp.Database = dto.HealthCheck{Status: dto.HealthStatusUnavailable, Error: err.Error()}
+	}
+
+	version, dirty, err := s.healthRepo.GetMigrationVersion(ctx)
+	switch {
+	case err != nil:
+		s.logger.WarnContext(ctx, "HealthService.Ready:healthRepo.GetMigrationVersion - Internal error", slog.String("error", err.Error()))
+		resp.Migrations.HealthCheck = dto.HealthCheck{Status: dto.HealthStatusUnavailable, Error: err.Error()}
+	case dirty:
+		resp.Migrations.HealthCheck = dto.HealthCheck{Status: dto.HealthStatusUnavailable, Error: fmt.Sprintf("migration %d is dirty", version)}
+	case version < s.migrationVersion:
+		resp.Migrations.HealthCheck = dto.HealthCheck{Status: dto.HealthStatusUnavailable, Error: fmt.Sprintf("expected migration %d", s.migrationVersion)}
+	}
+	resp.Migrations.Version, resp.Migrations.Dirty = version, dirty
+
+	status := s.workers.Status()
+	resp.Workers = dto.WorkersCheck{
+		HealthCheck: dto.HealthCheck{Status: dto.HealthStatusOk},
+		Running:     status.Running,
+		Count:       status.Count,
+		Busy:        status.Busy,
+	}
+	if !status.Running && status.Count > 0 {
+		resp.Workers.HealthCheck = dto.HealthCheck{Status: dto.HealthStatusUnavailable, Error: "workers are not running"}
+	}
+
+	if resp.Draining || resp.Database.Status != dto.HealthStatusOk ||
+		resp.Migrations.Status != dto.HealthStatusOk || resp.Workers.Status != dto.HealthStatusOk {
+		resp.Status = dto.HealthStatusUnavailable
+	}
+	return resp
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+)
+
+const actionCreate = "create"
+
+// Events of the Git hostings which are mapped onto PRs
+const (
+	githubEventPullRequest  = "pull_request"
+	githubEventPing         = "ping"
+	gitlabEventMergeRequest = "Merge Request Hook"
+)
+
+// Limit of the PR name, see dto.PrCreateRequest
+const maxPrNameLength = 200
+
+// hostingEvent is a PR change on the Git hosting in our terms
+type hostingEvent struct {
+	action string
+	prId   string
+	title  string
+	draft  bool
+	//Login of the PR author, used only on create
+	login string
+}
+
+type githubPullRequestPayload struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Draft  bool   `json:"draft"`
+		Merged bool   `json:"merged"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		Id int64 `json:"id"`
+	} `json:"repository"`
+}
+
+type gitlabMergeRequestPayload struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		Id int64 `json:"id"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		Iid            int    `json:"iid"`
+		Title          string `json:"title"`
+		Action         string `json:"action"`
+		Draft          bool   `json:"draft"`
+		WorkInProgress bool   `json:"work_in_progress"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Draft *struct {
+			Previous bool `json:"previous"`
+			Current  bool `json:"current"`
+		} `json:"draft"`
+	} `json:"changes"`
+}
+
+// parseHostingEvent maps the delivery onto a PR action.
+// A nil event with a reason means the delivery is valid but has nothing to do for us.
+func parseHostingEvent(d *dto.HostingDelivery) (*hostingEvent, string, error) {
+	switch d.Provider {
+	case models.ProviderGitHub:
+		return parseGitHubEvent(d.Event, d.Body)
+	case models.ProviderGitLab:
+		return parseGitLabEvent(d.Event, d.Body)
+	}
+	return nil, "", ErrIntegrationDisabled
+}
+
+func parseGitHubEvent(event string, body []byte) (*hostingEvent, string, error) {
+	switch event {
+	case githubEventPullRequest:
+	case githubEventPing:
+		return nil, "ping", nil
+	default:
+		return nil, "unsupported event " + event, nil
+	}
+
+	var payload githubPullRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", ErrInvalidPayload
+	}
+	pr := payload.PullRequest
+	if pr.Number == 0 || payload.Repository.Id == 0 {
+		return nil, "", ErrInvalidPayload
+	}
+
+	e := &hostingEvent{
+		prId:  fmt.Sprintf("gh-%d-%d", payload.Repository.Id, pr.Number),
+		title: truncate(pr.Title, maxPrNameLength),
+		draft: pr.Draft,
+		login: pr.User.Login,
+	}
+	switch payload.Action {
+	case "opened":
+		e.action = actionCreate
+	case "ready_for_review":
+		e.action = actionReady
+	case "reopened":
+		e.action = actionReopen
+	case "closed":
+		e.action = actionClose
+		if pr.Merged {
+			e.action = actionMerge
+		}
+	default:
+		return nil, "unsupported action " + payload.Action, nil
+	}
+	return e, "", nil
+}
+
+func parseGitLabEvent(event string, body []byte) (*hostingEvent, string, error) {
+	if event != gitlabEventMergeRequest {
+		return nil, "unsupported event " + event, nil
+	}
+
+	var payload gitlabMergeRequestPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", ErrInvalidPayload
+	}
+	attrs := payload.ObjectAttributes
+	if payload.ObjectKind != "merge_request" || attrs.Iid == 0 || payload.Project.Id == 0 {
+		return nil, "", ErrInvalidPayload
+	}
+
+	//GitLab sends only the numeric author id, the login is taken from the user who triggered the event,
+	//for the open action it is the author
+	e := &hostingEvent{
+		prId:  fmt.Sprintf("gl-%d-%d", payload.Project.Id, attrs.Iid),
+		title: truncate(attrs.Title, maxPrNameLength),
+		draft: attrs.Draft || attrs.WorkInProgress,
+		login: payload.User.Username,
+	}
+	switch attrs.Action {
+	case "open":
+		e.action = actionCreate
+	case "reopen":
+		e.action = actionReopen
+	case "close":
+		e.action = actionClose
+	case "merge":
+		e.action = actionMerge
+	case "update":
+		//Only marking the draft as ready matters among the updates
+		if draft := payload.Changes.Draft; draft == nil || !draft.Previous || draft.Current {
+			return nil, "unsupported update", nil
+		}
+		e.action = actionReady
+	default:
+		return nil, "unsupported action " + attrs.Action, nil
+	}
+	return e, "", nil
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/tracing"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+)
+
+// Outcomes of an inbound delivery
+const (
+	DeliveryProcessed = "processed"
+	DeliveryDuplicate = "duplicate"
+	DeliveryIgnored   = "ignored"
+)
+
+type IntegrationService struct {
+	integrationRepo repository.IIntegrationRepo
+	prRepo          repository.IPullRequestRepo
+	prService       IPullRequestService
+	cfg             config.IntegrationsConfig
+	trManager       *manager.Manager
+	logger          *slog.Logger
+}
+
+func NewIntegrationService(integrationRepo repository.IIntegrationRepo, prRepo repository.IPullRequestRepo, prService IPullRequestService, cfg config.IntegrationsConfig, trManager *manager.Manager, logger *slog.Logger) *IntegrationService {
+	return &IntegrationService{
+		integrationRepo: integrationRepo,
+		prRepo:          prRepo,
+		prService:       prService,
+		cfg:             cfg,
+		trManager:       trManager,
+		logger:          logger,
+	}
+}
+
+// Receive verifies an inbound webhook of a Git hosting and applies it to the PR.
+// The delivery is recorded in the same transaction as the change, so a replay is skipped,
+// and a failed delivery may be redelivered once the cause (e.g. a missing identity) is fixed.
+func (s *IntegrationService) Receive(ctx context.Context, d *dto.HostingDelivery) (*dto.HostingDeliveryResponse, error) {
+	ctx, span := tracing.Start(ctx, "IntegrationService.Receive")
+	defer span.End()
+
+	if err := s.verify(d); err != nil {
+		return nil, err
+	}
+
+	event, reason, err := parseHostingEvent(d)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return &dto.HostingDeliveryResponse{Status: DeliveryIgnored, Reason: reason}, nil
+	}
+
+	resp := &dto.HostingDeliveryResponse{
+		Action: event.action,
+		PrId:   event.prId,
+	}
+	//Do everything in a transaction
+	err = inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		//Deliveries without an id rely on the actions being idempotent
+		if d.DeliveryId != "" {
+			err := s.integrationRepo.AddDelivery(ctx, &models.IntegrationDelivery{
+				Provider:   d.Provider,
+				DeliveryId: d.DeliveryId,
+				Event:      d.Event,
+				Action:     event.action,
+				PrId:       event.prId,
+			})
+			if err != nil {
+				if errors.Is(err, repository.ErrAlreadyExists) {
+					resp.Status = DeliveryDuplicate
+					return nil
+				}
+				s.logger.ErrorContext(ctx, "IntegrationService.Receive:integrationRepo.AddDelivery - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+		}
+
+		resp.Status, resp.Reason, err = s.apply(ctx, d.Provider, event)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Checks the signature of the body (GitHub) or the secret token (GitLab)
+func (s *IntegrationService) verify(d *dto.HostingDelivery) error {
+	switch d.Provider {
+	case models.ProviderGitHub:
+		if s.cfg.GitHubSecret == "" {
+			return ErrIntegrationDisabled
+		}
+		signature, ok := strings.CutPrefix(d.Signature, "sha256=")
+		if !ok || !hmac.Equal([]byte(signature), []byte(Sign(s.cfg.GitHubSecret, d.Body))) {
+			return ErrInvalidSignature
+		}
+	case models.ProviderGitLab:
+		if s.cfg.GitLabToken == "" {
+			return ErrIntegrationDisabled
+		}
+		if subtle.ConstantTimeCompare([]byte(d.Signature), []byte(s.cfg.GitLabToken)) != 1 {
+			return ErrInvalidSignature
+		}
+	default:
+		return ErrIntegrationDisabled
+	}
+	return nil
+}
+
+// Applies the event with the PR service and returns the outcome with its reason
+func (s *IntegrationService) apply(ctx context.Context, provider string, event *hostingEvent) (string, string, error) {
+	var err error
+	switch event.action {
+	case actionCreate:
+		return s.create(ctx, provider, event)
+	case actionReady:
+		_, err = s.prService.Ready(ctx, event.prId)
+	case actionClose:
+		_, err = s.prService.Close(ctx, event.prId)
+	case actionReopen:
+		_, err = s.prService.Reopen(ctx, event.prId)
+	case actionMerge:
+		_, err = s.prService.Merge(ctx, &dto.MergeRequest{PrId: event.prId})
+		//The PR is already merged on the hosting, a merge bypassing the team policy is recorded as forced
+		if errors.Is(err, ErrNotApproved) {
+			_, err = s.prService.Merge(ctx, &dto.MergeRequest{PrId: event.prId, Force: true})
+		}
+	}
+
+	switch {
+	case err == nil:
+		return DeliveryProcessed, "", nil
+	//PRs opened before the integration was set up are not tracked
+	case errors.Is(err, ErrNotFound):
+		return DeliveryIgnored, "pull request is not tracked", nil
+	//The PR is already in the target status
+	case errors.Is(err, ErrInvalidTransition):
+		return DeliveryIgnored, err.Error(), nil
+	}
+	return "", "", err
+}
+
+func (s *IntegrationService) create(ctx context.Context, provider string, event *hostingEvent) (string, string, error) {
+	//Checked beforehand, a failed insert would abort the transaction
+	_, err := s.prRepo.GetById(ctx, event.prId)
+	if err == nil {
+		return DeliveryIgnored, ErrPullRequestALreadyExists.Error(), nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		s.logger.ErrorContext(ctx, "IntegrationService.create:prRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return "", "", ErrInternal
+	}
+
+	authorId, err := s.integrationRepo.GetUserIdByLogin(ctx, provider, event.login)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", "", ErrIdentityNotMapped
+		}
+		s.logger.ErrorContext(ctx, "IntegrationService.create:integrationRepo.GetUserIdByLogin - Internal error", slog.String("error", err.Error()))
+		return "", "", ErrInternal
+	}
+
+	_, err = s.prService.Create(ctx, &dto.PrCreateRequest{
+		PrId:     event.prId,
+		PrName:   event.title,
+		AuthorId: authorId,
+		Draft:    event.draft,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return DeliveryProcessed, "", nil
+}
+
+func (s *IntegrationService) SetIdentity(ctx context.Context, req *dto.IdentityRequest) (*dto.Identity, error) {
+	ctx, span := tracing.Start(ctx, "IntegrationService.SetIdentity")
+	defer span.End()
+
+	identity, err := s.integrationRepo.SetIdentity(ctx, &models.Identity{
+		Provider: req.Provider,
+		Login:    req.Login,
+		UserId:   req.UserId,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "IntegrationService.SetIdentity:integrationRepo.SetIdentity - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	return toIdentityDto(identity), nil
+}
+
+func (s *IntegrationService) ListIdentities(ctx context.Context, req *dto.IdentityListRequest) ([]dto.Identity, error) {
+	ctx, span := tracing.Start(ctx, "IntegrationService.ListIdentities")
+	defer span.End()
+
+	identities, err := s.integrationRepo.GetIdentities(ctx, req.Provider)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "IntegrationService.ListIdentities:integrationRepo.GetIdentities - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	resp := make([]dto.Identity, 0, len(identities))
+	for i := range identities {
+		resp = append(resp, *toIdentityDto(&identities[i]))
+	}
+	return resp, nil
+}
+
+func (s *IntegrationService) DeleteIdentity(ctx context.Context, req *dto.IdentityDeleteRequest) error {
+	ctx, span := tracing.Start(ctx, "IntegrationService.DeleteIdentity")
+	defer span.End()
+
+	err := s.integrationRepo.DeleteIdentity(ctx, req.Provider, req.Login)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "IntegrationService.DeleteIdentity:integrationRepo.DeleteIdentity - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+	return nil
+}
+
+func toIdentityDto(identity *models.Identity) *dto.Identity {
+	return &dto.Identity{
+		Provider:  identity.Provider,
+		Login:     identity.Login,
+		UserId:    identity.UserId,
+		CreatedAt: identity.CreatedAt,
+	}
+}
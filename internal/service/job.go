@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/tracing"
+	"github.com/google/uuid"
+)
+
+type reassignTeamPayload struct {
+	TeamName string `json:"team_name"`
+	//Caller who enqueued the job, recorded in the PR history
+	ActorId   string `json:"actor_id,omitempty"`
+	ActorRole string `json:"actor_role,omitempty"`
+}
+
+type JobService struct {
+	jobRepo     repository.IJobRepo
+	teamRepo    repository.ITeamRepo
+	userRepo    repository.IUserRepo
+	prService   IPullRequestService
+	maxAttempts int
+	logger      *slog.Logger
+}
+
+func NewJobService(jobRepo repository.IJobRepo, teamRepo repository.ITeamRepo, userRepo repository.IUserRepo, prService IPullRequestService, maxAttempts int, logger *slog.Logger) *JobService {
+	return &JobService{
+		jobRepo:     jobRepo,
+		teamRepo:    teamRepo,
+		userRepo:    userRepo,
+		prService:   prService,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+func (s *JobService) EnqueueReassignTeam(ctx context.Context, teamName string) (string, error) {
+	ctx, span := tracing.Start(ctx, "JobService.EnqueueReassignTeam")
+	defer span.End()
+
+	teamId, err := s.teamRepo.GetIdByName(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "JobService.EnqueueReassignTeam:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+		return "", ErrInternal
+	}
+
+	//Team leads reassign reviews only in their own team
+	ok, err := callerLeadsTeam(ctx, s.userRepo, teamId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "JobService.EnqueueReassignTeam:callerLeadsTeam - Internal error", slog.String("error", err.Error()))
+		return "", ErrInternal
+	}
+	if !ok {
+		return "", ErrForbidden
+	}
+
+	payload := reassignTeamPayload{TeamName: teamName}
+	if principal, ok := auth.FromContext(ctx); ok {
+		payload.ActorId, payload.ActorRole = principal.UserId, principal.Role
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "JobService.EnqueueReassignTeam:json.Marshal - Internal error", slog.String("error", err.Error()))
+		return "", ErrInternal
+	}
+
+	job, err := s.jobRepo.Create(ctx, &models.Job{
+		Id:           uuid.NewString(),
+		Type:         models.JobReassignTeam,
+		Payload:      data,
+		MaxAttempts:  s.maxAttempts,
+		TraceContext: tracing.Inject(ctx),
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "JobService.EnqueueReassignTeam:jobRepo.Create - Internal error", slog.String("error", err.Error()))
+		return "", ErrInternal
+	}
+	return job.Id, nil
+}
+
+// HandleReassignTeam is the worker handler of reassign_team jobs.
+func (s *JobService) HandleReassignTeam(ctx context.Context, job *models.Job) (any, error) {
+	ctx, span := tracing.Start(ctx, "JobService.HandleReassignTeam")
+	defer span.End()
+
+	var payload reassignTeamPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+	if payload.ActorRole != "" {
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{UserId: payload.ActorId, Role: payload.ActorRole})
+	}
+
+	return s.prService.ReassignAllInactiveReviewersByTeam(ctx, payload.TeamName, func(processed int, total int) {
+		//Progress is informational, the job goes on if it is not saved
+		if err := s.jobRepo.UpdateProgress(ctx, job.Id, processed, total); err != nil {
+			s.logger.ErrorContext(ctx, "JobService.HandleReassignTeam:jobRepo.UpdateProgress - Internal error", slog.String("error", err.Error()))
+		}
+	})
+}
+
+// FailExpired marks failed the jobs which lost their worker on the last attempt, it is run by the scheduler.
+// Such jobs are not leased again, so without it they would stay running forever.
+func (s *JobService) FailExpired(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "JobService.FailExpired")
+	defer span.End()
+
+	jobsId, err := s.jobRepo.FailExpired(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "JobService.FailExpired:jobRepo.FailExpired - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+	for _, jobId := range jobsId {
+		s.logger.WarnContext(ctx, "JobService.FailExpired - Job failed, lease expired on the last attempt", slog.String("job_id", jobId))
+	}
+	return nil
+}
+
+func (s *JobService) Get(ctx context.Context, jobId string) (*dto.TaskResponse, error) {
+	ctx, span := tracing.Start(ctx, "JobService.Get")
+	defer span.End()
+
+	job, err := s.jobRepo.GetById(ctx, jobId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "JobService.Get:jobRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	return &dto.TaskResponse{
+		TaskId:   job.Id,
+		Type:     job.Type,
+		Status:   job.Status,
+		Attempts: job.Attempts,
+		Progress: dto.TaskProgress{
+			Processed: job.ProgressProcessed,
+			Total:     job.ProgressTotal,
+		},
+		Result:    job.Result,
+		Error:     job.LastError,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import "github.com/Estriper0/avito_intership/internal/models"
+
+const (
+	actionReady  = "ready"
+	actionClose  = "close"
+	actionReopen = "reopen"
+	actionMerge  = "merge"
+)
+
+// transitions is the PR state machine: action -> current status -> next status.
+var transitions = map[string]map[int]int{
+	actionReady: {
+		models.StatusDraft: models.StatusOpen,
+	},
+	actionClose: {
+		models.StatusDraft: models.StatusClosed,
+		models.StatusOpen:  models.StatusClosed,
+	},
+	actionReopen: {
+		models.StatusClosed: models.StatusOpen,
+	},
+	actionMerge: {
+		models.StatusOpen: models.StatusMerged,
+	},
+}
+
+// nextStatus returns the status the PR moves to after the action
+// and false if the action is not allowed in the current status.
+func nextStatus(action string, current int) (int, bool) {
+	next, ok := transitions[action][current]
+	return next, ok
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/tracing"
+	"github.com/google/uuid"
+)
+
+// webhookEnvelope is the body sent to the subscribers
+type webhookEnvelope struct {
+	Id         string    `json:"id"`
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+type reviewerReassignedEvent struct {
+	PrId          string `json:"pull_request_id"`
+	OldReviewerId string `json:"old_reviewer_id"`
+	NewReviewerId string `json:"new_reviewer_id"`
+	Reason        string `json:"reason"`
+}
+
+type reviewSlaBreachedEvent struct {
+	PrId       string    `json:"pull_request_id"`
+	ReviewerId string    `json:"reviewer_id"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+type usersDeactivatedEvent struct {
+	UsersId []string `json:"users_id"`
+}
+
+type webhookDeliveryPayload struct {
+	WebhookId int             `json:"webhook_id"`
+	Event     string          `json:"event"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// Outbox turns domain events into webhook delivery jobs. Jobs are written with
+// the connection of the current transaction, so a rolled back change sends nothing.
+type Outbox struct {
+	webhookRepo repository.IWebhookRepo
+	jobRepo     repository.IJobRepo
+	maxAttempts int
+	logger      *slog.Logger
+}
+
+func NewOutbox(webhookRepo repository.IWebhookRepo, jobRepo repository.IJobRepo, maxAttempts int, logger *slog.Logger) *Outbox {
+	return &Outbox{
+		webhookRepo: webhookRepo,
+		jobRepo:     jobRepo,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+// Publish schedules one delivery per subscribed webhook and item.
+func (o *Outbox) Publish(ctx context.Context, event string, items ...any) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	webhooks, err := o.webhookRepo.GetSubscribed(ctx, event)
+	if err != nil {
+		o.logger.ErrorContext(ctx, "Outbox.Publish:webhookRepo.GetSubscribed - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	occurredAt := time.Now().UTC()
+	for _, item := range items {
+		//Every subscriber gets the same event id, so the consumers can deduplicate
+		body, err := json.Marshal(webhookEnvelope{
+			Id:         uuid.NewString(),
+			Event:      event,
+			OccurredAt: occurredAt,
+			Data:       item,
+		})
+		if err != nil {
+			o.logger.ErrorContext(ctx, "Outbox.Publish:json.Marshal - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		for _, webhook := range webhooks {
+			payload, err := json.Marshal(webhookDeliveryPayload{
+				WebhookId: webhook.Id,
+				Event:     event,
+				Body:      body,
+			})
+			if err != nil {
+				o.logger.ErrorContext(ctx, "Outbox.Publish:json.Marshal - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+
+			_, err = o.jobRepo.Create(ctx, &models.Job{
+				Id:           uuid.NewString(),
+				Type:         models.JobWebhookDelivery,
+				Payload:      payload,
+				MaxAttempts:  o.maxAttempts,
+				TraceContext: tracing.Inject(ctx),
+			})
+			if err != nil {
+				o.logger.ErrorContext(ctx, "Outbox.Publish:jobRepo.Create - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+		}
+	}
+	return nil
+}
@@ -4,154 +4,707 @@ import (
 	"context"
 	"errors"
 	"log/slog"
-	"math/rand"
+	"slices"
 
+	"github.com/Estriper0/avito_intership/internal/auth"
 	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/metrics"
 	"github.com/Estriper0/avito_intership/internal/models"
 	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/tracing"
 	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
 )
 
+const (
+	defaultListLimit int = 20
+)
+
 type PullRequestService struct {
 	prRepo    repository.IPullRequestRepo
 	userRepo  repository.IUserRepo
 	teamRepo  repository.ITeamRepo
+	eventRepo repository.IPrEventRepo
+	selectors *ReviewerSelectors
+	outbox    *Outbox
 	trManager *manager.Manager
 	logger    *slog.Logger
 }
 
-func NewPullRequestService(prRepo repository.IPullRequestRepo, userRepo repository.IUserRepo, teamRepo repository.ITeamRepo, trManager *manager.Manager, logger *slog.Logger) *PullRequestService {
+func NewPullRequestService(prRepo repository.IPullRequestRepo, userRepo repository.IUserRepo, teamRepo repository.ITeamRepo, eventRepo repository.IPrEventRepo, selectors *ReviewerSelectors, outbox *Outbox, trManager *manager.Manager, logger *slog.Logger) *PullRequestService {
 	return &PullRequestService{
 		prRepo:    prRepo,
 		userRepo:  userRepo,
 		teamRepo:  teamRepo,
+		eventRepo: eventRepo,
+		selectors: selectors,
+		outbox:    outbox,
 		trManager: trManager,
 		logger:    logger,
 	}
 }
 
+// Picks reviewers among the candidates with the strategy configured for the team
+func (s *PullRequestService) selectReviewers(ctx context.Context, team *models.Team, candidates []models.User, count int) ([]string, error) {
+	return s.selectors.ForTeam(team.Name).Select(ctx, team.Id, candidates, count)
+}
+
+// Getting the team of the PR author with its reviewers settings
+func (s *PullRequestService) getAuthorTeam(ctx context.Context, authorId string) (*models.Team, error) {
+	author, err := s.userRepo.GetById(ctx, authorId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.teamRepo.GetById(ctx, author.TeamId)
+}
+
+// Picks up to count reviewers from the fallback teams of the team in their order,
+// each fallback team is asked with its own strategy. Excluded users are the author and the current reviewers.
+func (s *PullRequestService) selectFallbackReviewers(ctx context.Context, team *models.Team, exclude []string, count int) ([]string, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+
+	fallbacks, err := s.teamRepo.GetFallbacks(ctx, team.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]string, 0, count)
+	for i := range fallbacks {
+		users, err := s.userRepo.GetAllByTeam(ctx, fallbacks[i].Id)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := make([]models.User, 0, len(users))
+		for _, user := range users {
+			if user.IsActive && !slices.Contains(exclude, user.UserId) && !slices.Contains(selected, user.UserId) {
+				candidates = append(candidates, user)
+			}
+		}
+		candidates, err = s.withoutAbsent(ctx, candidates)
+		if err != nil {
+			return nil, err
+		}
+
+		reviewersId, err := s.selectReviewers(ctx, &fallbacks[i], candidates, count-len(selected))
+		if err != nil && !errors.Is(err, ErrNoCandidate) {
+			return nil, err
+		}
+		selected = append(selected, reviewersId...)
+		if len(selected) == count {
+			break
+		}
+	}
+	return selected, nil
+}
+
+// Drops the users who are absent right now, they are candidates again once the absence ends
+func (s *PullRequestService) withoutAbsent(ctx context.Context, users []models.User) ([]models.User, error) {
+	if len(users) == 0 {
+		return users, nil
+	}
+
+	usersId := make([]string, 0, len(users))
+	for _, user := range users {
+		usersId = append(usersId, user.UserId)
+	}
+	absentId, err := s.userRepo.GetAbsentIds(ctx, usersId)
+	if err != nil || len(absentId) == 0 {
+		return users, err
+	}
+
+	return slices.DeleteFunc(users, func(user models.User) bool {
+		return slices.Contains(absentId, user.UserId)
+	}), nil
+}
+
+// Checks that the caller is the user or an admin, calls outside the HTTP API are trusted
+func callerIs(ctx context.Context, userId string) bool {
+	principal, ok := auth.FromContext(ctx)
+	return !ok || principal.IsAdmin() || principal.UserId == userId
+}
+
+// Name of the caller for the PR history
+func actorFromContext(ctx context.Context) string {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return models.ActorSystem
+	}
+	if principal.UserId == "" {
+		return models.ActorAdmin
+	}
+	return principal.UserId
+}
+
+// Appends events to the PR history, must be called inside the transaction of the change
+func (s *PullRequestService) recordEvents(ctx context.Context, events ...models.PrEvent) error {
+	actor := actorFromContext(ctx)
+	for i := range events {
+		events[i].Actor = actor
+	}
+
+	if err := s.eventRepo.Add(ctx, events); err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.recordEvents:eventRepo.Add - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+
+	//Every change goes through the history, so the business counters are kept here.
+	//They are counted once the transaction is committed, a rolled back change is not counted.
+	onCommit(ctx, func() {
+		for _, event := range events {
+			switch event.Type {
+			case models.EventCreated:
+				metrics.PullRequestsCreated.Inc()
+			case models.EventMerged:
+				metrics.PullRequestsMerged.Inc()
+			case models.EventReassigned:
+				metrics.Reassignments.WithLabelValues(event.Reason).Inc()
+			}
+		}
+	})
+	return nil
+}
+
 func (s *PullRequestService) Create(ctx context.Context, pr *dto.PrCreateRequest) (*dto.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.Create")
+	defer span.End()
+
+	//Members open PRs only as themselves
+	if !callerIs(ctx, pr.AuthorId) {
+		return nil, ErrForbidden
+	}
+
 	var resp *dto.PullRequest
 	//Do everything in a transaction
-	err := s.trManager.Do(ctx, func(ctx context.Context) error {
-		exists, err := s.userRepo.ExistsById(ctx, pr.AuthorId)
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		team, err := s.getAuthorTeam(ctx, pr.AuthorId)
 		if err != nil {
-			s.logger.Error("PullRequestService.Create:userRepo.ExistsById - Internal error", slog.String("error", err.Error()))
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "PullRequestService.Create:getAuthorTeam - Internal error", slog.String("error", err.Error()))
 			return ErrInternal
-		} else if !exists {
-			return ErrNotFound
 		}
 
-		//Getting all active users from a user's team without a user
-		activeUsers, err := s.userRepo.GetActiveTeamMembersById(ctx, pr.AuthorId)
-		if err != nil {
-			s.logger.Error("PullRequestService.Create:userRepo.GetActiveTeamMembersById - Internal error", slog.String("error", err.Error()))
-			return ErrInternal
+		statusId := models.StatusOpen
+		if pr.Draft {
+			statusId = models.StatusDraft
 		}
 
 		p, err := s.prRepo.Create(ctx, &models.PullRequest{
 			PrId:     pr.PrId,
 			Name:     pr.PrName,
 			AuthorId: pr.AuthorId,
+			StatusId: statusId,
 		})
 		if err != nil {
 			if errors.Is(err, repository.ErrAlreadyExists) {
 				return ErrPullRequestALreadyExists
 			}
-			s.logger.Error("PullRequestService.Create:prRepo.Create - Internal error", slog.String("error", err.Error()))
+			s.logger.ErrorContext(ctx, "PullRequestService.Create:prRepo.Create - Internal error", slog.String("error", err.Error()))
 			return ErrInternal
 		}
 
+		if err := s.recordEvents(ctx, models.PrEvent{PrId: p.PrId, Type: models.EventCreated}); err != nil {
+			return err
+		}
+
+		//Changed files are kept for the code owners of later reassignments
+		if len(pr.ChangedFiles) > 0 {
+			err = s.prRepo.AddFiles(ctx, p.PrId, pr.ChangedFiles)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "PullRequestService.Create:prRepo.AddFiles - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+		}
+
 		//Getting a status name
 		status, err := s.prRepo.GetStatusById(ctx, p.StatusId)
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
 				return ErrNotFound
 			}
-			s.logger.Error("PullRequestService.Create:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
+			s.logger.ErrorContext(ctx, "PullRequestService.Create:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Drafts get reviewers only when they become ready
+		var reviewersId, externalId []string
+		if p.StatusId == models.StatusOpen {
+			reviewersId, externalId, err = s.assignReviewers(ctx, team, p)
+			if err != nil {
+				return err
+			}
+		}
+
+		resp = &dto.PullRequest{
+			PrId:              p.PrId,
+			PrName:            p.Name,
+			AuthorId:          p.AuthorId,
+			Status:            status,
+			AssignedReviewers: reviewersId,
+			ExternalReviewers: externalId,
+			UnderReviewed:     p.StatusId == models.StatusOpen && len(reviewersId) < team.MinReviewers,
+		}
+
+		return s.outbox.Publish(ctx, models.WebhookPrCreated, resp)
+	})
+	return resp, err
+}
+
+// Assign reviewers to the PR. Owners of the changed files are added first, one per matched rule,
+// then up to max_reviewers are filled from the author's team with the team strategy.
+// If the team cannot fill min_reviewers, the rest is taken from its fallback teams.
+// Reviewers from outside the author's team are returned separately.
+func (s *PullRequestService) assignReviewers(ctx context.Context, team *models.Team, pr *models.PullRequest) ([]string, []string, error) {
+	groups, err := s.ownerGroups(ctx, team, pr.PrId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.assignReviewers:ownerGroups - Internal error", slog.String("error", err.Error()))
+		return nil, nil, ErrInternal
+	}
+
+	var ownersId, externalId []string
+	for {
+		owner, err := s.pickOwner(ctx, team, groups, ownersId, append([]string{pr.AuthorId}, ownersId...))
+		if err != nil {
+			s.logger.ErrorContext(ctx, "PullRequestService.assignReviewers:pickOwner - Internal error", slog.String("error", err.Error()))
+			return nil, nil, ErrInternal
+		}
+		if owner == nil {
+			break
+		}
+		ownersId = append(ownersId, owner.UserId)
+		if owner.TeamId != team.Id {
+			externalId = append(externalId, owner.UserId)
+		}
+	}
+
+	//Getting all active users from a user's team without a user
+	activeUsers, err := s.userRepo.GetActiveTeamMembersById(ctx, pr.AuthorId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.assignReviewers:userRepo.GetActiveTeamMembersById - Internal error", slog.String("error", err.Error()))
+		return nil, nil, ErrInternal
+	}
+	activeUsers = slices.DeleteFunc(activeUsers, func(user models.User) bool {
+		return slices.Contains(ownersId, user.UserId)
+	})
+
+	var teamId []string
+	var selectErr error
+	if count := team.MaxReviewers - len(ownersId); count > 0 {
+		teamId, selectErr = s.selectReviewers(ctx, team, activeUsers, count)
+		if selectErr != nil && !errors.Is(selectErr, ErrNoCandidate) {
+			s.logger.ErrorContext(ctx, "PullRequestService.assignReviewers:selectReviewers - Internal error", slog.String("error", selectErr.Error()))
+			return nil, nil, ErrInternal
+		}
+	}
+	reviewersId := append(ownersId, teamId...)
+
+	fallbackId, err := s.selectFallbackReviewers(ctx, team, append([]string{pr.AuthorId}, reviewersId...), team.MinReviewers-len(reviewersId))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.assignReviewers:selectFallbackReviewers - Internal error", slog.String("error", err.Error()))
+		return nil, nil, ErrInternal
+	}
+	if len(reviewersId) == 0 && len(fallbackId) == 0 && selectErr != nil {
+		metrics.NoCandidate.WithLabelValues("assign").Inc()
+		return nil, nil, ErrNoCandidate
+	}
+	reviewersId = append(reviewersId, fallbackId...)
+	externalId = append(externalId, fallbackId...)
+
+	err = s.prRepo.AddReviewers(ctx, pr.PrId, reviewersId)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, nil, ErrPullRequestALreadyExists
+		}
+		s.logger.ErrorContext(ctx, "PullRequestService.assignReviewers:prRepo.AddReviewers - Internal error", slog.String("error", err.Error()))
+		return nil, nil, ErrInternal
+	}
+
+	events := make([]models.PrEvent, 0, len(reviewersId))
+	for _, reviewerId := range reviewersId {
+		event := models.PrEvent{PrId: pr.PrId, Type: models.EventAssigned, ReviewerId: reviewerId}
+		if slices.Contains(ownersId, reviewerId) {
+			event.Reason = models.ReasonCodeOwner
+		} else if slices.Contains(fallbackId, reviewerId) {
+			event.Reason = models.ReasonFallback
+		}
+		events = append(events, event)
+	}
+	if err := s.recordEvents(ctx, events...); err != nil {
+		return nil, nil, err
+	}
+
+	return reviewersId, externalId, nil
+}
+
+func (s *PullRequestService) Ready(ctx context.Context, prId string) (*dto.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.Ready")
+	defer span.End()
+
+	return s.transition(ctx, prId, actionReady)
+}
+
+func (s *PullRequestService) Close(ctx context.Context, prId string) (*dto.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.Close")
+	defer span.End()
+
+	return s.transition(ctx, prId, actionClose)
+}
+
+func (s *PullRequestService) Reopen(ctx context.Context, prId string) (*dto.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.Reopen")
+	defer span.End()
+
+	return s.transition(ctx, prId, actionReopen)
+}
+
+// Moves the PR to the next status according to the state machine.
+// A PR becoming open without reviewers (ready draft or reopened draft) gets them assigned.
+func (s *PullRequestService) transition(ctx context.Context, prId string, action string) (*dto.PullRequest, error) {
+	var resp *dto.PullRequest
+	//Do everything in a transaction
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		current, err := s.prRepo.GetById(ctx, prId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "PullRequestService.transition:prRepo.GetById - Internal error", slog.String("error", err.Error()))
 			return ErrInternal
 		}
 
-		//How many users will we assign as reviewers
-		var reviewersId []string
-		var count int
-		if len(activeUsers) > 2 {
-			count = 2
+		team, err := s.getAuthorTeam(ctx, current.AuthorId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "PullRequestService.transition:getAuthorTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Only the author, a lead of the author's team or an admin may change the status
+		if !callerIs(ctx, current.AuthorId) {
+			ok, err := callerLeadsTeam(ctx, s.userRepo, team.Id)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "PullRequestService.transition:callerLeadsTeam - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+			if !ok {
+				return ErrForbidden
+			}
+		}
+
+		next, ok := nextStatus(action, current.StatusId)
+		if !ok {
+			return ErrInvalidTransition
+		}
+
+		pr, err := s.prRepo.UpdateStatus(ctx, prId, next)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "PullRequestService.transition:prRepo.UpdateStatus - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		if err := s.recordEvents(ctx, models.PrEvent{PrId: prId, Type: models.EventStatusChanged, Reason: action}); err != nil {
+			return err
+		}
+
+		reviewersId, err := s.prRepo.GetReviewers(ctx, prId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "PullRequestService.transition:prRepo.GetReviewers - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		var externalId []string
+		if pr.StatusId == models.StatusOpen && len(reviewersId) == 0 {
+			reviewersId, externalId, err = s.assignReviewers(ctx, team, pr)
+			if err != nil {
+				return err
+			}
 		} else {
-			count = len(activeUsers)
+			//Reviewers of a reopened PR get the full SLA again
+			if pr.StatusId == models.StatusOpen {
+				if err := s.prRepo.ResetAssignedAt(ctx, prId); err != nil {
+					s.logger.ErrorContext(ctx, "PullRequestService.transition:prRepo.ResetAssignedAt - Internal error", slog.String("error", err.Error()))
+					return ErrInternal
+				}
+			}
+			externalId, err = s.prRepo.GetExternalReviewers(ctx, prId)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "PullRequestService.transition:prRepo.GetExternalReviewers - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
 		}
 
-		//Randomly shuffle the slice of active users and assign reviewers
-		perm := rand.Perm(len(activeUsers))
-		for i := 0; i < count; i++ {
-			reviewersId = append(reviewersId, activeUsers[perm[i]].UserId)
+		//Getting a status name
+		status, err := s.prRepo.GetStatusById(ctx, pr.StatusId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "PullRequestService.transition:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
 		}
 
-		err = s.prRepo.AddReviewers(ctx, pr.PrId, reviewersId)
+		resp = &dto.PullRequest{
+			PrId:              pr.PrId,
+			PrName:            pr.Name,
+			AuthorId:          pr.AuthorId,
+			Status:            status,
+			AssignedReviewers: reviewersId,
+			ExternalReviewers: externalId,
+			UnderReviewed:     pr.StatusId == models.StatusOpen && len(reviewersId) < team.MinReviewers,
+		}
+
+		return nil
+	})
+	return resp, err
+}
+
+func (s *PullRequestService) Merge(ctx context.Context, req *dto.MergeRequest) (*dto.MergeResponse, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.Merge")
+	defer span.End()
+
+	var resp *dto.MergeResponse
+	//Do everything in a transaction
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		current, err := s.prRepo.GetById(ctx, req.PrId)
 		if err != nil {
-			if errors.Is(err, repository.ErrAlreadyExists) {
-				return ErrPullRequestALreadyExists
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "PullRequestService.Merge:prRepo.GetById - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Only the author or an admin may merge
+		if !callerIs(ctx, current.AuthorId) {
+			return ErrForbidden
+		}
+
+		//Merge is idempotent, the state machine is checked only for the first merge
+		if _, ok := nextStatus(actionMerge, current.StatusId); !ok && current.StatusId != models.StatusMerged {
+			return ErrInvalidTransition
+		}
+
+		team, err := s.getAuthorTeam(ctx, current.AuthorId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "PullRequestService.Merge:getAuthorTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		reviews, err := s.prRepo.GetReviews(ctx, req.PrId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "PullRequestService.Merge:prRepo.GetReviews - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		if current.StatusId != models.StatusMerged && !req.Force && !approvalPolicyMet(team, reviews) {
+			return ErrNotApproved
+		}
+
+		pr, err := s.prRepo.Merge(ctx, req.PrId, req.Force)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "PullRequestService.Merge:prRepo.Merge - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Repeated merges do not change anything, so they are not recorded
+		if current.StatusId != models.StatusMerged {
+			event := models.PrEvent{PrId: req.PrId, Type: models.EventMerged}
+			if req.Force {
+				event.Reason = models.ReasonForce
+			}
+			if err := s.recordEvents(ctx, event); err != nil {
+				return err
+			}
+		}
+
+		//Getting a status name
+		status, err := s.prRepo.GetStatusById(ctx, pr.StatusId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "PullRequestService.Merge:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		reviewersId := make([]string, 0, len(reviews))
+		for _, review := range reviews {
+			reviewersId = append(reviewersId, review.UserId)
+		}
+
+		resp = &dto.MergeResponse{
+			PrId:              pr.PrId,
+			PrName:            pr.Name,
+			AuthorId:          pr.AuthorId,
+			Status:            status,
+			AssignedReviewers: reviewersId,
+			UnderReviewed:     len(reviewersId) < team.MinReviewers,
+			ForceMerged:       pr.ForceMerged,
+			MergedAt:          *pr.MergedAt,
+		}
+
+		if current.StatusId != models.StatusMerged {
+			return s.outbox.Publish(ctx, models.WebhookPrMerged, resp)
+		}
+		return nil
+	})
+	return resp, err
+}
+
+// The team policy is met when nobody requested changes and there are enough approvals.
+// A PR with fewer reviewers than the required approvals can't meet it, such PRs are merged only with force.
+func approvalPolicyMet(team *models.Team, reviews []models.Review) bool {
+	approvals := 0
+	for _, review := range reviews {
+		switch review.Decision {
+		case models.DecisionChangesRequested:
+			return false
+		case models.DecisionApproved:
+			approvals++
+		}
+	}
+
+	return approvals >= team.RequiredApprovals
+}
+
+func (s *PullRequestService) SubmitReview(ctx context.Context, req *dto.SubmitReviewRequest) (*dto.SubmitReviewResponse, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.SubmitReview")
+	defer span.End()
+
+	//Reviewers decide for themselves, admins may decide for anyone
+	if !callerIs(ctx, req.ReviewerId) {
+		return nil, ErrForbidden
+	}
+
+	var resp *dto.SubmitReviewResponse
+	//The decision and its history event are written together
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		pr, err := s.prRepo.GetById(ctx, req.PrId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "PullRequestService.SubmitReview:prRepo.GetById - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		switch pr.StatusId {
+		case models.StatusMerged:
+			return ErrReviewOnMerged
+		case models.StatusOpen:
+		default:
+			return ErrPullRequestNotOpen
+		}
+
+		review, err := s.prRepo.SetDecision(ctx, req.PrId, req.ReviewerId, req.Decision)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotAssigned
 			}
-			s.logger.Error("PullRequestService.Create:prRepo.AddReviewers - Internal error", slog.String("error", err.Error()))
+			s.logger.ErrorContext(ctx, "PullRequestService.SubmitReview:prRepo.SetDecision - Internal error", slog.String("error", err.Error()))
 			return ErrInternal
 		}
 
-		resp = &dto.PullRequest{
-			PrId:              p.PrId,
-			PrName:            p.Name,
-			AuthorId:          p.AuthorId,
-			Status:            status,
-			AssignedReviewers: reviewersId,
+		err = s.recordEvents(ctx, models.PrEvent{
+			PrId:       req.PrId,
+			Type:       models.EventReviewed,
+			ReviewerId: req.ReviewerId,
+			Reason:     req.Decision,
+		})
+		if err != nil {
+			return err
 		}
 
+		resp = &dto.SubmitReviewResponse{
+			PrId:       review.PrId,
+			ReviewerId: review.UserId,
+			Decision:   review.Decision,
+			DecidedAt:  *review.DecidedAt,
+		}
 		return nil
 	})
 	return resp, err
 }
 
-func (s *PullRequestService) Merge(ctx context.Context, prId string) (*dto.MergeResponse, error) {
-	pr, err := s.prRepo.Merge(ctx, prId)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrNotFound
+func (s *PullRequestService) Reassign(ctx context.Context, req *dto.ReassignRequest) (*dto.ReassignResponse, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.Reassign")
+	defer span.End()
+
+	var resp *dto.ReassignResponse
+	//The new reviewer and its history event are written together
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		if err := s.checkReassign(ctx, req); err != nil {
+			return err
 		}
-		s.logger.Error("PullRequestService.Merge:prRepo.Merge - Internal error", slog.String("error", err.Error()))
-		return nil, ErrInternal
+
+		var err error
+		resp, err = s.reassign(ctx, req, models.ReasonManual)
+		return err
+	})
+	return resp, err
+}
+
+// The author and the replaced reviewer may always reassign, other members only on the PRs of their team.
+// Mass and SLA reassignments are checked by their callers.
+func (s *PullRequestService) checkReassign(ctx context.Context, req *dto.ReassignRequest) error {
+	if callerIs(ctx, req.OldReviewerId) {
+		return nil
 	}
 
-	reviewersId, err := s.prRepo.GetReviewers(ctx, prId)
+	pr, err := s.prRepo.GetById(ctx, req.PrId)
 	if err != nil {
-		s.logger.Error("PullRequestService.Merge:prRepo.GetReviewers - Internal error", slog.String("error", err.Error()))
-		return nil, ErrInternal
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:prRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+	if callerIs(ctx, pr.AuthorId) {
+		return nil
 	}
 
-	//Getting a status name
-	status, err := s.prRepo.GetStatusById(ctx, pr.StatusId)
+	team, err := s.getAuthorTeam(ctx, pr.AuthorId)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrNotFound
+			return ErrNotFound
 		}
-		s.logger.Error("PullRequestService.Merge:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
-		return nil, ErrInternal
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:getAuthorTeam - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
 	}
-
-	return &dto.MergeResponse{
-		PrId:              pr.PrId,
-		PrName:            pr.Name,
-		AuthorId:          pr.AuthorId,
-		Status:            status,
-		AssignedReviewers: reviewersId,
-		MergedAt:          pr.MergedAt,
-	}, nil
+	ok, err := callerInTeam(ctx, s.userRepo, team.Id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:callerInTeam - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
 }
 
-func (s *PullRequestService) Reassign(ctx context.Context, req *dto.ReassignRequest) (*dto.ReassignResponse, error) {
+// Replaces one reviewer, the reason is recorded in the PR history
+func (s *PullRequestService) reassign(ctx context.Context, req *dto.ReassignRequest, reason string) (*dto.ReassignResponse, error) {
 	pr, err := s.prRepo.GetById(ctx, req.PrId)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
 		}
-		s.logger.Error("PullRequestService.Reassign:prRepo.GetAuthorById - Internal error", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:prRepo.GetAuthorById - Internal error", slog.String("error", err.Error()))
 		return nil, ErrInternal
 	}
 
@@ -161,17 +714,21 @@ func (s *PullRequestService) Reassign(ctx context.Context, req *dto.ReassignRequ
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
 		}
-		s.logger.Error("PullRequestService.Reassign:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
 		return nil, ErrInternal
 	}
 
-	if status == "MERGED" {
+	switch pr.StatusId {
+	case models.StatusMerged:
 		return nil, ErrPullRequestMerged
+	case models.StatusOpen:
+	default:
+		return nil, ErrPullRequestNotOpen
 	}
 
 	reviewers, err := s.prRepo.GetReviewers(ctx, req.PrId)
 	if err != nil {
-		s.logger.Error("PullRequestService.Reassign:prRepo.GetReviewers - Internal error", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:prRepo.GetReviewers - Internal error", slog.String("error", err.Error()))
 		return nil, ErrInternal
 	}
 
@@ -188,14 +745,24 @@ func (s *PullRequestService) Reassign(ctx context.Context, req *dto.ReassignRequ
 		return nil, ErrNotFound
 	}
 
+	team, err := s.getAuthorTeam(ctx, pr.AuthorId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:getAuthorTeam - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
 	//Getting all active users from a user's team without a author
 	activeUsers, err := s.userRepo.GetActiveTeamMembersById(ctx, pr.AuthorId)
 	if err != nil {
-		return nil, err
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:userRepo.GetActiveTeamMembersById - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
 	}
 
 	//Create a candidate slice based on active users, which does not include already assigned reviewers.
-	candidate := make([]string, 0, len(activeUsers))
+	candidate := make([]models.User, 0, len(activeUsers))
 	for _, user := range activeUsers {
 		alreadyReviewer := false
 		for _, reviewer := range reviewers {
@@ -205,23 +772,77 @@ func (s *PullRequestService) Reassign(ctx context.Context, req *dto.ReassignRequ
 			}
 		}
 		if !alreadyReviewer {
-			candidate = append(candidate, user.UserId)
+			candidate = append(candidate, user)
+		}
+	}
+
+	//The code ownership covered by the old reviewer has to stay covered
+	groups, err := s.ownerGroups(ctx, team, pr.PrId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:ownerGroups - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	owner, err := s.replacingOwner(ctx, team, groups, pr.AuthorId, reviewers, req.OldReviewerId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:replacingOwner - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	var selected []string
+	if owner != nil {
+		selected = []string{owner.UserId}
+	} else {
+		selected, err = s.selectReviewers(ctx, team, candidate, 1)
+		if err != nil && !errors.Is(err, ErrNoCandidate) {
+			s.logger.ErrorContext(ctx, "PullRequestService.Reassign:selectReviewers - Internal error", slog.String("error", err.Error()))
+			return nil, ErrInternal
+		}
+	}
+
+	//The team has nobody left, look in the fallback teams
+	if len(selected) == 0 {
+		selected, err = s.selectFallbackReviewers(ctx, team, append([]string{pr.AuthorId}, reviewers...), 1)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "PullRequestService.Reassign:selectFallbackReviewers - Internal error", slog.String("error", err.Error()))
+			return nil, ErrInternal
 		}
 	}
 
-	if len(candidate) == 0 {
+	if len(selected) == 0 {
+		metrics.NoCandidate.WithLabelValues("reassign").Inc()
 		return nil, ErrNoCandidate
 	}
 
-	newReviewerId, err := s.prRepo.UpdateReviewer(ctx, req.PrId, req.OldReviewerId, candidate[rand.Intn(len(candidate))])
+	newReviewerId, err := s.prRepo.UpdateReviewer(ctx, req.PrId, req.OldReviewerId, selected[0])
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
 		}
-		s.logger.Error("PullRequestService.Reassign:prRepo.UpdateReviewer - Internal error", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:prRepo.UpdateReviewer - Internal error", slog.String("error", err.Error()))
 		return nil, ErrInternal
 	}
 
+	err = s.recordEvents(ctx, models.PrEvent{
+		PrId:          req.PrId,
+		Type:          models.EventReassigned,
+		ReviewerId:    newReviewerId,
+		OldReviewerId: req.OldReviewerId,
+		Reason:        reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.outbox.Publish(ctx, models.WebhookPrReviewerReassigned, reviewerReassignedEvent{
+		PrId:          req.PrId,
+		OldReviewerId: req.OldReviewerId,
+		NewReviewerId: newReviewerId,
+		Reason:        reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	//Replacing the old reviewer with a new.
 	for i, reviewer := range reviewers {
 		if reviewer == req.OldReviewerId {
@@ -230,6 +851,12 @@ func (s *PullRequestService) Reassign(ctx context.Context, req *dto.ReassignRequ
 		}
 	}
 
+	externalId, err := s.prRepo.GetExternalReviewers(ctx, req.PrId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.Reassign:prRepo.GetExternalReviewers - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
 	return &dto.ReassignResponse{
 		PR: &dto.PullRequest{
 			PrId:              pr.PrId,
@@ -237,49 +864,461 @@ func (s *PullRequestService) Reassign(ctx context.Context, req *dto.ReassignRequ
 			AuthorId:          pr.AuthorId,
 			Status:            status,
 			AssignedReviewers: reviewers,
+			ExternalReviewers: externalId,
+			UnderReviewed:     len(reviewers) < team.MinReviewers,
 		},
 		NewReviewerId: newReviewerId,
 	}, nil
 }
 
-func (s *PullRequestService) ReassignAllInactiveReviewersByTeam(ctx context.Context, teamName string) ([]dto.MassReassignResponse, error) {
+func (s *PullRequestService) ReassignAllInactiveReviewersByTeam(ctx context.Context, teamName string, progress ProgressFunc) ([]dto.MassReassignResponse, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.ReassignAllInactiveReviewersByTeam")
+	defer span.End()
+
 	var resp []dto.MassReassignResponse
+	if progress == nil {
+		progress = func(int, int) {}
+	}
 
 	//Do everything in a transaction
-	err := s.trManager.Do(ctx, func(ctx context.Context) error {
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
 		teamId, err := s.teamRepo.GetIdByName(ctx, teamName)
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
 				return ErrNotFound
 			}
-			s.logger.Error("PullRequestService.GetAllInactiveReviewersByTeam:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+			s.logger.ErrorContext(ctx, "PullRequestService.GetAllInactiveReviewersByTeam:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
 			return ErrInternal
 		}
 
 		reviewers, err := s.prRepo.GetAllInactiveReviewersByTeam(ctx, teamId)
 		if err != nil {
-			s.logger.Error("PullRequestService.GetAllInactiveReviewersByTeam:prRepo.GetAllInactiveReviewersByTeam - Internal error", slog.String("error", err.Error()))
+			s.logger.ErrorContext(ctx, "PullRequestService.GetAllInactiveReviewersByTeam:prRepo.GetAllInactiveReviewersByTeam - Internal error", slog.String("error", err.Error()))
 			return ErrInternal
 		}
-		for _, reviewer := range reviewers {
-			newReviewer, err := s.Reassign(ctx, &dto.ReassignRequest{
+		//Skipped reviewers are reported too, so the caller sees every processed slot
+		resp = make([]dto.MassReassignResponse, 0, len(reviewers))
+		progress(0, len(reviewers))
+		for i, reviewer := range reviewers {
+			newReviewer, err := s.reassign(ctx, &dto.ReassignRequest{
 				PrId:          reviewer.PrId,
 				OldReviewerId: reviewer.UserId,
-			})
+			}, models.ReasonMass)
 			if err != nil {
-				if errors.Is(err, ErrNoCandidate){
-					continue
+				if !errors.Is(err, ErrNoCandidate) {
+					return err
+				}
+				resp = append(resp, dto.MassReassignResponse{
+					PrId:          reviewer.PrId,
+					OldReviewerId: reviewer.UserId,
+					Skipped:       true,
+					Reason:        err.Error(),
+				})
+			} else {
+				resp = append(resp, dto.MassReassignResponse{
+					PrId:          reviewer.PrId,
+					OldReviewerId: reviewer.UserId,
+					NewReviewerId: newReviewer.NewReviewerId,
+				})
+			}
+			progress(i+1, len(reviewers))
+		}
+		return nil
+	})
+
+	return resp, err
+}
+
+// ReassignOpenReviewsOfUsers replaces the users on every OPEN PR they review.
+// Reviewers and code owner groups are loaded once per PR, the author team and its candidates once per author,
+// and the open review counts of the candidates once per user. Only slots without a candidate in the team
+// look up the fallback teams. All replacements, the history and the webhooks are written with one statement each.
+// The reason is written to the history, e.g. deactivation or team change.
+func (s *PullRequestService) ReassignOpenReviewsOfUsers(ctx context.Context, usersId []string, reason string) ([]dto.MassReassignResponse, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.ReassignOpenReviewsOfUsers")
+	defer span.End()
+
+	return s.reassignOpenReviews(ctx, usersId, 0, reason)
+}
+
+// ReassignOpenReviewsInTeam replaces the users only on the OPEN PRs of the team members, as ReassignOpenReviewsOfUsers.
+// Reviews of other teams' PRs, e.g. as a code owner or from a fallback team, are kept.
+func (s *PullRequestService) ReassignOpenReviewsInTeam(ctx context.Context, usersId []string, teamId int, reason string) ([]dto.MassReassignResponse, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.ReassignOpenReviewsInTeam")
+	defer span.End()
+
+	return s.reassignOpenReviews(ctx, usersId, teamId, reason)
+}
+
+// Replaces the users on the OPEN PRs of the authors in the team, of all authors if authorTeamId is 0
+func (s *PullRequestService) reassignOpenReviews(ctx context.Context, usersId []string, authorTeamId int, reason string) ([]dto.MassReassignResponse, error) {
+	var resp []dto.MassReassignResponse
+
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		slots, err := s.prRepo.GetOpenReviewsByUsers(ctx, usersId, authorTeamId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "PullRequestService.ReassignOpenReviewsOfUsers:prRepo.GetOpenReviewsByUsers - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		resp = make([]dto.MassReassignResponse, 0, len(slots))
+		if len(slots) == 0 {
+			return nil
+		}
+
+		prIds := make([]string, 0, len(slots))
+		for _, slot := range slots {
+			if len(prIds) == 0 || prIds[len(prIds)-1] != slot.PrId {
+				prIds = append(prIds, slot.PrId)
+			}
+		}
+		reviewersByPr, err := s.prRepo.GetReviewersByPrIds(ctx, prIds)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "PullRequestService.ReassignOpenReviewsOfUsers:prRepo.GetReviewersByPrIds - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Replacements are written at the end, so the load of the new reviewers is counted here
+		ctx, load := withReviewLoad(ctx)
+
+		teams := make(map[string]*models.Team)
+		activeUsers := make(map[string][]models.User)
+		groupsByPr := make(map[string][]ownerGroup)
+		changes := make([]models.ReviewerChange, 0, len(slots))
+		events := make([]models.PrEvent, 0, len(slots))
+		notifications := make([]any, 0, len(slots))
+		for _, slot := range slots {
+			team, ok := teams[slot.AuthorId]
+			if !ok {
+				team, err = s.getAuthorTeam(ctx, slot.AuthorId)
+				if err != nil {
+					s.logger.ErrorContext(ctx, "PullRequestService.ReassignOpenReviewsOfUsers:getAuthorTeam - Internal error", slog.String("error", err.Error()))
+					return ErrInternal
+				}
+				teams[slot.AuthorId] = team
+			}
+
+			users, ok := activeUsers[slot.AuthorId]
+			if !ok {
+				//Users are deactivated or moved earlier in the same transaction, so they are not candidates
+				users, err = s.userRepo.GetActiveTeamMembersById(ctx, slot.AuthorId)
+				if err != nil {
+					s.logger.ErrorContext(ctx, "PullRequestService.ReassignOpenReviewsOfUsers:userRepo.GetActiveTeamMembersById - Internal error", slog.String("error", err.Error()))
+					return ErrInternal
+				}
+				activeUsers[slot.AuthorId] = users
+
+				//One query for the load of the whole team
+				usersId := make([]string, 0, len(users))
+				for _, user := range users {
+					usersId = append(usersId, user.UserId)
+				}
+				if _, err := load.fetch(ctx, s.userRepo, usersId); err != nil {
+					s.logger.ErrorContext(ctx, "PullRequestService.ReassignOpenReviewsOfUsers:userRepo.CountOpenReviews - Internal error", slog.String("error", err.Error()))
+					return ErrInternal
+				}
+			}
+
+			groups, ok := groupsByPr[slot.PrId]
+			if !ok {
+				groups, err = s.ownerGroups(ctx, team, slot.PrId)
+				if err != nil {
+					s.logger.ErrorContext(ctx, "PullRequestService.ReassignOpenReviewsOfUsers:ownerGroups - Internal error", slog.String("error", err.Error()))
+					return ErrInternal
+				}
+				groupsByPr[slot.PrId] = groups
+			}
+
+			reviewers := reviewersByPr[slot.PrId]
+			candidates := make([]models.User, 0, len(users))
+			for _, user := range users {
+				if !slices.Contains(reviewers, user.UserId) {
+					candidates = append(candidates, user)
+				}
+			}
+
+			owner, err := s.replacingOwner(ctx, team, groups, slot.AuthorId, reviewers, slot.UserId)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "PullRequestService.ReassignOpenReviewsOfUsers:replacingOwner - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+
+			var selected []string
+			if owner != nil {
+				selected = []string{owner.UserId}
+			} else {
+				selected, err = s.selectReviewers(ctx, team, candidates, 1)
+				if err != nil && !errors.Is(err, ErrNoCandidate) {
+					s.logger.ErrorContext(ctx, "PullRequestService.ReassignOpenReviewsOfUsers:selectReviewers - Internal error", slog.String("error", err.Error()))
+					return ErrInternal
 				}
-				return err
 			}
+			if len(selected) == 0 {
+				selected, err = s.selectFallbackReviewers(ctx, team, append([]string{slot.AuthorId}, reviewers...), 1)
+				if err != nil {
+					s.logger.ErrorContext(ctx, "PullRequestService.ReassignOpenReviewsOfUsers:selectFallbackReviewers - Internal error", slog.String("error", err.Error()))
+					return ErrInternal
+				}
+			}
+			if len(selected) == 0 {
+				metrics.NoCandidate.WithLabelValues("reassign").Inc()
+				resp = append(resp, dto.MassReassignResponse{
+					PrId:          slot.PrId,
+					OldReviewerId: slot.UserId,
+					Skipped:       true,
+					Reason:        ErrNoCandidate.Error(),
+				})
+				continue
+			}
+
+			newReviewerId := selected[0]
+			changes = append(changes, models.ReviewerChange{
+				PrId:          slot.PrId,
+				OldReviewerId: slot.UserId,
+				NewReviewerId: newReviewerId,
+			})
+			load.replaced(slot.UserId, newReviewerId)
+
+			//Keep the reviewers in sync for the next slots of the same PR
+			reviewersByPr[slot.PrId] = append(slices.DeleteFunc(reviewers, func(reviewer string) bool {
+				return reviewer == slot.UserId
+			}), newReviewerId)
+
 			resp = append(resp, dto.MassReassignResponse{
-				PrId:          reviewer.PrId,
-				OldReviewerId: reviewer.UserId,
-				NewReviewerId: newReviewer.NewReviewerId,
+				PrId:          slot.PrId,
+				OldReviewerId: slot.UserId,
+				NewReviewerId: newReviewerId,
+			})
+			events = append(events, models.PrEvent{
+				PrId:          slot.PrId,
+				Type:          models.EventReassigned,
+				ReviewerId:    newReviewerId,
+				OldReviewerId: slot.UserId,
+				Reason:        reason,
+			})
+			notifications = append(notifications, reviewerReassignedEvent{
+				PrId:          slot.PrId,
+				OldReviewerId: slot.UserId,
+				NewReviewerId: newReviewerId,
+				Reason:        reason,
 			})
 		}
-		return nil
+
+		if err := s.prRepo.UpdateReviewers(ctx, changes); err != nil {
+			s.logger.ErrorContext(ctx, "PullRequestService.ReassignOpenReviewsOfUsers:prRepo.UpdateReviewers - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//History of all reassignments is written with one statement
+		if err := s.recordEvents(ctx, events...); err != nil {
+			return err
+		}
+		return s.outbox.Publish(ctx, models.WebhookPrReviewerReassigned, notifications...)
 	})
 
 	return resp, err
 }
+
+func (s *PullRequestService) Get(ctx context.Context, prId string) (*dto.PullRequestDetails, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.Get")
+	defer span.End()
+
+	pr, err := s.prRepo.GetById(ctx, prId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "PullRequestService.Get:prRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	//Getting a status name
+	status, err := s.prRepo.GetStatusById(ctx, pr.StatusId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "PullRequestService.Get:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	team, err := s.getAuthorTeam(ctx, pr.AuthorId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "PullRequestService.Get:getAuthorTeam - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	reviews, err := s.prRepo.GetReviews(ctx, prId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.Get:prRepo.GetReviews - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	externalId, err := s.prRepo.GetExternalReviewers(ctx, prId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.Get:prRepo.GetExternalReviewers - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	files, err := s.prRepo.GetFiles(ctx, prId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.Get:prRepo.GetFiles - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	reviewersId := make([]string, 0, len(reviews))
+	decisions := make([]dto.ReviewDecision, 0, len(reviews))
+	for _, review := range reviews {
+		reviewersId = append(reviewersId, review.UserId)
+		decisions = append(decisions, dto.ReviewDecision{
+			ReviewerId: review.UserId,
+			Decision:   review.Decision,
+			DecidedAt:  review.DecidedAt,
+		})
+	}
+
+	return &dto.PullRequestDetails{
+		PrId:              pr.PrId,
+		PrName:            pr.Name,
+		AuthorId:          pr.AuthorId,
+		Status:            status,
+		AssignedReviewers: reviewersId,
+		ExternalReviewers: externalId,
+		Reviews:           decisions,
+		ChangedFiles:      files,
+		UnderReviewed:     pr.StatusId == models.StatusOpen && len(reviewersId) < team.MinReviewers,
+		ForceMerged:       pr.ForceMerged,
+		CreatedAt:         pr.CreatedAt,
+		MergedAt:          pr.MergedAt,
+		ClosedAt:          pr.ClosedAt,
+	}, nil
+}
+
+func (s *PullRequestService) History(ctx context.Context, prId string) (*dto.PrHistoryResponse, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.History")
+	defer span.End()
+
+	_, err := s.prRepo.GetById(ctx, prId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "PullRequestService.History:prRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	events, err := s.eventRepo.GetByPrId(ctx, prId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.History:eventRepo.GetByPrId - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	resp := &dto.PrHistoryResponse{
+		PrId:   prId,
+		Events: make([]dto.PrEvent, 0, len(events)),
+	}
+	for _, event := range events {
+		resp.Events = append(resp.Events, dto.PrEvent{
+			Type:          event.Type,
+			Actor:         event.Actor,
+			ReviewerId:    event.ReviewerId,
+			OldReviewerId: event.OldReviewerId,
+			Reason:        event.Reason,
+			CreatedAt:     event.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+func (s *PullRequestService) List(ctx context.Context, req *dto.PrListRequest) (*dto.PrListResponse, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.List")
+	defer span.End()
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+
+	filter := &models.PullRequestFilter{
+		TeamName:    req.TeamName,
+		AuthorId:    req.AuthorId,
+		ReviewerId:  req.ReviewerId,
+		Status:      req.Status,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		//One extra row tells whether there is a next page
+		Limit: limit + 1,
+	}
+	if req.Cursor != "" {
+		createdAt, prId, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.AfterCreatedAt = &createdAt
+		filter.AfterPrId = prId
+	}
+
+	prs, err := s.prRepo.List(ctx, filter)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.List:prRepo.List - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	resp := &dto.PrListResponse{
+		PullRequests: make([]dto.PrListItem, 0, min(len(prs), limit)),
+	}
+	if len(prs) > limit {
+		prs = prs[:limit]
+		last := prs[len(prs)-1]
+		resp.NextCursor = encodeCursor(last.CreatedAt, last.PrId)
+	}
+	if len(prs) == 0 {
+		return resp, nil
+	}
+
+	prIds := make([]string, 0, len(prs))
+	for _, pr := range prs {
+		prIds = append(prIds, pr.PrId)
+	}
+
+	//Getting reviewers of the whole page in one query
+	reviewers, err := s.prRepo.GetReviewersByPrIds(ctx, prIds)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.List:prRepo.GetReviewersByPrIds - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	statuses := make(map[int]string)
+	for _, pr := range prs {
+		//Getting a status name, there are only a few of them
+		status, ok := statuses[pr.StatusId]
+		if !ok {
+			status, err = s.prRepo.GetStatusById(ctx, pr.StatusId)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "PullRequestService.List:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
+				return nil, ErrInternal
+			}
+			statuses[pr.StatusId] = status
+		}
+
+		reviewersId := reviewers[pr.PrId]
+		if reviewersId == nil {
+			reviewersId = []string{}
+		}
+
+		resp.PullRequests = append(resp.PullRequests, dto.PrListItem{
+			PrId:              pr.PrId,
+			PrName:            pr.Name,
+			AuthorId:          pr.AuthorId,
+			Status:            status,
+			AssignedReviewers: reviewersId,
+			CreatedAt:         pr.CreatedAt,
+			MergedAt:          pr.MergedAt,
+			ClosedAt:          pr.ClosedAt,
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApprovalPolicyMet(t *testing.T) {
+	review := func(decision string) models.Review {
+		return models.Review{Decision: decision}
+	}
+
+	tests := []struct {
+		name     string
+		required int
+		reviews  []models.Review
+		want     bool
+	}{
+		{
+			name:     "enough approvals",
+			required: 2,
+			reviews:  []models.Review{review(models.DecisionApproved), review(models.DecisionApproved), review("")},
+			want:     true,
+		},
+		{
+			name:     "not enough approvals",
+			required: 2,
+			reviews:  []models.Review{review(models.DecisionApproved), review(models.DecisionCommented), review("")},
+			want:     false,
+		},
+		{
+			name:     "changes requested",
+			required: 1,
+			reviews:  []models.Review{review(models.DecisionApproved), review(models.DecisionApproved), review(models.DecisionChangesRequested)},
+			want:     false,
+		},
+		{
+			name:     "no reviewers",
+			required: 1,
+			reviews:  nil,
+			want:     false,
+		},
+		{
+			name:     "fewer reviewers than required approvals",
+			required: 2,
+			reviews:  []models.Review{review(models.DecisionApproved)},
+			want:     false,
+		},
+		{
+			name:     "no approvals required",
+			required: 0,
+			reviews:  nil,
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team := &models.Team{RequiredApprovals: tt.required}
+			assert.Equal(t, tt.want, approvalPolicyMet(team, tt.reviews))
+		})
+	}
+}
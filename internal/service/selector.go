@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+)
+
+const (
+	StrategyRandom      = "random"
+	StrategyLeastLoaded = "least_loaded"
+	StrategyRoundRobin  = "round_robin"
+	StrategyWeighted    = "weighted"
+)
+
+// ReviewerSelector picks up to count reviewers from the candidates of a team.
+type ReviewerSelector interface {
+	Select(ctx context.Context, teamId int, candidates []models.User, count int) ([]string, error)
+}
+
+// ReviewerSelectors holds the default strategy and the per-team overrides.
+type ReviewerSelectors struct {
+	def    ReviewerSelector
+	byTeam map[string]ReviewerSelector
+}
+
+func NewReviewerSelectors(cfg config.ReviewConfig, userRepo repository.IUserRepo) (*ReviewerSelectors, error) {
+	//One instance per strategy, so the round-robin state is shared by all teams using it
+	cache := make(map[string]ReviewerSelector)
+	get := func(strategy string) (ReviewerSelector, error) {
+		if selector, ok := cache[strategy]; ok {
+			return selector, nil
+		}
+		selector, err := NewReviewerSelector(strategy, cfg, userRepo)
+		if err != nil {
+			return nil, err
+		}
+		cache[strategy] = selector
+		return selector, nil
+	}
+
+	def, err := get(cfg.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	byTeam := make(map[string]ReviewerSelector, len(cfg.TeamStrategies))
+	for teamName, strategy := range cfg.TeamStrategies {
+		selector, err := get(strategy)
+		if err != nil {
+			return nil, fmt.Errorf("team %s: %w", teamName, err)
+		}
+		byTeam[teamName] = selector
+	}
+
+	return &ReviewerSelectors{
+		def:    def,
+		byTeam: byTeam,
+	}, nil
+}
+
+// ForTeam returns the strategy configured for the team or the default one.
+func (s *ReviewerSelectors) ForTeam(teamName string) ReviewerSelector {
+	if selector, ok := s.byTeam[teamName]; ok {
+		return selector
+	}
+	return s.def
+}
+
+func NewReviewerSelector(strategy string, cfg config.ReviewConfig, userRepo repository.IUserRepo) (ReviewerSelector, error) {
+	switch strategy {
+	case "", StrategyRandom:
+		return &RandomSelector{}, nil
+	case StrategyLeastLoaded:
+		return &LeastLoadedSelector{
+			userRepo: userRepo,
+			maxOpen:  cfg.MaxOpenReviews,
+			userMax:  cfg.UserMaxOpenReviews,
+		}, nil
+	case StrategyRoundRobin:
+		return &RoundRobinSelector{last: make(map[int]string)}, nil
+	case StrategyWeighted:
+		return &WeightedSelector{weights: cfg.Weights}, nil
+	}
+	return nil, fmt.Errorf("unknown reviewer selection strategy: %s", strategy)
+}
+
+// RandomSelector picks reviewers uniformly at random.
+type RandomSelector struct{}
+
+func (s *RandomSelector) Select(ctx context.Context, teamId int, candidates []models.User, count int) ([]string, error) {
+	count = min(count, len(candidates))
+
+	//Randomly shuffle the slice of candidates and take the first ones
+	perm := rand.Perm(len(candidates))
+	reviewersId := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		reviewersId = append(reviewersId, candidates[perm[i]].UserId)
+	}
+	return reviewersId, nil
+}
+
+// LeastLoadedSelector picks reviewers with the fewest open reviews.
+// Ties are broken by user_id, users at their open review cap are skipped.
+type LeastLoadedSelector struct {
+	userRepo repository.IUserRepo
+	maxOpen  int
+	userMax  map[string]int
+}
+
+func (s *LeastLoadedSelector) Select(ctx context.Context, teamId int, candidates []models.User, count int) ([]string, error) {
+	count = min(count, len(candidates))
+	if count == 0 {
+		return nil, nil
+	}
+
+	usersId := make([]string, 0, len(candidates))
+	for _, user := range candidates {
+		usersId = append(usersId, user.UserId)
+	}
+
+	load, err := countOpenReviews(ctx, s.userRepo, usersId)
+	if err != nil {
+		return nil, err
+	}
+
+	//Leave only users who can take one more review
+	available := usersId[:0]
+	for _, userId := range usersId {
+		if limit, ok := s.limit(userId); ok && load[userId] >= limit {
+			continue
+		}
+		available = append(available, userId)
+	}
+	if len(available) == 0 {
+		return nil, ErrNoCandidate
+	}
+
+	sort.Slice(available, func(i, j int) bool {
+		if load[available[i]] != load[available[j]] {
+			return load[available[i]] < load[available[j]]
+		}
+		return available[i] < available[j]
+	})
+
+	return available[:min(count, len(available))], nil
+}
+
+// limit returns the open review cap of the user, ok is false if the user has no cap.
+// A user override of 0 takes no reviews at all, a negative one removes the global cap.
+func (s *LeastLoadedSelector) limit(userId string) (int, bool) {
+	if limit, ok := s.userMax[userId]; ok {
+		return limit, limit >= 0
+	}
+	return s.maxOpen, s.maxOpen > 0
+}
+
+type reviewLoadKey struct{}
+
+// reviewLoad keeps the open review counts for a batch of assignments written at once,
+// so every user is counted once and the assignments of the batch are added on top
+type reviewLoad struct {
+	mu      sync.Mutex
+	counted map[string]int
+	added   map[string]int
+}
+
+// withReviewLoad makes the open review counts of ctx cached, the caller reports its assignments with replaced
+func withReviewLoad(ctx context.Context) (context.Context, *reviewLoad) {
+	load := &reviewLoad{counted: make(map[string]int), added: make(map[string]int)}
+	return context.WithValue(ctx, reviewLoadKey{}, load), load
+}
+
+// fetch returns the counts of the users, only the users not counted before are queried
+func (l *reviewLoad) fetch(ctx context.Context, userRepo repository.IUserRepo, usersId []string) (map[string]int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var missing []string
+	for _, userId := range usersId {
+		if _, ok := l.counted[userId]; !ok {
+			missing = append(missing, userId)
+		}
+	}
+	if len(missing) > 0 {
+		counts, err := userRepo.CountOpenReviews(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, userId := range missing {
+			l.counted[userId] = counts[userId]
+		}
+	}
+
+	load := make(map[string]int, len(usersId))
+	for _, userId := range usersId {
+		load[userId] = l.counted[userId] + l.added[userId]
+	}
+	return load, nil
+}
+
+// replaced moves a review of the batch from the old reviewer to the new one
+func (l *reviewLoad) replaced(oldReviewerId string, newReviewerId string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.added[oldReviewerId]--
+	l.added[newReviewerId]++
+}
+
+// Counts the open reviews of the users, from the cache of ctx if there is one
+func countOpenReviews(ctx context.Context, userRepo repository.IUserRepo, usersId []string) (map[string]int, error) {
+	if load, ok := ctx.Value(reviewLoadKey{}).(*reviewLoad); ok {
+		return load.fetch(ctx, userRepo, usersId)
+	}
+	return userRepo.CountOpenReviews(ctx, usersId)
+}
+
+// RoundRobinSelector walks through the team members ordered by user_id,
+// continuing after the last assigned reviewer of the team.
+type RoundRobinSelector struct {
+	mu   sync.Mutex
+	last map[int]string
+}
+
+func (s *RoundRobinSelector) Select(ctx context.Context, teamId int, candidates []models.User, count int) ([]string, error) {
+	count = min(count, len(candidates))
+	if count == 0 {
+		return nil, nil
+	}
+
+	usersId := make([]string, 0, len(candidates))
+	for _, user := range candidates {
+		usersId = append(usersId, user.UserId)
+	}
+	sort.Strings(usersId)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	//Start from the first user after the last assigned one
+	start := sort.SearchStrings(usersId, s.last[teamId])
+	if start < len(usersId) && usersId[start] == s.last[teamId] {
+		start++
+	}
+
+	reviewersId := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		reviewersId = append(reviewersId, usersId[(start+i)%len(usersId)])
+	}
+	s.last[teamId] = reviewersId[len(reviewersId)-1]
+
+	return reviewersId, nil
+}
+
+// WeightedSelector picks reviewers randomly with probability proportional to the user weight.
+type WeightedSelector struct {
+	weights map[string]int
+}
+
+func (s *WeightedSelector) Select(ctx context.Context, teamId int, candidates []models.User, count int) ([]string, error) {
+	count = min(count, len(candidates))
+
+	type weighted struct {
+		userId string
+		key    float64
+	}
+
+	//Weighted sampling without replacement: every user gets a key u^(1/w) and the largest keys win
+	keys := make([]weighted, 0, len(candidates))
+	for _, user := range candidates {
+		weight, ok := s.weights[user.UserId]
+		if !ok {
+			weight = 1
+		}
+		if weight <= 0 {
+			continue
+		}
+		keys = append(keys, weighted{
+			userId: user.UserId,
+			key:    math.Pow(rand.Float64(), 1/float64(weight)),
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].key > keys[j].key
+	})
+
+	count = min(count, len(keys))
+	reviewersId := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		reviewersId = append(reviewersId, keys[i].userId)
+	}
+	return reviewersId, nil
+}
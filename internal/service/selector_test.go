@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReviewerSelectors(t *testing.T) {
+	userRepo := &stubUserRepo{}
+
+	selectors, err := NewReviewerSelectors(config.ReviewConfig{
+		Strategy: StrategyLeastLoaded,
+		TeamStrategies: map[string]string{
+			"payments": StrategyRoundRobin,
+			"search":   StrategyRoundRobin,
+			"mobile":   StrategyWeighted,
+		},
+	}, userRepo)
+	require.NoError(t, err)
+
+	assert.IsType(t, &LeastLoadedSelector{}, selectors.ForTeam("backend"))
+	assert.IsType(t, &RoundRobinSelector{}, selectors.ForTeam("payments"))
+	assert.IsType(t, &WeightedSelector{}, selectors.ForTeam("mobile"))
+	//Teams with the same strategy share its state
+	assert.Same(t, selectors.ForTeam("payments"), selectors.ForTeam("search"))
+
+	selectors, err = NewReviewerSelectors(config.ReviewConfig{}, userRepo)
+	require.NoError(t, err)
+	assert.IsType(t, &RandomSelector{}, selectors.ForTeam("backend"))
+
+	_, err = NewReviewerSelectors(config.ReviewConfig{Strategy: "fastest"}, userRepo)
+	assert.ErrorContains(t, err, "unknown reviewer selection strategy: fastest")
+
+	_, err = NewReviewerSelectors(config.ReviewConfig{
+		Strategy:       StrategyRandom,
+		TeamStrategies: map[string]string{"payments": "fastest"},
+	}, userRepo)
+	assert.ErrorContains(t, err, "team payments")
+}
+
+func TestRandomSelector(t *testing.T) {
+	ctx := context.Background()
+	selector := &RandomSelector{}
+	candidates := users("u1", "u2", "u3")
+
+	for i := 0; i < 20; i++ {
+		reviewersId, err := selector.Select(ctx, 1, candidates, 2)
+		require.NoError(t, err)
+		require.Len(t, reviewersId, 2)
+		assert.NotEqual(t, reviewersId[0], reviewersId[1])
+		assert.Subset(t, []string{"u1", "u2", "u3"}, reviewersId)
+	}
+
+	reviewersId, err := selector.Select(ctx, 1, candidates, 5)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"u1", "u2", "u3"}, reviewersId)
+
+	reviewersId, err = selector.Select(ctx, 1, nil, 2)
+	require.NoError(t, err)
+	assert.Empty(t, reviewersId)
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	ctx := context.Background()
+	selector := &RoundRobinSelector{last: make(map[int]string)}
+	//The order of the candidates does not matter, the queue goes by user_id
+	candidates := users("u3", "u1", "u2")
+
+	expected := [][]string{
+		{"u1", "u2"},
+		{"u3", "u1"},
+		{"u2", "u3"},
+	}
+	for _, want := range expected {
+		reviewersId, err := selector.Select(ctx, 1, candidates, 2)
+		require.NoError(t, err)
+		assert.Equal(t, want, reviewersId)
+	}
+
+	//Every team has its own queue
+	reviewersId, err := selector.Select(ctx, 2, candidates, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u1"}, reviewersId)
+
+	//The queue continues after the last reviewer even if they are not a candidate anymore
+	reviewersId, err = selector.Select(ctx, 1, users("u1", "u4"), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u4"}, reviewersId)
+}
+
+func TestWeightedSelector(t *testing.T) {
+	ctx := context.Background()
+	selector := &WeightedSelector{weights: map[string]int{"u1": 0, "u2": 100}}
+	candidates := users("u1", "u2", "u3")
+
+	picked := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		reviewersId, err := selector.Select(ctx, 1, candidates, 1)
+		require.NoError(t, err)
+		require.Len(t, reviewersId, 1)
+		picked[reviewersId[0]]++
+	}
+	assert.Zero(t, picked["u1"], "weight 0 excludes the user")
+	assert.Greater(t, picked["u2"], picked["u3"])
+
+	//Users with weight 0 are not taken even when the count is not filled
+	reviewersId, err := selector.Select(ctx, 1, candidates, 3)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"u2", "u3"}, reviewersId)
+}
+
+// Candidates outside the home team go through the same strategies, without the author,
+// the current reviewers, inactive and absent users
+func TestPullRequestService_SelectFallbackReviewers(t *testing.T) {
+	ctx := context.Background()
+
+	fallback := users("author", "reviewer", "c1", "c2", "absent")
+	fallback = append(fallback, models.User{UserId: "inactive", Username: "inactive", IsActive: false})
+
+	for _, strategy := range []string{StrategyRandom, StrategyLeastLoaded, StrategyRoundRobin, StrategyWeighted} {
+		t.Run(strategy, func(t *testing.T) {
+			userRepo := &stubUserRepo{
+				byTeam: map[int][]models.User{2: fallback},
+				absent: []string{"absent"},
+			}
+			teamRepo := &stubTeamRepo{
+				fallbacks: map[int][]models.Team{1: {{Id: 2, Name: "fallback"}}},
+			}
+			selectors, err := NewReviewerSelectors(config.ReviewConfig{Strategy: strategy}, userRepo)
+			require.NoError(t, err)
+
+			s := &PullRequestService{userRepo: userRepo, teamRepo: teamRepo, selectors: selectors}
+			home := &models.Team{Id: 1, Name: "home"}
+
+			reviewersId, err := s.selectFallbackReviewers(ctx, home, []string{"author", "reviewer"}, 5)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{"c1", "c2"}, reviewersId)
+
+			reviewersId, err = s.selectFallbackReviewers(ctx, home, []string{"author", "reviewer"}, 1)
+			require.NoError(t, err)
+			require.Len(t, reviewersId, 1)
+			assert.Subset(t, []string{"c1", "c2"}, reviewersId)
+		})
+	}
+}
+
+func TestLeastLoadedSelector(t *testing.T) {
+	ctx := context.Background()
+	candidates := users("u3", "u1", "u2", "u4")
+
+	tests := []struct {
+		name    string
+		load    map[string]int
+		maxOpen int
+		userMax map[string]int
+		count   int
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "fewest open reviews first",
+			load:  map[string]int{"u1": 3, "u2": 0, "u3": 1, "u4": 2},
+			count: 2,
+			want:  []string{"u2", "u3"},
+		},
+		{
+			name:  "ties are broken by user_id",
+			load:  map[string]int{"u1": 1, "u2": 1, "u3": 0, "u4": 1},
+			count: 3,
+			want:  []string{"u3", "u1", "u2"},
+		},
+		{
+			name:  "count above the candidates",
+			load:  map[string]int{},
+			count: 10,
+			want:  []string{"u1", "u2", "u3", "u4"},
+		},
+		{
+			name:    "users at the global cap are skipped",
+			load:    map[string]int{"u1": 2, "u2": 1, "u3": 2, "u4": 0},
+			maxOpen: 2,
+			count:   3,
+			want:    []string{"u4", "u2"},
+		},
+		{
+			name:    "user cap overrides the global one",
+			load:    map[string]int{"u1": 1, "u2": 3, "u3": 3, "u4": 3},
+			maxOpen: 3,
+			userMax: map[string]int{"u1": 1, "u2": 5},
+			count:   2,
+			want:    []string{"u2"},
+		},
+		{
+			name:    "user cap 0 takes no reviews",
+			load:    map[string]int{},
+			userMax: map[string]int{"u1": 0, "u2": 0},
+			count:   4,
+			want:    []string{"u3", "u4"},
+		},
+		{
+			name:    "negative user cap removes the global cap",
+			load:    map[string]int{"u1": 10, "u2": 2, "u3": 2, "u4": 2},
+			maxOpen: 2,
+			userMax: map[string]int{"u1": -1},
+			count:   2,
+			want:    []string{"u1"},
+		},
+		{
+			name:    "everyone at the cap",
+			load:    map[string]int{"u1": 1, "u2": 1, "u3": 1, "u4": 1},
+			maxOpen: 1,
+			count:   2,
+			wantErr: ErrNoCandidate,
+		},
+		{
+			name:    "everyone takes no reviews",
+			load:    map[string]int{},
+			userMax: map[string]int{"u1": 0, "u2": 0, "u3": 0, "u4": 0},
+			count:   1,
+			wantErr: ErrNoCandidate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := NewReviewerSelector(StrategyLeastLoaded, config.ReviewConfig{
+				MaxOpenReviews:     tt.maxOpen,
+				UserMaxOpenReviews: tt.userMax,
+			}, &stubUserRepo{load: tt.load})
+			require.NoError(t, err)
+
+			reviewersId, err := selector.Select(ctx, 1, candidates, tt.count)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, reviewersId)
+		})
+	}
+
+	//No candidates is not an error, the PR is created without reviewers
+	selector := &LeastLoadedSelector{userRepo: &stubUserRepo{}, maxOpen: 1}
+	reviewersId, err := selector.Select(ctx, 1, nil, 2)
+	require.NoError(t, err)
+	assert.Empty(t, reviewersId)
+}
+
+func TestLeastLoadedSelector_ReviewLoad(t *testing.T) {
+	userRepo := &stubUserRepo{load: map[string]int{"u1": 1, "u2": 2, "u3": 2}}
+	selector := &LeastLoadedSelector{userRepo: userRepo, maxOpen: 3}
+	ctx, load := withReviewLoad(context.Background())
+
+	_, err := load.fetch(ctx, userRepo, []string{"u1", "u2"})
+	require.NoError(t, err)
+
+	selected, err := selector.Select(ctx, 1, users("u1", "u2"), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u1"}, selected)
+
+	//The batch moves two reviews to u1, so u2 is less loaded now and u1 reaches the cap
+	load.replaced("ghost", "u1")
+	load.replaced("ghost", "u1")
+	selected, err = selector.Select(ctx, 1, users("u1", "u2", "u3"), 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u3"}, selected)
+
+	//Only the users not counted before are queried
+	assert.Equal(t, [][]string{{"u1", "u2"}, {"u3"}}, userRepo.counted)
+}
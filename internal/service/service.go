@@ -4,24 +4,80 @@ import (
 	"context"
 
 	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
 )
 
+// ProgressFunc reports how many of the total items are processed.
+type ProgressFunc func(processed int, total int)
+
 type IUserService interface {
-	SetIsActive(ctx context.Context, req *dto.SetIsActiveRequest) (*dto.UserResponse, error)
+	SetIsActive(ctx context.Context, req *dto.SetIsActiveRequest) (*dto.SetIsActiveResponse, error)
 	GetReview(ctx context.Context, userId string) ([]dto.ReviewResponse, error)
 	GetStatsReview(ctx context.Context) ([]dto.UserStatsReviewResponse, error)
+	GetReviewStats(ctx context.Context, req *dto.UserStatsRequest) ([]dto.UserStats, error)
 	MassDeactivation(ctx context.Context, req *dto.MassDeactivationRequest) (*dto.MassDeactivationResponse, error)
+	Move(ctx context.Context, req *dto.UserMoveRequest) (*dto.UserTeamChangeResponse, error)
+	AddAbsence(ctx context.Context, req *dto.AbsenceRequest) (*dto.Absence, error)
+	GetAbsences(ctx context.Context, userId string) ([]dto.Absence, error)
+	DeleteAbsence(ctx context.Context, req *dto.AbsenceDeleteRequest) error
 }
 
 type ITeamService interface {
 	Add(ctx context.Context, team *dto.Team) (int, error)
 	Get(ctx context.Context, teamName string) (*dto.Team, error)
-	GetStatsPR(ctx context.Context, teamName string) (*dto.TeamStatsPrResponse, error)
+	GetStatsPR(ctx context.Context, req *dto.TeamStatsPrRequest) (*dto.TeamStatsPrResponse, error)
+	UpdateSettings(ctx context.Context, req *dto.TeamSettingsRequest) (*dto.TeamSettingsResponse, error)
+	AddMembers(ctx context.Context, req *dto.TeamMembersRequest) (*dto.Team, error)
+	RemoveMember(ctx context.Context, req *dto.TeamMemberRemoveRequest) (*dto.UserTeamChangeResponse, error)
+	Rename(ctx context.Context, req *dto.TeamRenameRequest) (*dto.TeamSettingsResponse, error)
+	Delete(ctx context.Context, req *dto.TeamDeleteRequest) (*dto.TeamDeleteResponse, error)
+	SetFallbacks(ctx context.Context, req *dto.TeamFallbacksRequest) (*dto.TeamFallbacksResponse, error)
+	GetFallbacks(ctx context.Context, teamName string) (*dto.TeamFallbacksResponse, error)
+	SetCodeOwners(ctx context.Context, req *dto.CodeOwnersRequest) (*dto.CodeOwnersResponse, error)
+	GetCodeOwners(ctx context.Context, teamName string) (*dto.CodeOwnersResponse, error)
+	SlaReport(ctx context.Context, req *dto.SlaReportRequest) ([]dto.TeamSlaReport, error)
 }
 
 type IPullRequestService interface {
 	Create(ctx context.Context, pr *dto.PrCreateRequest) (*dto.PullRequest, error)
-	Merge(ctx context.Context, prId string) (*dto.MergeResponse, error)
+	Merge(ctx context.Context, req *dto.MergeRequest) (*dto.MergeResponse, error)
+	SubmitReview(ctx context.Context, req *dto.SubmitReviewRequest) (*dto.SubmitReviewResponse, error)
+	Ready(ctx context.Context, prId string) (*dto.PullRequest, error)
+	Close(ctx context.Context, prId string) (*dto.PullRequest, error)
+	Reopen(ctx context.Context, prId string) (*dto.PullRequest, error)
+	Get(ctx context.Context, prId string) (*dto.PullRequestDetails, error)
+	History(ctx context.Context, prId string) (*dto.PrHistoryResponse, error)
+	List(ctx context.Context, req *dto.PrListRequest) (*dto.PrListResponse, error)
 	Reassign(ctx context.Context, req *dto.ReassignRequest) (*dto.ReassignResponse, error)
-	ReassignAllInactiveReviewersByTeam(ctx context.Context, teamName string) ([]dto.MassReassignResponse, error)
+	ReassignAllInactiveReviewersByTeam(ctx context.Context, teamName string, progress ProgressFunc) ([]dto.MassReassignResponse, error)
+	ReassignOpenReviewsOfUsers(ctx context.Context, usersId []string, reason string) ([]dto.MassReassignResponse, error)
+	ReassignOpenReviewsInTeam(ctx context.Context, usersId []string, teamId int, reason string) ([]dto.MassReassignResponse, error)
+}
+
+type IJobService interface {
+	EnqueueReassignTeam(ctx context.Context, teamName string) (string, error)
+	HandleReassignTeam(ctx context.Context, job *models.Job) (any, error)
+	Get(ctx context.Context, jobId string) (*dto.TaskResponse, error)
+}
+
+type IWebhookService interface {
+	Create(ctx context.Context, req *dto.WebhookRequest) (*dto.Webhook, error)
+	Get(ctx context.Context, webhookId int) (*dto.Webhook, error)
+	List(ctx context.Context) ([]dto.Webhook, error)
+	Update(ctx context.Context, webhookId int, req *dto.WebhookRequest) (*dto.Webhook, error)
+	Delete(ctx context.Context, webhookId int) error
+	GetDeliveries(ctx context.Context, webhookId int, req *dto.WebhookDeliveriesRequest) ([]dto.WebhookDelivery, error)
+	HandleDelivery(ctx context.Context, job *models.Job) (any, error)
+}
+
+type IIntegrationService interface {
+	Receive(ctx context.Context, delivery *dto.HostingDelivery) (*dto.HostingDeliveryResponse, error)
+	SetIdentity(ctx context.Context, req *dto.IdentityRequest) (*dto.Identity, error)
+	ListIdentities(ctx context.Context, req *dto.IdentityListRequest) ([]dto.Identity, error)
+	DeleteIdentity(ctx context.Context, req *dto.IdentityDeleteRequest) error
+}
+
+type IHealthService interface {
+	Ready(ctx context.Context) *dto.ReadinessResponse
+	Drain()
 }
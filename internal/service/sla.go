@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/tracing"
+)
+
+// Breaches handled by one sweep, the rest are left to the next ones
+const slaSweepBatch int = 100
+
+// SweepSla marks the reviewers who have made no decision within the review SLA of the author's team,
+// it is run by the scheduler. Every breach is recorded in the PR history and sent to the webhooks once.
+// Teams with sla_auto_reassign get the reviewer replaced, a breach without candidates stays as is.
+// Each breach is committed on its own, a failing one is logged and retried by the next sweep.
+func (s *PullRequestService) SweepSla(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "PullRequestService.SweepSla")
+	defer span.End()
+
+	breaches, err := s.prRepo.GetDueSlaBreaches(ctx, slaSweepBatch)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "PullRequestService.SweepSla:prRepo.GetDueSlaBreaches - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+
+	for _, breach := range breaches {
+		err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+			return s.markSlaBreach(ctx, &breach)
+		})
+		if err != nil {
+			s.logger.WarnContext(ctx, "PullRequestService.SweepSla - Breach is not handled",
+				slog.String("pr_id", breach.PrId),
+				slog.String("reviewer_id", breach.ReviewerId),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+	return nil
+}
+
+func (s *PullRequestService) markSlaBreach(ctx context.Context, breach *models.SlaBreach) error {
+	err := s.prRepo.MarkSlaBreached(ctx, breach)
+	if err != nil {
+		//Marked by another sweep or decided in the meantime
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		s.logger.ErrorContext(ctx, "PullRequestService.SweepSla:prRepo.MarkSlaBreached - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+
+	err = s.recordEvents(ctx, models.PrEvent{
+		PrId:       breach.PrId,
+		Type:       models.EventSlaBreached,
+		ReviewerId: breach.ReviewerId,
+	})
+	if err != nil {
+		return err
+	}
+	err = s.outbox.Publish(ctx, models.WebhookPrReviewSlaBreached, reviewSlaBreachedEvent{
+		PrId:       breach.PrId,
+		ReviewerId: breach.ReviewerId,
+		AssignedAt: breach.AssignedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !breach.AutoReassign {
+		return nil
+	}
+	_, err = s.reassign(ctx, &dto.ReassignRequest{PrId: breach.PrId, OldReviewerId: breach.ReviewerId}, models.ReasonSla)
+	if err != nil && !errors.Is(err, ErrNoCandidate) {
+		return err
+	}
+	return nil
+}
+
+// SlaReport returns the breach counters of the teams within the window together with the breaches still open.
+func (s *TeamService) SlaReport(ctx context.Context, req *dto.SlaReportRequest) ([]dto.TeamSlaReport, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.SlaReport")
+	defer span.End()
+
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return nil, ErrInvalidPeriod
+	}
+
+	var teamId int
+	if req.TeamName != "" {
+		var err error
+		teamId, err = s.teamRepo.GetIdByName(ctx, req.TeamName)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.SlaReport:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+			return nil, ErrInternal
+		}
+	}
+
+	//Members see the report only of their own team, without a team name it is the default
+	callerTeam, restricted, err := callerTeamId(ctx, s.userRepo)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "TeamService.SlaReport:callerTeamId - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	if restricted {
+		if callerTeam == 0 || (teamId != 0 && teamId != callerTeam) {
+			return nil, ErrForbidden
+		}
+		teamId = callerTeam
+	}
+
+	stats, err := s.teamRepo.GetSlaStats(ctx, teamId, req.From, req.To)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "TeamService.SlaReport:teamRepo.GetSlaStats - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	breaches, err := s.prRepo.GetOpenSlaBreaches(ctx, teamId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "TeamService.SlaReport:prRepo.GetOpenSlaBreaches - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	openBreaches := make(map[int][]dto.SlaBreach)
+	for _, breach := range breaches {
+		openBreaches[breach.TeamId] = append(openBreaches[breach.TeamId], dto.SlaBreach{
+			PrId:       breach.PrId,
+			ReviewerId: breach.ReviewerId,
+			AssignedAt: breach.AssignedAt,
+			BreachedAt: *breach.BreachedAt,
+		})
+	}
+
+	resp := make([]dto.TeamSlaReport, 0, len(stats))
+	for _, stat := range stats {
+		report := dto.TeamSlaReport{
+			TeamName:       stat.Name,
+			ReviewSlaHours: stat.ReviewSlaHours,
+			Breaches:       stat.Breaches,
+			Reassigned:     stat.Reassigned,
+			OpenBreaches:   openBreaches[stat.TeamId],
+		}
+		if report.OpenBreaches == nil {
+			report.OpenBreaches = []dto.SlaBreach{}
+		}
+		resp = append(resp, report)
+	}
+	return resp, nil
+}
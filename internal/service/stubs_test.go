@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+)
+
+// Stubs implement only the methods used by a test, a call of any other method panics
+
+type stubUserRepo struct {
+	repository.IUserRepo
+	byTeam map[int][]models.User
+	load   map[string]int
+	absent []string
+	//Users of every CountOpenReviews call
+	counted [][]string
+}
+
+func (r *stubUserRepo) GetById(ctx context.Context, userId string) (*models.User, error) {
+	for teamId, users := range r.byTeam {
+		for _, user := range users {
+			if user.UserId == userId {
+				user.TeamId = teamId
+				return &user, nil
+			}
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *stubUserRepo) GetAllByTeam(ctx context.Context, teamId int) ([]models.User, error) {
+	return r.byTeam[teamId], nil
+}
+
+func (r *stubUserRepo) CountOpenReviews(ctx context.Context, usersId []string) (map[string]int, error) {
+	r.counted = append(r.counted, usersId)
+	load := make(map[string]int, len(usersId))
+	for _, userId := range usersId {
+		load[userId] = r.load[userId]
+	}
+	return load, nil
+}
+
+func (r *stubUserRepo) GetAbsentIds(ctx context.Context, usersId []string) ([]string, error) {
+	return r.absent, nil
+}
+
+type stubTeamRepo struct {
+	repository.ITeamRepo
+	fallbacks map[int][]models.Team
+}
+
+func (r *stubTeamRepo) GetFallbacks(ctx context.Context, teamId int) ([]models.Team, error) {
+	return r.fallbacks[teamId], nil
+}
+
+func users(usersId ...string) []models.User {
+	result := make([]models.User, 0, len(usersId))
+	for _, userId := range usersId {
+		result = append(result, models.User{UserId: userId, Username: userId, IsActive: true})
+	}
+	return result
+}
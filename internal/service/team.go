@@ -3,41 +3,95 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 
 	"github.com/Estriper0/avito_intership/internal/handlers/dto"
 	"github.com/Estriper0/avito_intership/internal/models"
 	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/tracing"
 	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
 )
 
+const (
+	defaultMinReviewers      int = 1
+	defaultMaxReviewers      int = 2
+	defaultRequiredApprovals int = 1
+)
+
 type TeamService struct {
 	teamRepo  repository.ITeamRepo
 	userRepo  repository.IUserRepo
+	prRepo    repository.IPullRequestRepo
+	prService IPullRequestService
+	outbox    *Outbox
 	trManager *manager.Manager
 	logger    *slog.Logger
 }
 
-func NewTeamService(teamRepo repository.ITeamRepo, userRepo repository.IUserRepo, trManager *manager.Manager, logger *slog.Logger) *TeamService {
+func NewTeamService(teamRepo repository.ITeamRepo, userRepo repository.IUserRepo, prRepo repository.IPullRequestRepo, prService IPullRequestService, outbox *Outbox, trManager *manager.Manager, logger *slog.Logger) *TeamService {
 	return &TeamService{
 		teamRepo:  teamRepo,
 		userRepo:  userRepo,
+		prRepo:    prRepo,
+		prService: prService,
+		outbox:    outbox,
 		trManager: trManager,
 		logger:    logger,
 	}
 }
 
 func (s *TeamService) Add(ctx context.Context, team *dto.Team) (int, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.Add")
+	defer span.End()
+
+	//Settings which are not passed get default values
+	minReviewers, maxReviewers := defaultMinReviewers, defaultMaxReviewers
+	if team.MinReviewers != nil {
+		minReviewers = *team.MinReviewers
+	}
+	if team.MaxReviewers != nil {
+		maxReviewers = *team.MaxReviewers
+	}
+	if minReviewers > maxReviewers {
+		return 0, ErrInvalidReviewersSettings
+	}
+	requiredApprovals := defaultRequiredApprovals
+	if team.RequiredApprovals != nil {
+		requiredApprovals = *team.RequiredApprovals
+	}
+	team.MinReviewers = &minReviewers
+	team.MaxReviewers = &maxReviewers
+	team.RequiredApprovals = &requiredApprovals
+
 	var id int
 	//Do everything in a transaction
-	err := s.trManager.Do(ctx, func(ctx context.Context) error {
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		//Members are only taken from no team, moving from another team is /users/move with its access checks
+		for _, user := range team.Members {
+			existing, err := s.userRepo.GetById(ctx, user.UserId)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				s.logger.ErrorContext(ctx, "TeamService.Add:userRepo.GetById - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+			if err == nil && existing.TeamId != 0 {
+				return ErrUserInOtherTeam
+			}
+		}
+
 		//Add a team to the table teams
-		teamId, err := s.teamRepo.Create(ctx, &models.Team{Name: team.TeamName})
+		teamId, err := s.teamRepo.Create(ctx, &models.Team{
+			Name:              team.TeamName,
+			MinReviewers:      minReviewers,
+			MaxReviewers:      maxReviewers,
+			RequiredApprovals: requiredApprovals,
+		})
 		if err != nil {
 			if errors.Is(err, repository.ErrAlreadyExists) {
 				return ErrTeamAlreadyExists
 			}
-			s.logger.Error("TeamService.Add:teamRepo.Create - Internal error", slog.String("error", err.Error()))
+			s.logger.ErrorContext(ctx, "TeamService.Add:teamRepo.Create - Internal error", slog.String("error", err.Error()))
 			return ErrInternal
 		}
 		id = teamId
@@ -51,31 +105,34 @@ func (s *TeamService) Add(ctx context.Context, team *dto.Team) (int, error) {
 				IsActive: user.IsActive,
 			})
 			if err != nil {
-				s.logger.Error("TeamService.Add:userRepo.Create - Internal error", slog.String("error", err.Error()))
+				s.logger.ErrorContext(ctx, "TeamService.Add:userRepo.Create - Internal error", slog.String("error", err.Error()))
 				return ErrInternal
 			}
 		}
 
-		return nil
+		return s.outbox.Publish(ctx, models.WebhookTeamCreated, team)
 	})
 
 	return id, err
 }
 
 func (s *TeamService) Get(ctx context.Context, teamName string) (*dto.Team, error) {
-	teamId, err := s.teamRepo.GetIdByName(ctx, teamName)
+	ctx, span := tracing.Start(ctx, "TeamService.Get")
+	defer span.End()
+
+	team, err := s.teamRepo.GetByName(ctx, teamName)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
 		}
-		s.logger.Error("TeamService.Get:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "TeamService.Get:teamRepo.GetByName - Internal error", slog.String("error", err.Error()))
 		return nil, ErrInternal
 	}
 
 	//Getting all team members
-	users, err := s.userRepo.GetAllByTeam(ctx, teamId)
+	users, err := s.userRepo.GetAllByTeam(ctx, team.Id)
 	if err != nil {
-		s.logger.Error("TeamService.Get:userRepo.GetAllByTeam - Internal error", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "TeamService.Get:userRepo.GetAllByTeam - Internal error", slog.String("error", err.Error()))
 		return nil, ErrInternal
 	}
 
@@ -89,25 +146,652 @@ func (s *TeamService) Get(ctx context.Context, teamName string) (*dto.Team, erro
 	}
 
 	return &dto.Team{
-		TeamName: teamName,
-		Members:  members,
+		TeamName:          teamName,
+		Members:           members,
+		MinReviewers:      &team.MinReviewers,
+		MaxReviewers:      &team.MaxReviewers,
+		RequiredApprovals: &team.RequiredApprovals,
 	}, err
 }
 
-func (s *TeamService) GetStatsPR(ctx context.Context, teamName string) (*dto.TeamStatsPrResponse, error) {
-	team, err := s.teamRepo.GetStatsPRByName(ctx, teamName)
+// GetStatsPR returns the PR counters of the team within the window together with the lead times,
+// the weekly throughput and the review load of the members. Everything is aggregated by the database.
+func (s *TeamService) GetStatsPR(ctx context.Context, req *dto.TeamStatsPrRequest) (*dto.TeamStatsPrResponse, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetStatsPR")
+	defer span.End()
+
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return nil, ErrInvalidPeriod
+	}
+
+	teamId, err := s.teamRepo.GetIdByName(ctx, req.TeamName)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
 		}
-		s.logger.Error("TeamService.GetStatsPR:teamRepo.GetStatsPR - Internal error", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "TeamService.GetStatsPR:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	//Members see the statistics only of their own team
+	ok, err := callerInTeam(ctx, s.userRepo, teamId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "TeamService.GetStatsPR:callerInTeam - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+
+	team, err := s.teamRepo.GetStatsPRByName(ctx, req.TeamName, req.From, req.To)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "TeamService.GetStatsPR:teamRepo.GetStatsPR - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	times, err := s.teamRepo.GetLeadTimes(ctx, teamId, req.From, req.To)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "TeamService.GetStatsPR:teamRepo.GetLeadTimes - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	weeks, err := s.teamRepo.GetMergedPerWeek(ctx, teamId, req.From, req.To)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "TeamService.GetStatsPR:teamRepo.GetMergedPerWeek - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	load, err := s.teamRepo.GetReviewLoad(ctx, teamId, req.From, req.To)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "TeamService.GetStatsPR:teamRepo.GetReviewLoad - Internal error", slog.String("error", err.Error()))
 		return nil, ErrInternal
 	}
-	
-	return &dto.TeamStatsPrResponse{
-		Name:     team.Name,
-		TotalPr:  team.TotalPr,
-		OpenPr:   team.OpenPr,
-		MergedPr: team.MergedPr,
+
+	resp := &dto.TeamStatsPrResponse{
+		Name:            team.Name,
+		From:            req.From,
+		To:              req.To,
+		TotalPr:         team.TotalPr,
+		OpenPr:          team.OpenPr,
+		MergedPr:        team.MergedPr,
+		DraftPr:         team.DraftPr,
+		ClosedPr:        team.ClosedPr,
+		UnderReviewedPr: team.UnderReviewedPr,
+		TimeToMerge: dto.DurationStats{
+			Count:         times.MergedCount,
+			MedianSeconds: roundSeconds(times.MergeMedian),
+			P90Seconds:    roundSeconds(times.MergeP90),
+		},
+		TimeToFirstReview: dto.DurationStats{
+			Count:         times.ReviewedCount,
+			MedianSeconds: roundSeconds(times.FirstReviewMedian),
+			P90Seconds:    roundSeconds(times.FirstReviewP90),
+		},
+		MergedPerWeek: make([]dto.WeeklyCount, 0, len(weeks)),
+		ReviewLoad:    make([]dto.MemberReviews, 0, len(load)),
+	}
+	for _, week := range weeks {
+		resp.MergedPerWeek = append(resp.MergedPerWeek, dto.WeeklyCount{Week: week.Week, Merged: week.Count})
+	}
+	for _, member := range load {
+		resp.ReviewLoad = append(resp.ReviewLoad, dto.MemberReviews{
+			UserId:      member.UserId,
+			Username:    member.Username,
+			Reviews:     member.Reviews,
+			OpenReviews: member.OpenReviews,
+		})
+	}
+	return resp, nil
+}
+
+// Percentiles are interpolated, whole seconds are enough for the statistics
+func roundSeconds(seconds *float64) *int64 {
+	if seconds == nil {
+		return nil
+	}
+	rounded := int64(math.Round(*seconds))
+	return &rounded
+}
+
+func (s *TeamService) UpdateSettings(ctx context.Context, req *dto.TeamSettingsRequest) (*dto.TeamSettingsResponse, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.UpdateSettings")
+	defer span.End()
+
+	if *req.MinReviewers > *req.MaxReviewers {
+		return nil, ErrInvalidReviewersSettings
+	}
+
+	var resp *dto.TeamSettingsResponse
+	//Do everything in a transaction
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		current, err := s.teamRepo.GetByName(ctx, req.TeamName)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.UpdateSettings:teamRepo.GetByName - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Team leads change only their own team
+		ok, err := callerLeadsTeam(ctx, s.userRepo, current.Id)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.UpdateSettings:callerLeadsTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		if !ok {
+			return ErrForbidden
+		}
+
+		requiredApprovals := current.RequiredApprovals
+		if req.RequiredApprovals != nil {
+			requiredApprovals = *req.RequiredApprovals
+		}
+		reviewSlaHours := current.ReviewSlaHours
+		if req.ReviewSlaHours != nil {
+			reviewSlaHours = *req.ReviewSlaHours
+		}
+		slaAutoReassign := current.SlaAutoReassign
+		if req.SlaAutoReassign != nil {
+			slaAutoReassign = *req.SlaAutoReassign
+		}
+
+		team, err := s.teamRepo.UpdateSettings(ctx, &models.Team{
+			Name:              req.TeamName,
+			MinReviewers:      *req.MinReviewers,
+			MaxReviewers:      *req.MaxReviewers,
+			RequiredApprovals: requiredApprovals,
+			ReviewSlaHours:    reviewSlaHours,
+			SlaAutoReassign:   slaAutoReassign,
+		})
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.UpdateSettings:teamRepo.UpdateSettings - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		resp = &dto.TeamSettingsResponse{
+			TeamName:          team.Name,
+			MinReviewers:      team.MinReviewers,
+			MaxReviewers:      team.MaxReviewers,
+			RequiredApprovals: team.RequiredApprovals,
+			ReviewSlaHours:    team.ReviewSlaHours,
+			SlaAutoReassign:   team.SlaAutoReassign,
+		}
+		return nil
+	})
+
+	return resp, err
+}
+
+// AddMembers adds users to an existing team. Unlike Add it does not move users
+// from other teams, this is done explicitly with UserService.Move.
+func (s *TeamService) AddMembers(ctx context.Context, req *dto.TeamMembersRequest) (*dto.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.AddMembers")
+	defer span.End()
+
+	var resp *dto.Team
+	//Do everything in a transaction
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		team, err := s.teamRepo.GetByName(ctx, req.TeamName)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.AddMembers:teamRepo.GetByName - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Team leads change only their own team
+		ok, err := callerLeadsTeam(ctx, s.userRepo, team.Id)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.AddMembers:callerLeadsTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		if !ok {
+			return ErrForbidden
+		}
+
+		for _, member := range req.Members {
+			user, err := s.userRepo.GetById(ctx, member.UserId)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				s.logger.ErrorContext(ctx, "TeamService.AddMembers:userRepo.GetById - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+			//Users without a team (TeamId 0) may join
+			if err == nil && user.TeamId != 0 && user.TeamId != team.Id {
+				return ErrUserInOtherTeam
+			}
+
+			_, err = s.userRepo.CreateOrUpdate(ctx, &models.User{
+				UserId:   member.UserId,
+				Username: member.Username,
+				TeamId:   team.Id,
+				IsActive: member.IsActive,
+			})
+			if err != nil {
+				s.logger.ErrorContext(ctx, "TeamService.AddMembers:userRepo.CreateOrUpdate - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+		}
+
+		resp, err = s.Get(ctx, team.Name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// RemoveMember leaves the user without a team, the user keeps the history of PRs and reviews.
+// Authors of open or draft PRs can't be removed, since the team of a PR is the team of its author.
+func (s *TeamService) RemoveMember(ctx context.Context, req *dto.TeamMemberRemoveRequest) (*dto.UserTeamChangeResponse, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.RemoveMember")
+	defer span.End()
+
+	var resp *dto.UserTeamChangeResponse
+	//Do everything in a transaction
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		teamId, err := s.teamRepo.GetIdByName(ctx, req.TeamName)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.RemoveMember:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Team leads change only their own team
+		ok, err := callerLeadsTeam(ctx, s.userRepo, teamId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.RemoveMember:callerLeadsTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		if !ok {
+			return ErrForbidden
+		}
+
+		current, err := s.userRepo.GetById(ctx, req.UserId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.RemoveMember:userRepo.GetById - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		if current.TeamId != teamId {
+			return ErrNotTeamMember
+		}
+
+		unfinished, err := s.prRepo.CountUnfinishedByAuthors(ctx, []string{req.UserId})
+		if err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.RemoveMember:prRepo.CountUnfinishedByAuthors - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		if unfinished > 0 {
+			return ErrOpenPullRequests
+		}
+
+		user, err := s.userRepo.UpdateTeam(ctx, req.UserId, 0)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.RemoveMember:userRepo.UpdateTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		resp = &dto.UserTeamChangeResponse{
+			User: &dto.UserResponse{
+				UserId:   user.UserId,
+				Username: user.Username,
+				IsActive: user.IsActive,
+			},
+		}
+
+		//The user is out of the team, so the reviews of its PRs go to the remaining members, other teams' reviews are kept
+		if req.Reassign {
+			resp.Reassignments, err = s.prService.ReassignOpenReviewsInTeam(ctx, []string{user.UserId}, teamId, models.ReasonTeamChange)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (s *TeamService) Rename(ctx context.Context, req *dto.TeamRenameRequest) (*dto.TeamSettingsResponse, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.Rename")
+	defer span.End()
+
+	teamId, err := s.teamRepo.GetIdByName(ctx, req.TeamName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "TeamService.Rename:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	//Team leads rename only their own team
+	ok, err := callerLeadsTeam(ctx, s.userRepo, teamId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "TeamService.Rename:callerLeadsTeam - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+
+	team, err := s.teamRepo.Rename(ctx, req.TeamName, req.NewTeamName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		} else if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrTeamAlreadyExists
+		}
+		s.logger.ErrorContext(ctx, "TeamService.Rename:teamRepo.Rename - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	return &dto.TeamSettingsResponse{
+		TeamName:          team.Name,
+		MinReviewers:      team.MinReviewers,
+		MaxReviewers:      team.MaxReviewers,
+		RequiredApprovals: team.RequiredApprovals,
+		ReviewSlaHours:    team.ReviewSlaHours,
+		SlaAutoReassign:   team.SlaAutoReassign,
 	}, nil
 }
+
+// Delete removes the team and leaves its members without a team.
+// The team must not have open or draft PRs and its members must not have open reviews.
+func (s *TeamService) Delete(ctx context.Context, req *dto.TeamDeleteRequest) (*dto.TeamDeleteResponse, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.Delete")
+	defer span.End()
+
+	var resp *dto.TeamDeleteResponse
+	//Do everything in a transaction
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		teamId, err := s.teamRepo.GetIdByName(ctx, req.TeamName)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.Delete:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		members, err := s.userRepo.GetAllByTeam(ctx, teamId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.Delete:userRepo.GetAllByTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		usersId := make([]string, 0, len(members))
+		for _, member := range members {
+			usersId = append(usersId, member.UserId)
+		}
+
+		unfinished, err := s.prRepo.CountUnfinishedByAuthors(ctx, usersId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.Delete:prRepo.CountUnfinishedByAuthors - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		//Members may still review PRs of authors who moved to other teams
+		reviews, err := s.userRepo.CountOpenReviews(ctx, usersId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.Delete:userRepo.CountOpenReviews - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		for _, count := range reviews {
+			unfinished += count
+		}
+		if unfinished > 0 {
+			return ErrOpenPullRequests
+		}
+
+		if err := s.userRepo.ClearTeam(ctx, teamId); err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.Delete:userRepo.ClearTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		if err := s.teamRepo.Delete(ctx, teamId); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.Delete:teamRepo.Delete - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		resp = &dto.TeamDeleteResponse{
+			TeamName: req.TeamName,
+			UsersId:  usersId,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SetFallbacks replaces the teams whose members review PRs of the team
+// when it cannot fill min_reviewers by itself.
+func (s *TeamService) SetFallbacks(ctx context.Context, req *dto.TeamFallbacksRequest) (*dto.TeamFallbacksResponse, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.SetFallbacks")
+	defer span.End()
+
+	var resp *dto.TeamFallbacksResponse
+	//Do everything in a transaction
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		teamId, err := s.teamRepo.GetIdByName(ctx, req.TeamName)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.SetFallbacks:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Team leads change only their own team
+		ok, err := callerLeadsTeam(ctx, s.userRepo, teamId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.SetFallbacks:callerLeadsTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		if !ok {
+			return ErrForbidden
+		}
+
+		fallbacksId := make([]int, 0, len(req.FallbackTeams))
+		for _, fallbackName := range req.FallbackTeams {
+			if fallbackName == req.TeamName {
+				return ErrInvalidFallbacks
+			}
+			fallbackId, err := s.teamRepo.GetIdByName(ctx, fallbackName)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return ErrNotFound
+				}
+				s.logger.ErrorContext(ctx, "TeamService.SetFallbacks:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+			fallbacksId = append(fallbacksId, fallbackId)
+		}
+
+		err = s.teamRepo.SetFallbacks(ctx, teamId, fallbacksId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.SetFallbacks:teamRepo.SetFallbacks - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		resp = &dto.TeamFallbacksResponse{
+			TeamName:      req.TeamName,
+			FallbackTeams: append([]string{}, req.FallbackTeams...),
+		}
+		return nil
+	})
+
+	return resp, err
+}
+
+func (s *TeamService) GetFallbacks(ctx context.Context, teamName string) (*dto.TeamFallbacksResponse, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetFallbacks")
+	defer span.End()
+
+	teamId, err := s.teamRepo.GetIdByName(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "TeamService.GetFallbacks:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	fallbacks, err := s.teamRepo.GetFallbacks(ctx, teamId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "TeamService.GetFallbacks:teamRepo.GetFallbacks - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	resp := &dto.TeamFallbacksResponse{
+		TeamName:      teamName,
+		FallbackTeams: make([]string, 0, len(fallbacks)),
+	}
+	for _, fallback := range fallbacks {
+		resp.FallbackTeams = append(resp.FallbackTeams, fallback.Name)
+	}
+	return resp, nil
+}
+
+// SetCodeOwners replaces the code owner rules of the team. Owners are users or whole teams,
+// members of any team may own files of the team.
+func (s *TeamService) SetCodeOwners(ctx context.Context, req *dto.CodeOwnersRequest) (*dto.CodeOwnersResponse, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.SetCodeOwners")
+	defer span.End()
+
+	var resp *dto.CodeOwnersResponse
+	//Do everything in a transaction
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		teamId, err := s.teamRepo.GetIdByName(ctx, req.TeamName)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "TeamService.SetCodeOwners:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Team leads change only their own team
+		ok, err := callerLeadsTeam(ctx, s.userRepo, teamId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.SetCodeOwners:callerLeadsTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		if !ok {
+			return ErrForbidden
+		}
+
+		rules := make([]models.CodeOwnerRule, 0, len(req.Rules))
+		for _, r := range req.Rules {
+			if len(r.Users) == 0 && len(r.Teams) == 0 {
+				return fmt.Errorf("%w: %s has no owners", ErrInvalidCodeOwners, r.Pattern)
+			}
+			if _, err := compileOwnerPattern(r.Pattern); err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidCodeOwners, r.Pattern)
+			}
+
+			rule := models.CodeOwnerRule{
+				Pattern: r.Pattern,
+				UsersId: append([]string{}, r.Users...),
+				TeamsId: make([]int, 0, len(r.Teams)),
+			}
+			for _, teamName := range r.Teams {
+				ownerTeamId, err := s.teamRepo.GetIdByName(ctx, teamName)
+				if err != nil {
+					if errors.Is(err, repository.ErrNotFound) {
+						return ErrNotFound
+					}
+					s.logger.ErrorContext(ctx, "TeamService.SetCodeOwners:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+					return ErrInternal
+				}
+				rule.TeamsId = append(rule.TeamsId, ownerTeamId)
+			}
+
+			if len(rule.UsersId) > 0 {
+				users, err := s.userRepo.GetByIdsOrTeams(ctx, rule.UsersId, nil)
+				if err != nil {
+					s.logger.ErrorContext(ctx, "TeamService.SetCodeOwners:userRepo.GetByIdsOrTeams - Internal error", slog.String("error", err.Error()))
+					return ErrInternal
+				}
+				if len(users) != len(rule.UsersId) {
+					return ErrNotFound
+				}
+			}
+			rules = append(rules, rule)
+		}
+
+		err = s.teamRepo.SetCodeOwners(ctx, teamId, rules)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "TeamService.SetCodeOwners:teamRepo.SetCodeOwners - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		resp, err = s.getCodeOwners(ctx, teamId, req.TeamName)
+		return err
+	})
+
+	return resp, err
+}
+
+func (s *TeamService) GetCodeOwners(ctx context.Context, teamName string) (*dto.CodeOwnersResponse, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetCodeOwners")
+	defer span.End()
+
+	teamId, err := s.teamRepo.GetIdByName(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "TeamService.GetCodeOwners:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	return s.getCodeOwners(ctx, teamId, teamName)
+}
+
+func (s *TeamService) getCodeOwners(ctx context.Context, teamId int, teamName string) (*dto.CodeOwnersResponse, error) {
+	rules, err := s.teamRepo.GetCodeOwners(ctx, teamId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "TeamService.getCodeOwners:teamRepo.GetCodeOwners - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	resp := &dto.CodeOwnersResponse{
+		TeamName: teamName,
+		Rules:    make([]dto.CodeOwnerRule, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		resp.Rules = append(resp.Rules, dto.CodeOwnerRule{
+			Pattern: rule.Pattern,
+			Users:   rule.UsersId,
+			Teams:   rule.TeamNames,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+)
+
+type afterCommitKey struct{}
+
+// afterCommit collects the actions of a transaction which must run only once it is committed
+type afterCommit struct {
+	mu      sync.Mutex
+	actions []func()
+}
+
+// inTransaction runs fn in a transaction. Actions registered with onCommit run after the outermost
+// transaction is committed and are dropped when it is rolled back.
+func inTransaction(ctx context.Context, trManager *manager.Manager, fn func(ctx context.Context) error) error {
+	//A nested call joins the outer transaction, so the outer call runs the actions
+	if _, ok := ctx.Value(afterCommitKey{}).(*afterCommit); ok {
+		return trManager.Do(ctx, fn)
+	}
+
+	pending := &afterCommit{}
+	if err := trManager.Do(context.WithValue(ctx, afterCommitKey{}, pending), fn); err != nil {
+		return err
+	}
+
+	for _, action := range pending.actions {
+		action()
+	}
+	return nil
+}
+
+// onCommit registers the action to run after the transaction of ctx is committed.
+// Outside of inTransaction there is nothing to wait for and the action runs at once.
+func onCommit(ctx context.Context, action func()) {
+	pending, ok := ctx.Value(afterCommitKey{}).(*afterCommit)
+	if !ok {
+		action()
+		return
+	}
+
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+	pending.actions = append(pending.actions, action)
+}
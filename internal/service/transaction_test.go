@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/avito-tech/go-transaction-manager/trm/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransaction records how it was finished
+type fakeTransaction struct {
+	committed  bool
+	rolledBack bool
+	closed     chan struct{}
+}
+
+func (t *fakeTransaction) Transaction() any { return t }
+
+func (t *fakeTransaction) Commit(context.Context) error {
+	t.committed = true
+	close(t.closed)
+	return nil
+}
+
+func (t *fakeTransaction) Rollback(context.Context) error {
+	t.rolledBack = true
+	close(t.closed)
+	return nil
+}
+
+func (t *fakeTransaction) IsActive() bool { return !t.committed && !t.rolledBack }
+
+func (t *fakeTransaction) Closed() <-chan struct{} { return t.closed }
+
+func fakeManager(started *[]*fakeTransaction) *manager.Manager {
+	return manager.Must(func(ctx context.Context, _ trm.Settings) (context.Context, trm.Transaction, error) {
+		tr := &fakeTransaction{closed: make(chan struct{})}
+		*started = append(*started, tr)
+		return ctx, tr, nil
+	})
+}
+
+func TestInTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("actions run after the commit", func(t *testing.T) {
+		var started []*fakeTransaction
+		trManager := fakeManager(&started)
+
+		var ran []string
+		err := inTransaction(ctx, trManager, func(ctx context.Context) error {
+			onCommit(ctx, func() { ran = append(ran, "outer") })
+
+			//The nested call joins the transaction and does not run the actions itself
+			err := inTransaction(ctx, trManager, func(ctx context.Context) error {
+				onCommit(ctx, func() { ran = append(ran, "nested") })
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Empty(t, ran)
+			return nil
+		})
+		require.NoError(t, err)
+
+		require.Len(t, started, 1)
+		assert.True(t, started[0].committed)
+		assert.Equal(t, []string{"outer", "nested"}, ran)
+	})
+
+	t.Run("actions are dropped on a rollback", func(t *testing.T) {
+		var started []*fakeTransaction
+		trManager := fakeManager(&started)
+		errFailed := errors.New("failed")
+
+		ran := false
+		err := inTransaction(ctx, trManager, func(ctx context.Context) error {
+			onCommit(ctx, func() { ran = true })
+			return errFailed
+		})
+		assert.ErrorIs(t, err, errFailed)
+
+		require.Len(t, started, 1)
+		assert.True(t, started[0].rolledBack)
+		assert.False(t, ran)
+	})
+
+	t.Run("outside of a transaction", func(t *testing.T) {
+		ran := false
+		onCommit(ctx, func() { ran = true })
+		assert.True(t, ran)
+	})
+}
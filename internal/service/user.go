@@ -6,56 +6,127 @@ import (
 	"log/slog"
 
 	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
 	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/tracing"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
 )
 
 type UserService struct {
-	userRepo repository.IUserRepo
-	teamRepo repository.ITeamRepo
-	prRepo   repository.IPullRequestRepo
-	logger   *slog.Logger
+	userRepo  repository.IUserRepo
+	teamRepo  repository.ITeamRepo
+	prRepo    repository.IPullRequestRepo
+	prService IPullRequestService
+	outbox    *Outbox
+	trManager *manager.Manager
+	logger    *slog.Logger
 }
 
-func NewUserService(userRepo repository.IUserRepo, teamRepo repository.ITeamRepo, prRepo repository.IPullRequestRepo, logger *slog.Logger) *UserService {
+func NewUserService(userRepo repository.IUserRepo, teamRepo repository.ITeamRepo, prRepo repository.IPullRequestRepo, prService IPullRequestService, outbox *Outbox, trManager *manager.Manager, logger *slog.Logger) *UserService {
 	return &UserService{
-		userRepo: userRepo,
-		teamRepo: teamRepo,
-		prRepo:   prRepo,
-		logger:   logger,
+		userRepo:  userRepo,
+		teamRepo:  teamRepo,
+		prRepo:    prRepo,
+		prService: prService,
+		outbox:    outbox,
+		trManager: trManager,
+		logger:    logger,
 	}
 }
 
-func (s *UserService) SetIsActive(ctx context.Context, req *dto.SetIsActiveRequest) (*dto.UserResponse, error) {
-	user, err := s.userRepo.UpdateIsActive(ctx, req.UserId, *req.IsActive)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrNotFound
+func (s *UserService) SetIsActive(ctx context.Context, req *dto.SetIsActiveRequest) (*dto.SetIsActiveResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetIsActive")
+	defer span.End()
+
+	var resp *dto.SetIsActiveResponse
+
+	//Deactivation and reassignment are committed together
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		current, err := s.userRepo.GetById(ctx, req.UserId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "UserService.SetIsActive:userRepo.GetById - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
 		}
-		s.logger.Error("UserService.SetIsActive:userRepo.UpdateIsActive - Internal error", slog.String("error", err.Error()))
-		return nil, ErrInternal
-	}
 
-	teamName, err := s.teamRepo.GetNameById(ctx, user.TeamId)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrNotFound
+		//Team leads manage only the members of their own team
+		ok, err := callerLeadsTeam(ctx, s.userRepo, current.TeamId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "UserService.SetIsActive:callerLeadsTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
 		}
-		s.logger.Error("UserService.SetIsActive:teamRepo.GetNameById - Internal error", slog.String("error", err.Error()))
-		return nil, ErrInternal
+		if !ok {
+			return ErrForbidden
+		}
+
+		//Only an actual deactivation is announced, the lock keeps concurrent requests from announcing it twice
+		wasActive, err := s.userRepo.GetActiveIds(ctx, []string{req.UserId})
+		if err != nil {
+			s.logger.ErrorContext(ctx, "UserService.SetIsActive:userRepo.GetActiveIds - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		user, err := s.userRepo.UpdateIsActive(ctx, req.UserId, *req.IsActive)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "UserService.SetIsActive:userRepo.UpdateIsActive - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Users removed from their team have no team name
+		var teamName string
+		if user.TeamId != 0 {
+			teamName, err = s.teamRepo.GetNameById(ctx, user.TeamId)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return ErrNotFound
+				}
+				s.logger.ErrorContext(ctx, "UserService.SetIsActive:teamRepo.GetNameById - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+		}
+
+		resp = &dto.SetIsActiveResponse{
+			User: &dto.UserResponse{
+				UserId:   user.UserId,
+				Username: user.Username,
+				TeamName: teamName,
+				IsActive: user.IsActive,
+			},
+		}
+
+		if user.IsActive {
+			return nil
+		}
+		if req.Reassign {
+			resp.Reassignments, err = s.prService.ReassignOpenReviewsOfUsers(ctx, []string{user.UserId}, models.ReasonDeactivation)
+			if err != nil {
+				return err
+			}
+		}
+		if len(wasActive) == 0 {
+			return nil
+		}
+		return s.outbox.Publish(ctx, models.WebhookUserDeactivated, usersDeactivatedEvent{UsersId: []string{user.UserId}})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &dto.UserResponse{
-		UserId:   user.UserId,
-		Username: user.Username,
-		TeamName: teamName,
-		IsActive: user.IsActive,
-	}, err
+	return resp, nil
 }
 
 func (s *UserService) GetReview(ctx context.Context, userId string) ([]dto.ReviewResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetReview")
+	defer span.End()
+
 	pr, err := s.prRepo.GetAllReviewByUserId(ctx, userId)
 	if err != nil {
-		s.logger.Error("UserService.GetReview:prRepo.GetAllReviewByUserId - Internal error", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "UserService.GetReview:prRepo.GetAllReviewByUserId - Internal error", slog.String("error", err.Error()))
 		return nil, ErrInternal
 	}
 
@@ -67,7 +138,7 @@ func (s *UserService) GetReview(ctx context.Context, userId string) ([]dto.Revie
 			if errors.Is(err, repository.ErrNotFound) {
 				return nil, ErrNotFound
 			}
-			s.logger.Error("UserService.GetReview:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
+			s.logger.ErrorContext(ctx, "UserService.GetReview:prRepo.GetStatusById - Internal error", slog.String("error", err.Error()))
 			return nil, ErrInternal
 		}
 
@@ -83,9 +154,12 @@ func (s *UserService) GetReview(ctx context.Context, userId string) ([]dto.Revie
 }
 
 func (s *UserService) GetStatsReview(ctx context.Context) ([]dto.UserStatsReviewResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetStatsReview")
+	defer span.End()
+
 	users, err := s.userRepo.GetStatsReview(ctx)
 	if err != nil {
-		s.logger.Error("UserService.GetStatsReview:userRepo.GetStatsReview - Internal error", slog.String("error", err.Error()))
+		s.logger.ErrorContext(ctx, "UserService.GetStatsReview:userRepo.GetStatsReview - Internal error", slog.String("error", err.Error()))
 		return nil, ErrInternal
 	}
 
@@ -101,17 +175,207 @@ func (s *UserService) GetStatsReview(ctx context.Context) ([]dto.UserStatsReview
 	return resp, nil
 }
 
-func (s *UserService) MassDeactivation(ctx context.Context, req *dto.MassDeactivationRequest) (*dto.MassDeactivationResponse, error) {
-	usersId, err := s.userRepo.MassDeactivation(ctx, req.UsersId)
+// GetReviewStats returns the review history of the users, by default the busiest reviewers come first
+func (s *UserService) GetReviewStats(ctx context.Context, req *dto.UserStatsRequest) ([]dto.UserStats, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetReviewStats")
+	defer span.End()
+
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return nil, ErrInvalidPeriod
+	}
+
+	var teamId int
+	if req.TeamName != "" {
+		var err error
+		teamId, err = s.teamRepo.GetIdByName(ctx, req.TeamName)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "UserService.GetReviewStats:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+			return nil, ErrInternal
+		}
+	}
+
+	//Members see the statistics only of their own team, without a team name it is the default
+	teamName := req.TeamName
+	callerTeam, restricted, err := callerTeamId(ctx, s.userRepo)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrNotFound
+		s.logger.ErrorContext(ctx, "UserService.GetReviewStats:callerTeamId - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	if restricted {
+		if callerTeam == 0 || (teamId != 0 && teamId != callerTeam) {
+			return nil, ErrForbidden
+		}
+		if teamId == 0 {
+			teamName, err = s.teamRepo.GetNameById(ctx, callerTeam)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return nil, ErrNotFound
+				}
+				s.logger.ErrorContext(ctx, "UserService.GetReviewStats:teamRepo.GetNameById - Internal error", slog.String("error", err.Error()))
+				return nil, ErrInternal
+			}
 		}
-		s.logger.Error("UserService.MassDeactivation:userRepo.MassDeactivation - Internal error", slog.String("error", err.Error()))
+	}
+
+	filter := &models.UserStatsFilter{
+		TeamName: teamName,
+		From:     req.From,
+		To:       req.To,
+		SortBy:   req.SortBy,
+		Desc:     req.Order != "asc",
+	}
+	if filter.SortBy == "" {
+		filter.SortBy = "total_reviews"
+	}
+
+	users, err := s.userRepo.GetReviewStats(ctx, filter)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "UserService.GetReviewStats:userRepo.GetReviewStats - Internal error", slog.String("error", err.Error()))
 		return nil, ErrInternal
 	}
 
-	return &dto.MassDeactivationResponse{
-		UsersId: usersId,
-	}, nil
+	resp := make([]dto.UserStats, 0, len(users))
+	for _, user := range users {
+		resp = append(resp, dto.UserStats{
+			UserId:           user.UserId,
+			Username:         user.Username,
+			TeamName:         user.TeamName,
+			TotalReviews:     user.TotalReviews,
+			OpenReviews:      user.OpenReviews,
+			CompletedReviews: user.CompletedReviews,
+			ReassignedAway:   user.ReassignedAway,
+			AvgReviewSeconds: roundSeconds(user.AvgReviewSeconds),
+			AuthoredPr:       user.AuthoredPr,
+		})
+	}
+	return resp, nil
+}
+
+func (s *UserService) MassDeactivation(ctx context.Context, req *dto.MassDeactivationRequest) (*dto.MassDeactivationResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.MassDeactivation")
+	defer span.End()
+
+	var resp *dto.MassDeactivationResponse
+
+	//Deactivation and reassignment are committed together
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		//Users which are already inactive are not announced again
+		wasActive, err := s.userRepo.GetActiveIds(ctx, req.UsersId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "UserService.MassDeactivation:userRepo.GetActiveIds - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		usersId, err := s.userRepo.MassDeactivation(ctx, req.UsersId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "UserService.MassDeactivation:userRepo.MassDeactivation - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		resp = &dto.MassDeactivationResponse{
+			UsersId: usersId,
+		}
+
+		if req.Reassign {
+			resp.Reassignments, err = s.prService.ReassignOpenReviewsOfUsers(ctx, usersId, models.ReasonDeactivation)
+			if err != nil {
+				return err
+			}
+		}
+		if len(wasActive) == 0 {
+			return nil
+		}
+		return s.outbox.Publish(ctx, models.WebhookUserDeactivated, usersDeactivatedEvent{UsersId: wasActive})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Move puts the user into another team. Open PRs of the user follow the author to the new team,
+// reviews of the old team's open PRs are optionally reassigned to the old team members.
+func (s *UserService) Move(ctx context.Context, req *dto.UserMoveRequest) (*dto.UserTeamChangeResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Move")
+	defer span.End()
+
+	var resp *dto.UserTeamChangeResponse
+
+	//Moving and reassignment are committed together
+	err := inTransaction(ctx, s.trManager, func(ctx context.Context) error {
+		teamId, err := s.teamRepo.GetIdByName(ctx, req.TeamName)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "UserService.Move:teamRepo.GetIdByName - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		current, err := s.userRepo.GetById(ctx, req.UserId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			s.logger.ErrorContext(ctx, "UserService.Move:userRepo.GetById - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+
+		//Team leads move only the members of their own team, users without a team only into it
+		fromTeamId := current.TeamId
+		if fromTeamId == 0 {
+			fromTeamId = teamId
+		}
+		ok, err := callerLeadsTeam(ctx, s.userRepo, fromTeamId)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "UserService.Move:callerLeadsTeam - Internal error", slog.String("error", err.Error()))
+			return ErrInternal
+		}
+		if !ok {
+			return ErrForbidden
+		}
+
+		user := current
+		if current.TeamId != teamId {
+			user, err = s.userRepo.UpdateTeam(ctx, req.UserId, teamId)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return ErrNotFound
+				}
+				s.logger.ErrorContext(ctx, "UserService.Move:userRepo.UpdateTeam - Internal error", slog.String("error", err.Error()))
+				return ErrInternal
+			}
+		}
+
+		resp = &dto.UserTeamChangeResponse{
+			User: &dto.UserResponse{
+				UserId:   user.UserId,
+				Username: user.Username,
+				TeamName: req.TeamName,
+				IsActive: user.IsActive,
+			},
+		}
+
+		//The user is already in the new team, so candidates are taken among the old team members.
+		//Reviews of other teams' PRs (code owners, fallback teams) do not depend on the team and are kept.
+		if req.Reassign && current.TeamId != 0 && current.TeamId != teamId {
+			resp.Reassignments, err = s.prService.ReassignOpenReviewsInTeam(ctx, []string{user.UserId}, current.TeamId, models.ReasonTeamChange)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
 }
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/tracing"
+)
+
+const (
+	defaultDeliveriesLimit int = 50
+	webhookSecretBytes     int = 32
+)
+
+// Headers of the outgoing requests
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+type WebhookService struct {
+	webhookRepo repository.IWebhookRepo
+	client      *http.Client
+	logger      *slog.Logger
+}
+
+func NewWebhookService(webhookRepo repository.IWebhookRepo, client *http.Client, logger *slog.Logger) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		client:      client,
+		logger:      logger,
+	}
+}
+
+func (s *WebhookService) Create(ctx context.Context, req *dto.WebhookRequest) (*dto.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Create")
+	defer span.End()
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(buf); err != nil {
+			s.logger.ErrorContext(ctx, "WebhookService.Create:rand.Read - Internal error", slog.String("error", err.Error()))
+			return nil, ErrInternal
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	webhook, err := s.webhookRepo.Create(ctx, &models.Webhook{
+		Url:      req.Url,
+		Secret:   secret,
+		Events:   req.Events,
+		IsActive: isActive,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "WebhookService.Create:webhookRepo.Create - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	//The secret is shown only once
+	resp := toWebhookDto(webhook)
+	resp.Secret = webhook.Secret
+	return resp, nil
+}
+
+func (s *WebhookService) Get(ctx context.Context, webhookId int) (*dto.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Get")
+	defer span.End()
+
+	webhook, err := s.webhookRepo.GetById(ctx, webhookId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "WebhookService.Get:webhookRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	return toWebhookDto(webhook), nil
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]dto.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.List")
+	defer span.End()
+
+	webhooks, err := s.webhookRepo.GetAll(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "WebhookService.List:webhookRepo.GetAll - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	resp := make([]dto.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		resp = append(resp, *toWebhookDto(&webhook))
+	}
+	return resp, nil
+}
+
+func (s *WebhookService) Update(ctx context.Context, webhookId int, req *dto.WebhookRequest) (*dto.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Update")
+	defer span.End()
+
+	current, err := s.webhookRepo.GetById(ctx, webhookId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "WebhookService.Update:webhookRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	//Secret and activity are kept when not passed
+	current.Url = req.Url
+	current.Events = req.Events
+	if req.Secret != "" {
+		current.Secret = req.Secret
+	}
+	if req.IsActive != nil {
+		current.IsActive = *req.IsActive
+	}
+
+	webhook, err := s.webhookRepo.Update(ctx, current)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "WebhookService.Update:webhookRepo.Update - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	return toWebhookDto(webhook), nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, webhookId int) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.Delete")
+	defer span.End()
+
+	err := s.webhookRepo.Delete(ctx, webhookId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "WebhookService.Delete:webhookRepo.Delete - Internal error", slog.String("error", err.Error()))
+		return ErrInternal
+	}
+	return nil
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookId int, req *dto.WebhookDeliveriesRequest) ([]dto.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetDeliveries")
+	defer span.End()
+
+	if _, err := s.Get(ctx, webhookId); err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultDeliveriesLimit
+	}
+
+	deliveries, err := s.webhookRepo.GetDeliveries(ctx, webhookId, limit)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "WebhookService.GetDeliveries:webhookRepo.GetDeliveries - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+
+	resp := make([]dto.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		resp = append(resp, dto.WebhookDelivery{
+			Id:         delivery.Id,
+			DeliveryId: delivery.JobId,
+			Event:      delivery.Event,
+			Attempt:    delivery.Attempt,
+			StatusCode: delivery.StatusCode,
+			Error:      delivery.Error,
+			DurationMs: delivery.DurationMs,
+			CreatedAt:  delivery.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// HandleDelivery is the worker handler of webhook_delivery jobs.
+// Every attempt is written to the delivery log, failed ones are retried by the worker pool.
+func (s *WebhookService) HandleDelivery(ctx context.Context, job *models.Job) (any, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.HandleDelivery")
+	defer span.End()
+
+	var payload webhookDeliveryPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.webhookRepo.GetById(ctx, payload.WebhookId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.logger.ErrorContext(ctx, "WebhookService.HandleDelivery:webhookRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, ErrInternal
+	}
+	if !webhook.IsActive {
+		return map[string]any{"skipped": true}, nil
+	}
+
+	start := time.Now()
+	statusCode, sendErr := s.send(ctx, webhook, job.Id, payload)
+
+	delivery := &models.WebhookDelivery{
+		WebhookId:  webhook.Id,
+		JobId:      job.Id,
+		Event:      payload.Event,
+		Attempt:    job.Attempts,
+		StatusCode: statusCode,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+	if err := s.webhookRepo.AddDelivery(ctx, delivery); err != nil {
+		s.logger.ErrorContext(ctx, "WebhookService.HandleDelivery:webhookRepo.AddDelivery - Internal error", slog.String("error", err.Error()))
+	}
+
+	if sendErr != nil {
+		return nil, sendErr
+	}
+	return map[string]any{"status_code": statusCode}, nil
+}
+
+func (s *WebhookService) send(ctx context.Context, webhook *models.Webhook, deliveryId string, payload webhookDeliveryPayload) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(payload.Body))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrWebhookDelivery, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, payload.Event)
+	req.Header.Set(HeaderWebhookDelivery, deliveryId)
+	req.Header.Set(HeaderWebhookSignature, "sha256="+Sign(webhook.Secret, payload.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrWebhookDelivery, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%w: status %d", ErrWebhookDelivery, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of the body, receivers compare it with the signature header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func toWebhookDto(webhook *models.Webhook) *dto.Webhook {
+	return &dto.Webhook{
+		Id:        webhook.Id,
+		Url:       webhook.Url,
+		Events:    webhook.Events,
+		IsActive:  webhook.IsActive,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
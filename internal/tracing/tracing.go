@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Estriper0/avito_intership/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ServiceName = "pr-reviewer"
+
+	instrumentationName = "github.com/Estriper0/avito_intership"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOtlp   = "otlp"
+)
+
+// Init sets the global tracer provider and the W3C propagator.
+// With the none exporter spans are not recorded, the returned shutdown flushes the spans left in the batch.
+func Init(ctx context.Context, cfg config.TracingConfig) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(ctx context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOtlp:
+		options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("tracing:Init - unknown exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing:Init:%s - %w", cfg.Exporter, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named after the component and its method, e.g. PullRequestService.Create
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name)
+}
+
+// Inject returns the trace context to be stored with a background job
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// Extract continues the trace stored with a background job
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// TaskFunc is a periodic task, an error is logged and the task runs again on the next tick.
+type TaskFunc func(ctx context.Context) error
+
+// Scheduler runs a task at a fixed interval, e.g. to react to absences starting.
+// Tasks must be safe to run concurrently in several replicas.
+type Scheduler struct {
+	name     string
+	interval time.Duration
+	task     TaskFunc
+	logger   *slog.Logger
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewScheduler(name string, interval time.Duration, task TaskFunc, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		name:     name,
+		interval: interval,
+		task:     task,
+		logger:   logger,
+	}
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			//A run in progress is finished even if the scheduler is stopping
+			if err := s.task(context.WithoutCancel(ctx)); err != nil {
+				s.logger.ErrorContext(ctx, "worker.Scheduler.run - Task failed", slog.String("task", s.name), slog.String("error", err.Error()))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the ticks and waits for the run in progress.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
@@ -0,0 +1,235 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/Estriper0/avito_intership/internal/metrics"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// HandlerFunc processes a leased job, the returned value is stored as the job result.
+type HandlerFunc func(ctx context.Context, job *models.Job) (any, error)
+
+// Pool runs background jobs stored in the jobs table.
+// Several pools (e.g. in different replicas) may poll the same table,
+// a job is leased by one worker at a time.
+type Pool struct {
+	jobRepo   repository.IJobRepo
+	cfg       config.WorkerConfig
+	logger    *slog.Logger
+	retryable func(err error) bool
+	handlers  map[string]HandlerFunc
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	running   atomic.Bool
+	busy      atomic.Int32
+}
+
+func New(jobRepo repository.IJobRepo, cfg config.WorkerConfig, logger *slog.Logger, retryable func(err error) bool) *Pool {
+	return &Pool{
+		jobRepo:   jobRepo,
+		cfg:       cfg,
+		logger:    logger,
+		retryable: retryable,
+		handlers:  make(map[string]HandlerFunc),
+	}
+}
+
+// Register binds a handler to a job type, must be called before Start.
+func (p *Pool) Register(jobType string, handler HandlerFunc) {
+	p.handlers[jobType] = handler
+}
+
+func (p *Pool) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.running.Store(true)
+
+	for i := 0; i < p.cfg.Count; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.poll(ctx)
+		}()
+	}
+}
+
+// Stop stops polling and waits for the jobs in progress.
+func (p *Pool) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+	p.running.Store(false)
+}
+
+// Status is reported by the readiness probe
+func (p *Pool) Status() models.WorkerStatus {
+	return models.WorkerStatus{
+		Running: p.running.Load(),
+		Count:   p.cfg.Count,
+		Busy:    int(p.busy.Load()),
+	}
+}
+
+func (p *Pool) poll(ctx context.Context) {
+	for {
+		job, err := p.jobRepo.Lease(ctx, p.cfg.LeaseTimeout)
+		if err == nil {
+			//Jobs in progress are finished even if the pool is stopping
+			p.process(context.WithoutCancel(ctx), job)
+			continue
+		}
+		if !errors.Is(err, repository.ErrNotFound) && ctx.Err() == nil {
+			p.logger.ErrorContext(ctx, "worker.Pool.poll:jobRepo.Lease - Internal error", slog.String("error", err.Error()))
+		}
+
+		//Nothing to do, wait for the next poll
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.cfg.PollInterval):
+		}
+	}
+}
+
+func (p *Pool) process(ctx context.Context, job *models.Job) {
+	//The job continues the trace of the request that enqueued it
+	ctx, span := tracing.Start(tracing.Extract(ctx, job.TraceContext), "worker."+job.Type)
+	defer span.End()
+	span.SetAttributes(attribute.String("job.id", job.Id), attribute.Int("job.attempt", job.Attempts))
+
+	p.busy.Add(1)
+	defer p.busy.Add(-1)
+
+	start := time.Now()
+	defer func() {
+		metrics.WorkerBusy.WithLabelValues(job.Type).Add(time.Since(start).Seconds())
+	}()
+
+	handler, ok := p.handlers[job.Type]
+	if !ok {
+		p.fail(ctx, job, "unknown job type: "+job.Type)
+		return
+	}
+
+	//The lease is prolonged while the handler runs. If the job was taken by another worker anyway
+	//(e.g. this one could not reach the database for the whole lease), the handler is cancelled
+	handlerCtx, cancel := context.WithCancel(ctx)
+	var lost atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if !p.keepLease(handlerCtx, job) {
+			lost.Store(true)
+			cancel()
+		}
+	}()
+
+	result, err := handler(handlerCtx, job)
+	cancel()
+	wg.Wait()
+
+	if lost.Load() {
+		p.leaseLost(ctx, job)
+		return
+	}
+
+	if err != nil {
+		if !p.retryable(err) || job.Attempts >= job.MaxAttempts {
+			p.fail(ctx, job, err.Error())
+			return
+		}
+		runAt := time.Now().Add(p.backoff(job.Attempts))
+		if err := p.jobRepo.Retry(ctx, job.Id, job.Attempts, err.Error(), runAt); err != nil {
+			if errors.Is(err, repository.ErrLeaseLost) {
+				p.leaseLost(ctx, job)
+				return
+			}
+			p.logger.ErrorContext(ctx, "worker.Pool.process:jobRepo.Retry - Internal error", slog.String("error", err.Error()))
+		}
+		metrics.WorkerJobs.WithLabelValues(job.Type, "retried").Inc()
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		p.fail(ctx, job, err.Error())
+		return
+	}
+	if err := p.jobRepo.Complete(ctx, job.Id, job.Attempts, data); err != nil {
+		if errors.Is(err, repository.ErrLeaseLost) {
+			p.leaseLost(ctx, job)
+			return
+		}
+		p.logger.ErrorContext(ctx, "worker.Pool.process:jobRepo.Complete - Internal error", slog.String("error", err.Error()))
+	}
+	metrics.WorkerJobs.WithLabelValues(job.Type, "succeeded").Inc()
+}
+
+// keepLease extends the lease every third of the lease timeout until ctx is done.
+// Returns false if the lease was lost.
+func (p *Pool) keepLease(ctx context.Context, job *models.Job) bool {
+	if p.cfg.LeaseTimeout <= 0 {
+		return true
+	}
+
+	ticker := time.NewTicker(p.cfg.LeaseTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		case <-ticker.C:
+			err := p.jobRepo.Extend(ctx, job.Id, job.Attempts, p.cfg.LeaseTimeout)
+			if errors.Is(err, repository.ErrLeaseLost) {
+				return false
+			}
+			//A failed extension is retried on the next tick, the lease is still valid until then
+			if err != nil && ctx.Err() == nil {
+				p.logger.ErrorContext(ctx, "worker.Pool.keepLease:jobRepo.Extend - Internal error", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// Another worker owns the job now, its result is left to that worker
+func (p *Pool) leaseLost(ctx context.Context, job *models.Job) {
+	p.logger.WarnContext(ctx, "Job lease lost", slog.String("job_id", job.Id), slog.String("type", job.Type), slog.Int("attempt", job.Attempts))
+	metrics.WorkerJobs.WithLabelValues(job.Type, "lost").Inc()
+}
+
+func (p *Pool) fail(ctx context.Context, job *models.Job, reason string) {
+	if err := p.jobRepo.Fail(ctx, job.Id, job.Attempts, reason); err != nil {
+		if errors.Is(err, repository.ErrLeaseLost) {
+			p.leaseLost(ctx, job)
+			return
+		}
+		p.logger.ErrorContext(ctx, "worker.Pool.fail:jobRepo.Fail - Internal error", slog.String("error", err.Error()))
+	}
+	p.logger.WarnContext(ctx, "Job failed", slog.String("job_id", job.Id), slog.String("type", job.Type), slog.String("error", reason))
+	metrics.WorkerJobs.WithLabelValues(job.Type, "failed").Inc()
+}
+
+// backoff returns the delay before the next attempt: base * 2^(attempts-1), capped.
+func (p *Pool) backoff(attempts int) time.Duration {
+	delay := p.cfg.RetryBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= p.cfg.MaxRetryBackoff {
+			return p.cfg.MaxRetryBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,4 @@
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS min_reviewers INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS max_reviewers INTEGER NOT NULL DEFAULT 2,
+    ADD CONSTRAINT teams_reviewers_check CHECK (min_reviewers >= 0 AND min_reviewers <= max_reviewers);
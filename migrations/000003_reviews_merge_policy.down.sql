@@ -0,0 +1,9 @@
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS force_merged;
+
+ALTER TABLE teams
+    DROP COLUMN IF EXISTS required_approvals;
+
+ALTER TABLE pull_requests_reviewers
+    DROP COLUMN IF EXISTS decision,
+    DROP COLUMN IF EXISTS decided_at;
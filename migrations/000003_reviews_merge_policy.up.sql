@@ -0,0 +1,9 @@
+ALTER TABLE pull_requests_reviewers
+    ADD COLUMN IF NOT EXISTS decision VARCHAR(30) CHECK (decision IN ('APPROVED', 'CHANGES_REQUESTED', 'COMMENTED')),
+    ADD COLUMN IF NOT EXISTS decided_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS required_approvals INTEGER NOT NULL DEFAULT 1 CHECK (required_approvals >= 0);
+
+ALTER TABLE pull_requests
+    ADD COLUMN IF NOT EXISTS force_merged BOOLEAN NOT NULL DEFAULT false;
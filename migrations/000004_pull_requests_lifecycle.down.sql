@@ -0,0 +1,6 @@
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS closed_at;
+
+UPDATE pull_requests SET status_id = 1 WHERE status_id IN (3, 4);
+
+DELETE FROM pull_requests_statuses WHERE id IN (3, 4);
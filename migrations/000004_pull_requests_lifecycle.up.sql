@@ -0,0 +1,7 @@
+INSERT INTO pull_requests_statuses (id, status) VALUES
+    (3, 'DRAFT'),
+    (4, 'CLOSED')
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE pull_requests
+    ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP WITH TIME ZONE;
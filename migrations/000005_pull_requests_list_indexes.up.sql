@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_pr_created_at ON pull_requests(created_at DESC, pr_id DESC);
+CREATE INDEX IF NOT EXISTS idx_pr_status_id ON pull_requests(status_id);
@@ -0,0 +1,3 @@
+ALTER TABLE jobs
+    DROP COLUMN IF EXISTS progress_total,
+    DROP COLUMN IF EXISTS progress_processed;
@@ -0,0 +1,3 @@
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS progress_processed INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS progress_total INTEGER NOT NULL DEFAULT 0;
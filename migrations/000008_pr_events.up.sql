@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS pr_events (
+    id BIGSERIAL PRIMARY KEY,
+    pr_id VARCHAR(30) NOT NULL REFERENCES pull_requests(pr_id),
+    type VARCHAR(30) NOT NULL,
+    actor VARCHAR(30) NOT NULL,
+    reviewer_id VARCHAR(30),
+    old_reviewer_id VARCHAR(30),
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pr_events_pr_id ON pr_events(pr_id, id);
+
+--History is append-only
+CREATE OR REPLACE FUNCTION pr_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'pr_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_pr_events_append_only
+    BEFORE UPDATE OR DELETE ON pr_events
+    FOR EACH ROW EXECUTE FUNCTION pr_events_append_only();
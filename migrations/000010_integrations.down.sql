@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS integration_deliveries;
+DROP TABLE IF EXISTS identity_mappings;
@@ -0,0 +1,19 @@
+--Logins on the Git hosting mapped to our users
+CREATE TABLE IF NOT EXISTS identity_mappings (
+    provider VARCHAR(20) NOT NULL,
+    login VARCHAR(100) NOT NULL,
+    user_id VARCHAR(30) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY(provider, login)
+);
+
+--Processed inbound deliveries, replays of the same delivery are skipped
+CREATE TABLE IF NOT EXISTS integration_deliveries (
+    provider VARCHAR(20) NOT NULL,
+    delivery_id VARCHAR(100) NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    pr_id VARCHAR(30),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY(provider, delivery_id)
+);
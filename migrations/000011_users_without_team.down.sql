@@ -0,0 +1,9 @@
+--Users without a team cannot be put back into one automatically, so the rollback stops until they are assigned or deleted
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE team_id IS NULL) THEN
+        RAISE EXCEPTION 'users without a team exist, move them to a team or delete them before rolling back 000011_users_without_team';
+    END IF;
+END $$;
+
+ALTER TABLE users ALTER COLUMN team_id SET NOT NULL;
@@ -0,0 +1,2 @@
+--Users removed from a team or left after the team deletion keep their history without a team
+ALTER TABLE users ALTER COLUMN team_id DROP NOT NULL;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS team_fallbacks (
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    fallback_team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY(team_id, fallback_team_id),
+    CONSTRAINT team_fallbacks_self_check CHECK (team_id <> fallback_team_id)
+);
+
+CREATE INDEX idx_team_fallbacks_fallback_team_id ON team_fallbacks(fallback_team_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pull_requests_files;
+DROP TABLE IF EXISTS code_owner_rules;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS code_owner_rules (
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    pattern VARCHAR(255) NOT NULL,
+    users_id VARCHAR(30)[] NOT NULL DEFAULT '{}',
+    teams_id INTEGER[] NOT NULL DEFAULT '{}',
+    PRIMARY KEY(team_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS pull_requests_files (
+    pr_id VARCHAR(30) NOT NULL REFERENCES pull_requests(pr_id) ON DELETE CASCADE,
+    path TEXT NOT NULL,
+    PRIMARY KEY(pr_id, path)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user_absences (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(30) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason VARCHAR(100) NOT NULL DEFAULT '',
+    --Set once the open reviews of the user are reassigned at the start of the absence
+    reassigned_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT user_absences_period_check CHECK (starts_at < ends_at)
+);
+
+CREATE INDEX idx_user_absences_user_id ON user_absences(user_id, ends_at);
+CREATE INDEX idx_user_absences_pending ON user_absences(starts_at) WHERE reassigned_at IS NULL;
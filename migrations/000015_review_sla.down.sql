@@ -0,0 +1,7 @@
+ALTER TABLE teams
+    DROP COLUMN IF EXISTS sla_auto_reassign,
+    DROP COLUMN IF EXISTS review_sla_hours;
+
+ALTER TABLE pull_requests_reviewers
+    DROP COLUMN IF EXISTS sla_breached_at,
+    DROP COLUMN IF EXISTS assigned_at;
@@ -0,0 +1,11 @@
+--Existing reviewers are treated as assigned now, so the SLA of old PRs is not breached at once
+ALTER TABLE pull_requests_reviewers
+    ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS sla_breached_at TIMESTAMP WITH TIME ZONE;
+
+--0 turns the SLA off
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS review_sla_hours INTEGER NOT NULL DEFAULT 0 CHECK (review_sla_hours >= 0),
+    ADD COLUMN IF NOT EXISTS sla_auto_reassign BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX idx_pr_reviewers_sla_pending ON pull_requests_reviewers(assigned_at) WHERE decision IS NULL AND sla_breached_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pr_events_reviewed;
+DROP INDEX IF EXISTS idx_pr_author_merged_at;
+DROP INDEX IF EXISTS idx_pr_author_created_at;
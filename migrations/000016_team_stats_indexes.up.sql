@@ -0,0 +1,4 @@
+--Team analytics filter PRs of the team members by the creation or merge time
+CREATE INDEX IF NOT EXISTS idx_pr_author_created_at ON pull_requests(author_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_pr_author_merged_at ON pull_requests(author_id, merged_at) WHERE merged_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_pr_events_reviewed ON pr_events(pr_id, created_at) WHERE type = 'reviewed';
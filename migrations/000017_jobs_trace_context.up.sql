@@ -0,0 +1,3 @@
+--W3C trace context of the request that enqueued the job
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS trace_context JSONB NOT NULL DEFAULT '{}';
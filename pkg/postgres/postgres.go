@@ -14,6 +14,7 @@ func New(url string, poolSize int32) (*pgxpool.Pool, error) {
 	}
 
 	poolConfig.MaxConns = poolSize
+	poolConfig.ConnConfig.Tracer = QueryTracer{}
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/Estriper0/avito_intership/pkg/postgres"
+	//Repositories are looked up in the call stack to name the spans
+	repositoryPackage = "/internal/repository/db."
+)
+
+// QueryTracer records a span per query as a child of the span in the context.
+// Queries without a parent (e.g. polling of the workers) are not traced.
+type QueryTracer struct{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx
+	}
+
+	ctx, _ = otel.Tracer(instrumentationName).Start(ctx, statementName(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", data.SQL),
+		),
+	)
+	return ctx
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("db.operation", data.CommandTag.String()),
+		attribute.Int64("db.rows", data.CommandTag.RowsAffected()),
+	)
+	//No rows is an expected result of QueryRow, e.g. a missing user
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
+
+// statementName returns the repository method running the query, e.g. UserRepo.GetById
+func statementName() string {
+	pc := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(3, pc)])
+	for {
+		frame, more := frames.Next()
+		if _, method, ok := strings.Cut(frame.Function, repositoryPackage); ok {
+			return strings.NewReplacer("(*", "", ")", "").Replace(method)
+		}
+		if !more {
+			return "postgres.Query"
+		}
+	}
+}
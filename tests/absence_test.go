@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	"github.com/Estriper0/avito_intership/internal/service"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestSuite) TestUserService_Absences() {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	trManager := manager.Must(trmpgx.NewDefaultFactory(s.db))
+	prService := s.newPullRequestService(trManager, logger)
+	userService := service.NewUserService(
+		db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter),
+		db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter),
+		db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter),
+		prService,
+		service.NewOutbox(db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter), db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter), 1, logger),
+		trManager,
+		logger,
+	)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name, min_reviewers, max_reviewers) VALUES (1, 'backend', 1, 1);
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true), ('u2', 'bob', 1, true), ('u3', 'carol', 1, true);
+	`)
+	require.NoError(s.T(), err)
+
+	now := time.Now()
+
+	s.Run("validation and access", func() {
+		member := auth.WithPrincipal(s.ctx, &auth.Principal{UserId: "u1", Role: auth.RoleMember})
+		_, err := userService.AddAbsence(member, &dto.AbsenceRequest{UserId: "u2", StartsAt: now, EndsAt: now.Add(time.Hour)})
+		assert.ErrorIs(s.T(), err, service.ErrForbidden)
+
+		_, err = userService.AddAbsence(s.ctx, &dto.AbsenceRequest{UserId: "u2", StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)})
+		assert.ErrorIs(s.T(), err, service.ErrInvalidAbsence)
+
+		_, err = userService.AddAbsence(s.ctx, &dto.AbsenceRequest{UserId: "ghost", StartsAt: now, EndsAt: now.Add(time.Hour)})
+		assert.ErrorIs(s.T(), err, service.ErrNotFound)
+	})
+
+	carolAway, err := userService.AddAbsence(s.ctx, &dto.AbsenceRequest{UserId: "u3", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Reason: "vacation"})
+	require.NoError(s.T(), err)
+
+	s.Run("absent users are not candidates", func() {
+		pr, err := prService.Create(s.ctx, &dto.PrCreateRequest{PrId: "pr-1", PrName: "feature", AuthorId: "u1"})
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"u2"}, pr.AssignedReviewers)
+
+		_, err = prService.Reassign(s.ctx, &dto.ReassignRequest{PrId: "pr-1", OldReviewerId: "u2"})
+		assert.ErrorIs(s.T(), err, service.ErrNoCandidate)
+	})
+
+	//Carol is back early and Bob leaves
+	require.NoError(s.T(), userService.DeleteAbsence(s.ctx, &dto.AbsenceDeleteRequest{UserId: "u3", AbsenceId: carolAway.AbsenceId}))
+	_, err = userService.AddAbsence(s.ctx, &dto.AbsenceRequest{UserId: "u2", StartsAt: now.Add(-time.Minute), EndsAt: now.Add(time.Hour)})
+	require.NoError(s.T(), err)
+
+	s.Run("reviews are reassigned when the absence starts", func() {
+		require.NoError(s.T(), userService.StartAbsences(s.ctx))
+
+		pr, err := prService.Get(s.ctx, "pr-1")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"u3"}, pr.AssignedReviewers)
+
+		history, err := prService.History(s.ctx, "pr-1")
+		require.NoError(s.T(), err)
+		last := history.Events[len(history.Events)-1]
+		assert.Equal(s.T(), models.EventReassigned, last.Type)
+		assert.Equal(s.T(), models.ReasonAbsence, last.Reason)
+		assert.Equal(s.T(), "u2", last.OldReviewerId)
+
+		absences, err := userService.GetAbsences(s.ctx, "u2")
+		require.NoError(s.T(), err)
+		require.Len(s.T(), absences, 1)
+		assert.NotNil(s.T(), absences[0].ReassignedAt)
+	})
+
+	s.Run("started absences are processed once", func() {
+		_, err := prService.Reassign(s.ctx, &dto.ReassignRequest{PrId: "pr-1", OldReviewerId: "u3"})
+		assert.ErrorIs(s.T(), err, service.ErrNoCandidate)
+
+		require.NoError(s.T(), userService.StartAbsences(s.ctx))
+		history, err := prService.History(s.ctx, "pr-1")
+		require.NoError(s.T(), err)
+		assert.Len(s.T(), history.Events, 3)
+	})
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/Estriper0/avito_intership/internal/auth"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	"github.com/Estriper0/avito_intership/internal/service"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestSuite) TestService_TeamLeadScope() {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	trManager := manager.Must(trmpgx.NewDefaultFactory(s.db))
+	prService := s.newPullRequestService(trManager, logger)
+	userRepo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+	teamRepo := db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter)
+	prRepo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+	outbox := service.NewOutbox(db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter), db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter), 1, logger)
+	teamService := service.NewTeamService(teamRepo, userRepo, prRepo, prService, outbox, trManager, logger)
+	userService := service.NewUserService(userRepo, teamRepo, prRepo, prService, outbox, trManager, logger)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name, min_reviewers, max_reviewers) VALUES (1, 'backend', 1, 1), (2, 'mobile', 1, 1);
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true), ('u2', 'bob', 1, true), ('u3', 'carol', 1, true),
+			('m1', 'dave', 2, true), ('m2', 'erin', 2, true);
+	`)
+	require.NoError(s.T(), err)
+
+	backendLead := auth.WithPrincipal(s.ctx, &auth.Principal{UserId: "u1", Role: auth.RoleTeamLead})
+	backendMember := auth.WithPrincipal(s.ctx, &auth.Principal{UserId: "u3", Role: auth.RoleMember})
+	mobileLead := auth.WithPrincipal(s.ctx, &auth.Principal{UserId: "m1", Role: auth.RoleTeamLead})
+	admin := auth.WithPrincipal(s.ctx, &auth.Principal{Role: auth.RoleAdmin})
+
+	_, err = prService.Create(s.ctx, &dto.PrCreateRequest{PrId: "pr-1", PrName: "feature", AuthorId: "u2", Draft: true})
+	require.NoError(s.T(), err)
+
+	s.Run("status changes", func() {
+		_, err := prService.Ready(backendMember, "pr-1")
+		assert.ErrorIs(s.T(), err, service.ErrForbidden)
+
+		_, err = prService.Ready(mobileLead, "pr-1")
+		assert.ErrorIs(s.T(), err, service.ErrForbidden)
+
+		pr, err := prService.Ready(backendLead, "pr-1")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), "OPEN", pr.Status)
+
+		_, err = prService.Close(auth.WithPrincipal(s.ctx, &auth.Principal{UserId: "u2", Role: auth.RoleMember}), "pr-1")
+		require.NoError(s.T(), err)
+
+		_, err = prService.Reopen(admin, "pr-1")
+		require.NoError(s.T(), err)
+	})
+
+	s.Run("team settings and statistics", func() {
+		one := 1
+		req := &dto.TeamSettingsRequest{TeamName: "backend", MinReviewers: &one, MaxReviewers: &one}
+		_, err := teamService.UpdateSettings(mobileLead, req)
+		assert.ErrorIs(s.T(), err, service.ErrForbidden)
+
+		_, err = teamService.UpdateSettings(backendLead, req)
+		require.NoError(s.T(), err)
+
+		_, err = teamService.GetStatsPR(mobileLead, &dto.TeamStatsPrRequest{TeamName: "backend"})
+		assert.ErrorIs(s.T(), err, service.ErrForbidden)
+
+		_, err = teamService.GetStatsPR(backendMember, &dto.TeamStatsPrRequest{TeamName: "backend"})
+		require.NoError(s.T(), err)
+
+		//Without a team name the statistics are of the own team
+		stats, err := userService.GetReviewStats(mobileLead, &dto.UserStatsRequest{})
+		require.NoError(s.T(), err)
+		assert.NotEmpty(s.T(), stats)
+		for _, user := range stats {
+			assert.Equal(s.T(), "mobile", user.TeamName)
+		}
+	})
+
+	s.Run("new team", func() {
+		//A lead cannot take members of another team into a new one
+		_, err := teamService.Add(backendLead, &dto.Team{
+			TeamName: "platform",
+			Members:  []dto.Members{{UserId: "m2", Username: "erin", IsActive: false}},
+		})
+		assert.ErrorIs(s.T(), err, service.ErrUserInOtherTeam)
+
+		user, err := userRepo.GetById(s.ctx, "m2")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), 2, user.TeamId)
+		assert.True(s.T(), user.IsActive)
+	})
+
+	s.Run("pull requests", func() {
+		//Members open PRs only as themselves
+		_, err := prService.Create(backendMember, &dto.PrCreateRequest{PrId: "pr-2", PrName: "fix", AuthorId: "u2"})
+		assert.ErrorIs(s.T(), err, service.ErrForbidden)
+
+		_, err = prService.Create(backendMember, &dto.PrCreateRequest{PrId: "pr-2", PrName: "fix", AuthorId: "u3", Draft: true})
+		require.NoError(s.T(), err)
+
+		pr, err := prService.Get(s.ctx, "pr-1")
+		require.NoError(s.T(), err)
+		require.Len(s.T(), pr.AssignedReviewers, 1)
+		req := &dto.ReassignRequest{PrId: "pr-1", OldReviewerId: pr.AssignedReviewers[0]}
+
+		_, err = prService.Reassign(auth.WithPrincipal(s.ctx, &auth.Principal{UserId: "m2", Role: auth.RoleMember}), req)
+		assert.ErrorIs(s.T(), err, service.ErrForbidden)
+
+		resp, err := prService.Reassign(backendMember, req)
+		require.NoError(s.T(), err)
+		assert.NotEqual(s.T(), req.OldReviewerId, resp.NewReviewerId)
+	})
+
+	s.Run("members", func() {
+		active := false
+		_, err := userService.SetIsActive(mobileLead, &dto.SetIsActiveRequest{UserId: "u3", IsActive: &active})
+		assert.ErrorIs(s.T(), err, service.ErrForbidden)
+
+		//Leads take nobody from other teams
+		_, err = userService.Move(backendLead, &dto.UserMoveRequest{UserId: "m2", TeamName: "backend"})
+		assert.ErrorIs(s.T(), err, service.ErrForbidden)
+
+		resp, err := userService.Move(backendLead, &dto.UserMoveRequest{UserId: "u3", TeamName: "mobile"})
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), "mobile", resp.User.TeamName)
+	})
+}
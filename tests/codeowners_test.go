@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+	"slices"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	"github.com/Estriper0/avito_intership/internal/service"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestSuite) TestPullRequestService_CodeOwners() {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	trManager := manager.Must(trmpgx.NewDefaultFactory(s.db))
+	prService := s.newPullRequestService(trManager, logger)
+	teamService := service.NewTeamService(
+		db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter),
+		db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter),
+		db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter),
+		prService,
+		service.NewOutbox(db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter), db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter), 1, logger),
+		trManager,
+		logger,
+	)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name, min_reviewers, max_reviewers) VALUES (1, 'backend', 1, 2), (2, 'dba', 1, 2), (3, 'payments', 1, 2);
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true), ('u2', 'bob', 1, true), ('u3', 'carol', 1, true), ('u4', 'dan', 1, true),
+			('d1', 'erin', 2, true), ('d2', 'frank', 2, true),
+			('p1', 'grace', 3, true);
+	`)
+	require.NoError(s.T(), err)
+
+	s.Run("invalid rules", func() {
+		_, err := teamService.SetCodeOwners(s.ctx, &dto.CodeOwnersRequest{
+			TeamName: "backend",
+			Rules:    []dto.CodeOwnerRule{{Pattern: "/docs/"}},
+		})
+		assert.ErrorIs(s.T(), err, service.ErrInvalidCodeOwners)
+
+		_, err = teamService.SetCodeOwners(s.ctx, &dto.CodeOwnersRequest{
+			TeamName: "backend",
+			Rules:    []dto.CodeOwnerRule{{Pattern: "/docs/", Teams: []string{"ghosts"}}},
+		})
+		assert.ErrorIs(s.T(), err, service.ErrNotFound)
+
+		_, err = teamService.SetCodeOwners(s.ctx, &dto.CodeOwnersRequest{
+			TeamName: "backend",
+			Rules:    []dto.CodeOwnerRule{{Pattern: "/docs/", Users: []string{"ghost"}}},
+		})
+		assert.ErrorIs(s.T(), err, service.ErrNotFound)
+	})
+
+	rules, err := teamService.SetCodeOwners(s.ctx, &dto.CodeOwnersRequest{
+		TeamName: "backend",
+		Rules: []dto.CodeOwnerRule{
+			{Pattern: "*", Users: []string{"u2"}},
+			{Pattern: "/migrations/", Teams: []string{"dba"}},
+			{Pattern: "*.proto", Users: []string{"p1"}},
+		},
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), rules.Rules, 3)
+	assert.Equal(s.T(), []string{"dba"}, rules.Rules[1].Teams)
+
+	s.Run("owners of every matched rule are added", func() {
+		pr, err := prService.Create(s.ctx, &dto.PrCreateRequest{
+			PrId:         "pr-1",
+			PrName:       "schema",
+			AuthorId:     "u1",
+			ChangedFiles: []string{"migrations/0001_init.sql", "api/v1/service.proto"},
+		})
+		require.NoError(s.T(), err)
+		//Owners take all max_reviewers slots, the catch-all rule is overridden for both files
+		require.Len(s.T(), pr.AssignedReviewers, 2)
+		assert.Contains(s.T(), pr.AssignedReviewers, "p1")
+		assert.True(s.T(), slices.Contains(pr.AssignedReviewers, "d1") || slices.Contains(pr.AssignedReviewers, "d2"))
+		assert.ElementsMatch(s.T(), pr.AssignedReviewers, pr.ExternalReviewers)
+
+		history, err := prService.History(s.ctx, "pr-1")
+		require.NoError(s.T(), err)
+		for _, event := range history.Events {
+			if event.Type == models.EventAssigned {
+				assert.Equal(s.T(), models.ReasonCodeOwner, event.Reason)
+			}
+		}
+	})
+
+	s.Run("reassign keeps the ownership", func() {
+		details, err := prService.Get(s.ctx, "pr-1")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"api/v1/service.proto", "migrations/0001_init.sql"}, details.ChangedFiles)
+
+		dba, other := "d1", "d2"
+		if !slices.Contains(details.AssignedReviewers, dba) {
+			dba, other = other, dba
+		}
+
+		resp, err := prService.Reassign(s.ctx, &dto.ReassignRequest{PrId: "pr-1", OldReviewerId: dba})
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), other, resp.NewReviewerId)
+	})
+
+	s.Run("owners come before the team strategy", func() {
+		pr, err := prService.Create(s.ctx, &dto.PrCreateRequest{
+			PrId:         "pr-2",
+			PrName:       "feature",
+			AuthorId:     "u1",
+			ChangedFiles: []string{"tools/migrations/run.go"},
+		})
+		require.NoError(s.T(), err)
+		//The anchored rule does not match a nested directory, so the catch-all owner is taken
+		require.Len(s.T(), pr.AssignedReviewers, 2)
+		assert.Equal(s.T(), "u2", pr.AssignedReviewers[0])
+		assert.Empty(s.T(), pr.ExternalReviewers)
+	})
+
+	s.Run("draft gets owners when ready", func() {
+		_, err := prService.Create(s.ctx, &dto.PrCreateRequest{
+			PrId:         "pr-3",
+			PrName:       "draft",
+			AuthorId:     "u1",
+			Draft:        true,
+			ChangedFiles: []string{"proto/user.proto"},
+		})
+		require.NoError(s.T(), err)
+
+		pr, err := prService.Ready(s.ctx, "pr-3")
+		require.NoError(s.T(), err)
+		assert.Contains(s.T(), pr.AssignedReviewers, "p1")
+		assert.Equal(s.T(), []string{"p1"}, pr.ExternalReviewers)
+	})
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	"github.com/Estriper0/avito_intership/internal/service"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Users announced by the user.deactivated deliveries, in the order of the events
+func (s *TestSuite) deactivatedUsers() [][]string {
+	rows, err := s.db.Query(s.ctx, `SELECT payload FROM jobs WHERE type = $1 ORDER BY created_at, id`, models.JobWebhookDelivery)
+	require.NoError(s.T(), err)
+	defer rows.Close()
+
+	var events [][]string
+	for rows.Next() {
+		var payload []byte
+		require.NoError(s.T(), rows.Scan(&payload))
+
+		var delivery struct {
+			Event string `json:"event"`
+			Body  struct {
+				Data struct {
+					UsersId []string `json:"users_id"`
+				} `json:"data"`
+			} `json:"body"`
+		}
+		require.NoError(s.T(), json.Unmarshal(payload, &delivery))
+		if delivery.Event == models.WebhookUserDeactivated {
+			events = append(events, delivery.Body.Data.UsersId)
+		}
+	}
+	require.NoError(s.T(), rows.Err())
+	return events
+}
+
+func (s *TestSuite) TestUserService_DeactivationEvent() {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	trManager := manager.Must(trmpgx.NewDefaultFactory(s.db))
+	userService := service.NewUserService(
+		db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter),
+		db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter),
+		db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter),
+		s.newPullRequestService(trManager, logger),
+		service.NewOutbox(db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter), db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter), 1, logger),
+		trManager,
+		logger,
+	)
+
+	_, err := db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter).Create(s.ctx, &models.Webhook{
+		Url:      "http://ci.local/hook",
+		Secret:   "0123456789abcdef",
+		Events:   []string{models.WebhookUserDeactivated},
+		IsActive: true,
+	})
+	require.NoError(s.T(), err)
+	_, err = s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true), ('u2', 'bob', 1, true), ('u3', 'carol', 1, true);
+	`)
+	require.NoError(s.T(), err)
+
+	inactive, active := false, true
+
+	_, err = userService.SetIsActive(s.ctx, &dto.SetIsActiveRequest{UserId: "u1", IsActive: &inactive})
+	require.NoError(s.T(), err)
+	//Repeated deactivation and activation are not announced
+	_, err = userService.SetIsActive(s.ctx, &dto.SetIsActiveRequest{UserId: "u1", IsActive: &inactive})
+	require.NoError(s.T(), err)
+	_, err = userService.SetIsActive(s.ctx, &dto.SetIsActiveRequest{UserId: "u2", IsActive: &active})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), [][]string{{"u1"}}, s.deactivatedUsers())
+
+	//Only u2 and u3 were active, the response still lists every deactivated user
+	resp, err := userService.MassDeactivation(s.ctx, &dto.MassDeactivationRequest{UsersId: []string{"u1", "u2", "u3"}})
+	require.NoError(s.T(), err)
+	assert.ElementsMatch(s.T(), []string{"u1", "u2", "u3"}, resp.UsersId)
+	assert.Equal(s.T(), [][]string{{"u1"}, {"u2", "u3"}}, s.deactivatedUsers())
+
+	_, err = userService.MassDeactivation(s.ctx, &dto.MassDeactivationRequest{UsersId: []string{"u1", "u2"}})
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), s.deactivatedUsers(), 2)
+}
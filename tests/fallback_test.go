@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	"github.com/Estriper0/avito_intership/internal/service"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestSuite) newPullRequestService(trManager *manager.Manager, logger *slog.Logger) *service.PullRequestService {
+	userRepo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+	selectors, err := service.NewReviewerSelectors(config.ReviewConfig{Strategy: "random"}, userRepo)
+	require.NoError(s.T(), err)
+
+	outbox := service.NewOutbox(db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter), db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter), 1, logger)
+	return service.NewPullRequestService(
+		db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter),
+		userRepo,
+		db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter),
+		db.NewPrEventRepo(s.db, trmpgx.DefaultCtxGetter),
+		selectors,
+		outbox,
+		trManager,
+		logger,
+	)
+}
+
+func (s *TestSuite) TestPullRequestService_FallbackTeams() {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := s.newPullRequestService(manager.Must(trmpgx.NewDefaultFactory(s.db)), logger)
+	teamRepo := db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	//The backend has only one reviewer besides the author, the pool has one active member
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name, min_reviewers, max_reviewers) VALUES (1, 'backend', 2, 2), (2, 'pool', 1, 2), (3, 'empty', 1, 2);
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true), ('u2', 'bob', 1, true),
+			('p1', 'carol', 2, true), ('p2', 'dave', 2, false);
+	`)
+	require.NoError(s.T(), err)
+
+	s.Run("without fallbacks", func() {
+		pr, err := svc.Create(s.ctx, &dto.PrCreateRequest{PrId: "pr-1", PrName: "feature", AuthorId: "u1"})
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"u2"}, pr.AssignedReviewers)
+		assert.Empty(s.T(), pr.ExternalReviewers)
+		assert.True(s.T(), pr.UnderReviewed)
+	})
+
+	require.NoError(s.T(), teamRepo.SetFallbacks(s.ctx, 1, []int{3, 2}))
+
+	s.Run("create fills min_reviewers from fallbacks", func() {
+		pr, err := svc.Create(s.ctx, &dto.PrCreateRequest{PrId: "pr-2", PrName: "feature", AuthorId: "u1"})
+		require.NoError(s.T(), err)
+		assert.ElementsMatch(s.T(), []string{"u2", "p1"}, pr.AssignedReviewers)
+		assert.Equal(s.T(), []string{"p1"}, pr.ExternalReviewers)
+		assert.False(s.T(), pr.UnderReviewed)
+
+		history, err := svc.History(s.ctx, "pr-2")
+		require.NoError(s.T(), err)
+		var fallbackEvents int
+		for _, event := range history.Events {
+			if event.Type == models.EventAssigned && event.Reason == models.ReasonFallback {
+				assert.Equal(s.T(), "p1", event.ReviewerId)
+				fallbackEvents++
+			}
+		}
+		assert.Equal(s.T(), 1, fallbackEvents)
+	})
+
+	s.Run("reassign takes a fallback reviewer", func() {
+		_, err := s.db.Exec(s.ctx, `UPDATE users SET is_active = true WHERE user_id = 'p2'`)
+		require.NoError(s.T(), err)
+
+		resp, err := svc.Reassign(s.ctx, &dto.ReassignRequest{PrId: "pr-2", OldReviewerId: "u2"})
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), "p2", resp.NewReviewerId)
+		assert.Equal(s.T(), []string{"p1", "p2"}, resp.PR.ExternalReviewers)
+
+		details, err := svc.Get(s.ctx, "pr-2")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"p1", "p2"}, details.ExternalReviewers)
+	})
+
+	s.Run("home team is preferred", func() {
+		resp, err := svc.Reassign(s.ctx, &dto.ReassignRequest{PrId: "pr-2", OldReviewerId: "p1"})
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), "u2", resp.NewReviewerId)
+		assert.Equal(s.T(), []string{"p2"}, resp.PR.ExternalReviewers)
+	})
+
+	s.Run("no candidate anywhere", func() {
+		_, err := s.db.Exec(s.ctx, `UPDATE users SET is_active = false WHERE user_id = 'p1'`)
+		require.NoError(s.T(), err)
+
+		_, err = svc.Reassign(s.ctx, &dto.ReassignRequest{PrId: "pr-2", OldReviewerId: "p2"})
+		assert.ErrorIs(s.T(), err, service.ErrNoCandidate)
+	})
+}
@@ -0,0 +1,19 @@
+package tests
+
+import (
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestSuite) TestHealthRepo_Ping_GetMigrationVersion() {
+	repo := db.NewHealthRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	require.NoError(s.T(), repo.Ping(s.ctx))
+
+	version, dirty, err := repo.GetMigrationVersion(s.ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), uint(17), version)
+	assert.False(s.T(), dirty)
+}
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/Estriper0/avito_intership/internal/config"
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	"github.com/Estriper0/avito_intership/internal/service"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testGitHubSecret = "github-secret-0123456789"
+	testGitLabToken  = "gitlab-token-0123456789"
+)
+
+func (s *TestSuite) newIntegrationService() *service.IntegrationService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	trManager := manager.Must(trmpgx.NewDefaultFactory(s.db))
+
+	prService := s.newPullRequestService(trManager, logger)
+
+	return service.NewIntegrationService(
+		db.NewIntegrationRepo(s.db, trmpgx.DefaultCtxGetter),
+		db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter),
+		prService,
+		config.IntegrationsConfig{GitHubSecret: testGitHubSecret, GitLabToken: testGitLabToken},
+		trManager,
+		logger,
+	)
+}
+
+// Recorded payloads of the hostings are in testdata
+func (s *TestSuite) fixture(name string) []byte {
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(s.T(), err)
+	return body
+}
+
+func (s *TestSuite) githubDelivery(deliveryId string, name string) *dto.HostingDelivery {
+	body := s.fixture(name)
+	return &dto.HostingDelivery{
+		Provider:   models.ProviderGitHub,
+		DeliveryId: deliveryId,
+		Event:      "pull_request",
+		Signature:  "sha256=" + service.Sign(testGitHubSecret, body),
+		Body:       body,
+	}
+}
+
+func (s *TestSuite) TestIntegrationRepo_Identities() {
+	repo := db.NewIntegrationRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES ('u1', 'alice', 1, true), ('u2', 'bob', 1, true);
+	`)
+	require.NoError(s.T(), err)
+
+	_, err = repo.SetIdentity(s.ctx, &models.Identity{Provider: models.ProviderGitHub, Login: "alice-gh", UserId: "u1"})
+	require.NoError(s.T(), err)
+	_, err = repo.SetIdentity(s.ctx, &models.Identity{Provider: models.ProviderGitLab, Login: "alice", UserId: "u1"})
+	require.NoError(s.T(), err)
+
+	//Remapping the login
+	identity, err := repo.SetIdentity(s.ctx, &models.Identity{Provider: models.ProviderGitHub, Login: "alice-gh", UserId: "u2"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "u2", identity.UserId)
+
+	userId, err := repo.GetUserIdByLogin(s.ctx, models.ProviderGitHub, "alice-gh")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "u2", userId)
+
+	_, err = repo.GetUserIdByLogin(s.ctx, models.ProviderGitHub, "alice")
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+
+	_, err = repo.SetIdentity(s.ctx, &models.Identity{Provider: models.ProviderGitHub, Login: "ghost", UserId: "u9"})
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+
+	all, err := repo.GetIdentities(s.ctx, "")
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), all, 2)
+
+	github, err := repo.GetIdentities(s.ctx, models.ProviderGitHub)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), github, 1)
+	assert.Equal(s.T(), "alice-gh", github[0].Login)
+
+	require.NoError(s.T(), repo.DeleteIdentity(s.ctx, models.ProviderGitHub, "alice-gh"))
+	assert.ErrorIs(s.T(), repo.DeleteIdentity(s.ctx, models.ProviderGitHub, "alice-gh"), repository.ErrNotFound)
+}
+
+func (s *TestSuite) TestIntegrationService_GitHub() {
+	svc := s.newIntegrationService()
+	prRepo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES ('u1', 'alice', 1, true), ('u2', 'bob', 1, true), ('u3', 'carol', 1, true);
+	`)
+	require.NoError(s.T(), err)
+
+	s.Run("invalid signature", func() {
+		delivery := s.githubDelivery("d-1", "github_pull_request_opened.json")
+		delivery.Signature = "sha256=" + service.Sign("another-secret", delivery.Body)
+
+		_, err := svc.Receive(s.ctx, delivery)
+		assert.ErrorIs(s.T(), err, service.ErrInvalidSignature)
+	})
+
+	s.Run("login is not mapped", func() {
+		_, err := svc.Receive(s.ctx, s.githubDelivery("d-1", "github_pull_request_opened.json"))
+		assert.ErrorIs(s.T(), err, service.ErrIdentityNotMapped)
+
+		//The failed delivery is not recorded and may be redelivered
+		var count int
+		require.NoError(s.T(), s.db.QueryRow(s.ctx, `SELECT COUNT(*) FROM integration_deliveries`).Scan(&count))
+		assert.Zero(s.T(), count)
+	})
+
+	_, err = svc.SetIdentity(s.ctx, &dto.IdentityRequest{Provider: models.ProviderGitHub, Login: "octocat", UserId: "u1"})
+	require.NoError(s.T(), err)
+
+	s.Run("opened", func() {
+		resp, err := svc.Receive(s.ctx, s.githubDelivery("d-1", "github_pull_request_opened.json"))
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), &dto.HostingDeliveryResponse{Status: service.DeliveryProcessed, Action: "create", PrId: "gh-1296269-1347"}, resp)
+
+		pr, err := prRepo.GetById(s.ctx, "gh-1296269-1347")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), "Amazing new feature", pr.Name)
+		assert.Equal(s.T(), "u1", pr.AuthorId)
+		assert.Equal(s.T(), models.StatusOpen, pr.StatusId)
+	})
+
+	s.Run("replayed delivery", func() {
+		resp, err := svc.Receive(s.ctx, s.githubDelivery("d-1", "github_pull_request_opened.json"))
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), service.DeliveryDuplicate, resp.Status)
+	})
+
+	s.Run("same event in another delivery", func() {
+		resp, err := svc.Receive(s.ctx, s.githubDelivery("d-2", "github_pull_request_opened.json"))
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), service.DeliveryIgnored, resp.Status)
+	})
+
+	s.Run("merged without approvals", func() {
+		resp, err := svc.Receive(s.ctx, s.githubDelivery("d-3", "github_pull_request_closed_merged.json"))
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), service.DeliveryProcessed, resp.Status)
+		assert.Equal(s.T(), "merge", resp.Action)
+
+		pr, err := prRepo.GetById(s.ctx, "gh-1296269-1347")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), models.StatusMerged, pr.StatusId)
+		assert.True(s.T(), pr.ForceMerged)
+	})
+
+	s.Run("unsupported event", func() {
+		delivery := s.githubDelivery("d-4", "github_pull_request_opened.json")
+		delivery.Event = "push"
+
+		resp, err := svc.Receive(s.ctx, delivery)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), service.DeliveryIgnored, resp.Status)
+	})
+}
+
+func (s *TestSuite) TestIntegrationService_GitLab() {
+	svc := s.newIntegrationService()
+	prRepo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES ('u1', 'alice', 1, true), ('u2', 'bob', 1, true);
+	`)
+	require.NoError(s.T(), err)
+
+	_, err = svc.SetIdentity(s.ctx, &dto.IdentityRequest{Provider: models.ProviderGitLab, Login: "root", UserId: "u1"})
+	require.NoError(s.T(), err)
+
+	delivery := func(name string, token string) *dto.HostingDelivery {
+		return &dto.HostingDelivery{
+			Provider:  models.ProviderGitLab,
+			Event:     "Merge Request Hook",
+			Signature: token,
+			Body:      s.fixture(name),
+		}
+	}
+
+	_, err = svc.Receive(s.ctx, delivery("gitlab_merge_request_open.json", "wrong-token"))
+	assert.ErrorIs(s.T(), err, service.ErrInvalidSignature)
+
+	resp, err := svc.Receive(s.ctx, delivery("gitlab_merge_request_open.json", testGitLabToken))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), &dto.HostingDeliveryResponse{Status: service.DeliveryProcessed, Action: "create", PrId: "gl-1-1"}, resp)
+
+	//Without a delivery id the replay is still idempotent
+	resp, err = svc.Receive(s.ctx, delivery("gitlab_merge_request_open.json", testGitLabToken))
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), service.DeliveryIgnored, resp.Status)
+
+	for range 2 {
+		resp, err = svc.Receive(s.ctx, delivery("gitlab_merge_request_merge.json", testGitLabToken))
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), service.DeliveryProcessed, resp.Status)
+	}
+
+	pr, err := prRepo.GetById(s.ctx, "gl-1-1")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "MS-Viewport", pr.Name)
+	assert.Equal(s.T(), models.StatusMerged, pr.StatusId)
+}
@@ -0,0 +1,292 @@
+package tests
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestSuite) TestJobRepo_Create_GetById() {
+	repo := db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	jobId := uuid.NewString()
+	job, err := repo.Create(s.ctx, &models.Job{
+		Id:          jobId,
+		Type:        models.JobReassignTeam,
+		Payload:     json.RawMessage(`{"team_name":"team-1"}`),
+		MaxAttempts: 3,
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), jobId, job.Id)
+	assert.Equal(s.T(), models.JobStatusQueued, job.Status)
+	assert.Equal(s.T(), 0, job.Attempts)
+	assert.Equal(s.T(), 3, job.MaxAttempts)
+	assert.Empty(s.T(), job.TraceContext)
+
+	traced, err := repo.Create(s.ctx, &models.Job{
+		Id:           uuid.NewString(),
+		Type:         models.JobWebhookDelivery,
+		Payload:      json.RawMessage(`{}`),
+		MaxAttempts:  1,
+		TraceContext: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	})
+	require.NoError(s.T(), err)
+	stored, err := repo.GetById(s.ctx, traced.Id)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), traced.TraceContext, stored.TraceContext)
+
+	tests := []struct {
+		name    string
+		jobId   string
+		wantErr bool
+	}{
+		{name: "existing job", jobId: jobId},
+		{name: "not found", jobId: uuid.NewString(), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			job, err := repo.GetById(s.ctx, tt.jobId)
+
+			if tt.wantErr {
+				assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+				return
+			}
+
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), models.JobReassignTeam, job.Type)
+			assert.JSONEq(s.T(), `{"team_name":"team-1"}`, string(job.Payload))
+		})
+	}
+}
+
+func (s *TestSuite) TestJobRepo_Lease() {
+	repo := db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO jobs (id, type, payload, status, run_at, locked_until) VALUES
+			('00000000-0000-0000-0000-000000000001', 'reassign_team', '{}', 'queued', NOW() - INTERVAL '2 minute', NULL),
+			('00000000-0000-0000-0000-000000000002', 'reassign_team', '{}', 'running', NOW() - INTERVAL '1 minute', NOW() - INTERVAL '1 second'),
+			('00000000-0000-0000-0000-000000000003', 'reassign_team', '{}', 'running', NOW() - INTERVAL '3 minute', NOW() + INTERVAL '1 hour'),
+			('00000000-0000-0000-0000-000000000004', 'reassign_team', '{}', 'queued', NOW() + INTERVAL '1 hour', NULL),
+			('00000000-0000-0000-0000-000000000005', 'reassign_team', '{}', 'succeeded', NOW() - INTERVAL '5 minute', NULL);
+		INSERT INTO jobs (id, type, payload, status, attempts, max_attempts, run_at, locked_until) VALUES
+			('00000000-0000-0000-0000-000000000006', 'reassign_team', '{}', 'running', 3, 3, NOW() - INTERVAL '4 minute', NOW() - INTERVAL '1 second')
+	`)
+	require.NoError(s.T(), err)
+
+	//Due queued job first, then the job with the expired lease, others are skipped.
+	//The expired job without attempts left is not leased again.
+	tests := []struct {
+		name    string
+		wantId  string
+		wantErr bool
+	}{
+		{name: "queued job", wantId: "00000000-0000-0000-0000-000000000001"},
+		{name: "expired lease", wantId: "00000000-0000-0000-0000-000000000002"},
+		{name: "nothing to lease", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			job, err := repo.Lease(s.ctx, time.Minute)
+
+			if tt.wantErr {
+				assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+				return
+			}
+
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tt.wantId, job.Id)
+			assert.Equal(s.T(), models.JobStatusRunning, job.Status)
+			assert.Equal(s.T(), 1, job.Attempts)
+		})
+	}
+}
+
+func (s *TestSuite) TestJobRepo_FailExpired() {
+	repo := db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO jobs (id, type, payload, status, attempts, max_attempts, locked_until) VALUES
+			('00000000-0000-0000-0000-000000000001', 'reassign_team', '{}', 'running', 3, 3, NOW() - INTERVAL '1 second'),
+			('00000000-0000-0000-0000-000000000002', 'reassign_team', '{}', 'running', 2, 3, NOW() - INTERVAL '1 second'),
+			('00000000-0000-0000-0000-000000000003', 'reassign_team', '{}', 'running', 3, 3, NOW() + INTERVAL '1 hour'),
+			('00000000-0000-0000-0000-000000000004', 'reassign_team', '{}', 'queued', 3, 3, NULL)
+	`)
+	require.NoError(s.T(), err)
+
+	//Only the expired lease of the last attempt fails, the others may still finish or be retried
+	jobsId, err := repo.FailExpired(s.ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"00000000-0000-0000-0000-000000000001"}, jobsId)
+
+	job, err := repo.GetById(s.ctx, "00000000-0000-0000-0000-000000000001")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), models.JobStatusFailed, job.Status)
+	assert.Equal(s.T(), "lease expired on the last attempt", job.LastError)
+	assert.Equal(s.T(), 3, job.Attempts)
+
+	for _, jobId := range []string{"00000000-0000-0000-0000-000000000002", "00000000-0000-0000-0000-000000000003"} {
+		job, err := repo.GetById(s.ctx, jobId)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), models.JobStatusRunning, job.Status)
+	}
+
+	jobsId, err = repo.FailExpired(s.ctx)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), jobsId)
+}
+
+func (s *TestSuite) TestJobRepo_CountPending() {
+	repo := db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	counts, err := repo.CountPending(s.ctx)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), counts)
+
+	_, err = s.db.Exec(s.ctx, `
+		INSERT INTO jobs (id, type, payload, status) VALUES
+			('00000000-0000-0000-0000-000000000001', 'reassign_team', '{}', 'queued'),
+			('00000000-0000-0000-0000-000000000002', 'reassign_team', '{}', 'queued'),
+			('00000000-0000-0000-0000-000000000003', 'reassign_team', '{}', 'running'),
+			('00000000-0000-0000-0000-000000000004', 'webhook_delivery', '{}', 'queued'),
+			('00000000-0000-0000-0000-000000000005', 'webhook_delivery', '{}', 'succeeded'),
+			('00000000-0000-0000-0000-000000000006', 'webhook_delivery', '{}', 'failed')
+	`)
+	require.NoError(s.T(), err)
+
+	counts, err = repo.CountPending(s.ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []models.JobCount{
+		{Type: models.JobReassignTeam, Status: models.JobStatusQueued, Count: 2},
+		{Type: models.JobReassignTeam, Status: models.JobStatusRunning, Count: 1},
+		{Type: models.JobWebhookDelivery, Status: models.JobStatusQueued, Count: 1},
+	}, counts)
+}
+
+func (s *TestSuite) TestJobRepo_Complete_Retry_Fail() {
+	repo := db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	create := func() string {
+		job, err := repo.Create(s.ctx, &models.Job{Id: uuid.NewString(), Type: models.JobReassignTeam, Payload: json.RawMessage(`{}`), MaxAttempts: 5})
+		require.NoError(s.T(), err)
+		return job.Id
+	}
+	//Jobs are finished only by the worker holding the lease
+	lease := func() *models.Job {
+		jobId := create()
+		job, err := repo.Lease(s.ctx, time.Minute)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), jobId, job.Id)
+		return job
+	}
+
+	s.Run("complete", func() {
+		leased := lease()
+		jobId := leased.Id
+		err := repo.Complete(s.ctx, jobId, leased.Attempts, json.RawMessage(`[{"pull_request_id":"pr-1"}]`))
+		require.NoError(s.T(), err)
+
+		job, err := repo.GetById(s.ctx, jobId)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), models.JobStatusSucceeded, job.Status)
+		assert.JSONEq(s.T(), `[{"pull_request_id":"pr-1"}]`, string(job.Result))
+	})
+
+	s.Run("retry", func() {
+		leased := lease()
+		jobId := leased.Id
+		runAt := time.Now().Add(time.Hour)
+		err := repo.Retry(s.ctx, jobId, leased.Attempts, "temporary error", runAt)
+		require.NoError(s.T(), err)
+
+		job, err := repo.GetById(s.ctx, jobId)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), models.JobStatusQueued, job.Status)
+		assert.Equal(s.T(), "temporary error", job.LastError)
+		assert.WithinDuration(s.T(), runAt, job.RunAt, time.Second)
+	})
+
+	s.Run("fail", func() {
+		leased := lease()
+		jobId := leased.Id
+		err := repo.Fail(s.ctx, jobId, leased.Attempts, "permanent error")
+		require.NoError(s.T(), err)
+
+		job, err := repo.GetById(s.ctx, jobId)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), models.JobStatusFailed, job.Status)
+		assert.Equal(s.T(), "permanent error", job.LastError)
+	})
+
+	s.Run("progress", func() {
+		jobId := create()
+		err := repo.UpdateProgress(s.ctx, jobId, 3, 10)
+		require.NoError(s.T(), err)
+
+		job, err := repo.GetById(s.ctx, jobId)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), 3, job.ProgressProcessed)
+		assert.Equal(s.T(), 10, job.ProgressTotal)
+	})
+
+	s.Run("not leased", func() {
+		err := repo.Complete(s.ctx, uuid.NewString(), 1, json.RawMessage(`null`))
+		assert.ErrorIs(s.T(), err, repository.ErrLeaseLost)
+	})
+}
+
+// The first worker overran its lease and the job was leased again by the second one
+func (s *TestSuite) TestJobRepo_LeaseLost() {
+	repo := db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := repo.Create(s.ctx, &models.Job{Id: uuid.NewString(), Type: models.JobReassignTeam, Payload: json.RawMessage(`{}`), MaxAttempts: 5})
+	require.NoError(s.T(), err)
+
+	first, err := repo.Lease(s.ctx, time.Millisecond)
+	require.NoError(s.T(), err)
+	time.Sleep(10 * time.Millisecond)
+
+	second, err := repo.Lease(s.ctx, time.Minute)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), first.Id, second.Id)
+	require.Equal(s.T(), first.Attempts+1, second.Attempts)
+
+	//The first worker can neither prolong nor finish the job
+	err = repo.Extend(s.ctx, first.Id, first.Attempts, time.Minute)
+	assert.ErrorIs(s.T(), err, repository.ErrLeaseLost)
+	err = repo.Complete(s.ctx, first.Id, first.Attempts, json.RawMessage(`"first"`))
+	assert.ErrorIs(s.T(), err, repository.ErrLeaseLost)
+	err = repo.Retry(s.ctx, first.Id, first.Attempts, "first error", time.Now())
+	assert.ErrorIs(s.T(), err, repository.ErrLeaseLost)
+	err = repo.Fail(s.ctx, first.Id, first.Attempts, "first error")
+	assert.ErrorIs(s.T(), err, repository.ErrLeaseLost)
+
+	job, err := repo.GetById(s.ctx, first.Id)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), models.JobStatusRunning, job.Status)
+	assert.Empty(s.T(), job.LastError)
+
+	//The second worker owns the lease
+	err = repo.Extend(s.ctx, second.Id, second.Attempts, time.Minute)
+	require.NoError(s.T(), err)
+	err = repo.Complete(s.ctx, second.Id, second.Attempts, json.RawMessage(`"second"`))
+	require.NoError(s.T(), err)
+
+	job, err = repo.GetById(s.ctx, first.Id)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), models.JobStatusSucceeded, job.Status)
+	assert.JSONEq(s.T(), `"second"`, string(job.Result))
+
+	//A finished job has no lease to lose or to take
+	err = repo.Complete(s.ctx, second.Id, second.Attempts, json.RawMessage(`"again"`))
+	assert.ErrorIs(s.T(), err, repository.ErrLeaseLost)
+}
@@ -66,7 +66,7 @@ func (s *TestSuite) TearDownSuite() {
 }
 
 func (s *TestSuite) SetupTest() {
-	_, err := s.db.Exec(s.ctx, "TRUNCATE TABLE teams, users, pull_requests, pull_requests_reviewers CASCADE;")
+	_, err := s.db.Exec(s.ctx, "TRUNCATE TABLE teams, users, pull_requests, pull_requests_reviewers, pr_events, jobs, webhooks, webhook_deliveries, identity_mappings, integration_deliveries, team_fallbacks, code_owner_rules, pull_requests_files, user_absences CASCADE;")
 	s.Require().NoError(err)
 }
 
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestSuite) TestPrEventRepo_Add_GetByPrId() {
+	repo := db.NewPrEventRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES ('u1', 'alice', 1, true);
+		INSERT INTO pull_requests (pr_id, name, author_id) VALUES ('pr-1', 'pr-1', 'u1'), ('pr-2', 'pr-2', 'u1');
+	`)
+	require.NoError(s.T(), err)
+
+	err = repo.Add(s.ctx, []models.PrEvent{
+		{PrId: "pr-1", Type: models.EventCreated, Actor: "u1"},
+		{PrId: "pr-1", Type: models.EventAssigned, Actor: "u1", ReviewerId: "u2"},
+		{PrId: "pr-2", Type: models.EventCreated, Actor: "u1"},
+		{PrId: "pr-1", Type: models.EventReassigned, Actor: models.ActorSystem, ReviewerId: "u3", OldReviewerId: "u2", Reason: models.ReasonMass},
+	})
+	require.NoError(s.T(), err)
+
+	tests := []struct {
+		name string
+		prId string
+		want []models.PrEvent
+	}{
+		{
+			name: "timeline in order",
+			prId: "pr-1",
+			want: []models.PrEvent{
+				{PrId: "pr-1", Type: models.EventCreated, Actor: "u1"},
+				{PrId: "pr-1", Type: models.EventAssigned, Actor: "u1", ReviewerId: "u2"},
+				{PrId: "pr-1", Type: models.EventReassigned, Actor: models.ActorSystem, ReviewerId: "u3", OldReviewerId: "u2", Reason: models.ReasonMass},
+			},
+		},
+		{name: "no events", prId: "ghost"},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			events, err := repo.GetByPrId(s.ctx, tt.prId)
+			require.NoError(s.T(), err)
+			require.Len(s.T(), events, len(tt.want))
+
+			for i, event := range events {
+				assert.False(s.T(), event.CreatedAt.IsZero())
+				event.Id, event.CreatedAt = 0, tt.want[i].CreatedAt
+				assert.Equal(s.T(), tt.want[i], event)
+			}
+		})
+	}
+
+	s.Run("append only", func() {
+		_, err := s.db.Exec(s.ctx, `DELETE FROM pr_events WHERE pr_id = 'pr-2'`)
+		assert.Error(s.T(), err)
+
+		_, err = s.db.Exec(s.ctx, `UPDATE pr_events SET actor = 'u9'`)
+		assert.Error(s.T(), err)
+	})
+}
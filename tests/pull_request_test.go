@@ -147,7 +147,7 @@ func (s *TestSuite) TestPullRequestRepo_Merge() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			pr, err := repo.Merge(s.ctx, tt.prId)
+			pr, err := repo.Merge(s.ctx, tt.prId, false)
 
 			if tt.wantErr {
 				require.Error(s.T(), err)
@@ -157,7 +157,8 @@ func (s *TestSuite) TestPullRequestRepo_Merge() {
 
 			require.NoError(s.T(), err)
 			assert.Equal(s.T(), tt.wantStatus, pr.StatusId)
-			assert.WithinDuration(s.T(), time.Now(), pr.MergedAt, 5*time.Second)
+			require.NotNil(s.T(), pr.MergedAt)
+			assert.WithinDuration(s.T(), time.Now(), *pr.MergedAt, 5*time.Second)
 		})
 	}
 }
@@ -209,6 +210,8 @@ func (s *TestSuite) TestPullRequestRepo_GetStatusById() {
 	}{
 		{name: "open status", statusId: 1, want: "OPEN"},
 		{name: "merged status", statusId: 2, want: "MERGED"},
+		{name: "draft status", statusId: 3, want: "DRAFT"},
+		{name: "closed status", statusId: 4, want: "CLOSED"},
 		{name: "invalid id", statusId: 999, wantErr: true},
 	}
 
@@ -280,6 +283,44 @@ func (s *TestSuite) TestPullRequestRepo_UpdateReviewer() {
 	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
 }
 
+func (s *TestSuite) TestPullRequestRepo_UpdateReviewers() {
+	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true), ('u2', 'bob', 1, false), ('u3', 'carol', 1, false),
+			('u4', 'dave', 1, true), ('u5', 'erin', 1, true);
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id) VALUES ('pr-1', 'pr-1', 'u1', 1), ('pr-2', 'pr-2', 'u1', 1);
+		INSERT INTO pull_requests_reviewers (pr_id, user_id, decision, decided_at) VALUES
+			('pr-1', 'u2', 'COMMENTED', NOW()), ('pr-1', 'u3', NULL, NULL), ('pr-2', 'u2', NULL, NULL);
+	`)
+	require.NoError(s.T(), err)
+
+	err = repo.UpdateReviewers(s.ctx, []models.ReviewerChange{
+		{PrId: "pr-1", OldReviewerId: "u2", NewReviewerId: "u4"},
+		{PrId: "pr-1", OldReviewerId: "u3", NewReviewerId: "u5"},
+		{PrId: "pr-2", OldReviewerId: "u2", NewReviewerId: "u5"},
+	})
+	require.NoError(s.T(), err)
+
+	reviewers, err := repo.GetReviewersByPrIds(s.ctx, []string{"pr-1", "pr-2"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), map[string][]string{"pr-1": {"u4", "u5"}, "pr-2": {"u5"}}, reviewers)
+
+	//The new review starts without the decision of the old reviewer
+	reviews, err := repo.GetReviews(s.ctx, "pr-1")
+	require.NoError(s.T(), err)
+	for _, review := range reviews {
+		assert.Empty(s.T(), review.Decision)
+		assert.Nil(s.T(), review.DecidedAt)
+	}
+
+	require.NoError(s.T(), repo.UpdateReviewers(s.ctx, nil))
+	err = repo.UpdateReviewers(s.ctx, []models.ReviewerChange{{PrId: "pr-1", OldReviewerId: "ghost", NewReviewerId: "u1"}})
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+}
+
 func (s *TestSuite) TestPullRequestRepo_GetAllInactiveReviewersByTeam() {
 	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
 
@@ -302,5 +343,403 @@ func (s *TestSuite) TestPullRequestRepo_GetAllInactiveReviewersByTeam() {
 	reviewers, err := repo.GetAllInactiveReviewersByTeam(s.ctx, 1)
 	require.NoError(s.T(), err)
 
-	assert.Len(s.T(), reviewers, 2)	
+	assert.Len(s.T(), reviewers, 2)
+}
+
+func (s *TestSuite) TestPullRequestRepo_GetOpenReviewsByUsers() {
+	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1'), (2, 'test-team-2');
+
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 1, true),
+			('u3', 'charlie', 1, true),
+			('m1', 'dave', 2, true);
+
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id) VALUES
+			('pr-1', 'pr-1', 'u1', 1),
+			('pr-2', 'pr-2', 'u3', 1),
+			('pr-3', 'pr-3', 'u1', 2),
+			('pr-4', 'pr-4', 'm1', 1);
+
+		INSERT INTO pull_requests_reviewers (pr_id, user_id) VALUES
+			('pr-1', 'u2'),
+			('pr-1', 'u3'),
+			('pr-2', 'u2'),
+			('pr-3', 'u2'),
+			('pr-4', 'u2');
+	`)
+	require.NoError(s.T(), err)
+
+	tests := []struct {
+		name    string
+		usersId []string
+		teamId  int
+		want    []models.InactiveReviewers
+	}{
+		{
+			name:    "only open PRs",
+			usersId: []string{"u2"},
+			want: []models.InactiveReviewers{
+				{PrId: "pr-1", UserId: "u2", AuthorId: "u1"},
+				{PrId: "pr-2", UserId: "u2", AuthorId: "u3"},
+				{PrId: "pr-4", UserId: "u2", AuthorId: "m1"},
+			},
+		},
+		{
+			name:    "several users",
+			usersId: []string{"u2", "u3"},
+			want: []models.InactiveReviewers{
+				{PrId: "pr-1", UserId: "u2", AuthorId: "u1"},
+				{PrId: "pr-1", UserId: "u3", AuthorId: "u1"},
+				{PrId: "pr-2", UserId: "u2", AuthorId: "u3"},
+				{PrId: "pr-4", UserId: "u2", AuthorId: "m1"},
+			},
+		},
+		{
+			name:    "PRs of the team authors",
+			usersId: []string{"u2", "u3"},
+			teamId:  2,
+			want: []models.InactiveReviewers{
+				{PrId: "pr-4", UserId: "u2", AuthorId: "m1"},
+			},
+		},
+		{name: "no reviews", usersId: []string{"u1"}},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			reviews, err := repo.GetOpenReviewsByUsers(s.ctx, tt.usersId, tt.teamId)
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tt.want, reviews)
+		})
+	}
+}
+
+func (s *TestSuite) TestPullRequestRepo_SetDecision_GetReviews() {
+	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1');
+
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 1, true),
+			('u3', 'charlie', 1, true);
+
+		INSERT INTO pull_requests (pr_id, name, author_id) VALUES ('pr-1', 'pr-1', 'u1');
+
+		INSERT INTO pull_requests_reviewers (pr_id, user_id) VALUES
+			('pr-1', 'u2'),
+			('pr-1', 'u3');
+	`)
+	require.NoError(s.T(), err)
+
+	tests := []struct {
+		name     string
+		userId   string
+		decision string
+		wantErr  bool
+	}{
+		{name: "approve", userId: "u2", decision: models.DecisionApproved},
+		{name: "request changes", userId: "u3", decision: models.DecisionChangesRequested},
+		{name: "invalid decision", userId: "u3", decision: "LGTM", wantErr: true},
+		{name: "not assigned", userId: "u1", decision: models.DecisionApproved, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			review, err := repo.SetDecision(s.ctx, "pr-1", tt.userId, tt.decision)
+
+			if tt.wantErr {
+				require.Error(s.T(), err)
+				return
+			}
+
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tt.decision, review.Decision)
+			require.NotNil(s.T(), review.DecidedAt)
+		})
+	}
+
+	_, err = repo.SetDecision(s.ctx, "pr-1", "u1", models.DecisionApproved)
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+
+	reviews, err := repo.GetReviews(s.ctx, "pr-1")
+	require.NoError(s.T(), err)
+	decisions := make(map[string]string, len(reviews))
+	for _, review := range reviews {
+		decisions[review.UserId] = review.Decision
+	}
+	assert.Equal(s.T(), map[string]string{"u2": models.DecisionApproved, "u3": models.DecisionChangesRequested}, decisions)
+
+	//Reassigned reviewer starts without a decision
+	_, err = repo.UpdateReviewer(s.ctx, "pr-1", "u3", "u1")
+	require.NoError(s.T(), err)
+
+	reviews, err = repo.GetReviews(s.ctx, "pr-1")
+	require.NoError(s.T(), err)
+	for _, review := range reviews {
+		if review.UserId == "u1" {
+			assert.Empty(s.T(), review.Decision)
+			assert.Nil(s.T(), review.DecidedAt)
+		}
+	}
+}
+
+func (s *TestSuite) TestPullRequestRepo_MergeForce() {
+	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES ('u1', 'alice', 1, true);
+		INSERT INTO pull_requests (pr_id, name, author_id) VALUES ('pr-1', 'pr-1', 'u1');
+	`)
+	require.NoError(s.T(), err)
+
+	pr, err := repo.Merge(s.ctx, "pr-1", true)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), pr.ForceMerged)
+
+	//Repeated merge keeps the force flag
+	pr, err = repo.Merge(s.ctx, "pr-1", false)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), pr.ForceMerged)
+}
+
+func (s *TestSuite) TestPullRequestRepo_UpdateStatus() {
+	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES ('u1', 'alice', 1, true);
+	`)
+	require.NoError(s.T(), err)
+
+	pr, err := repo.Create(s.ctx, &models.PullRequest{PrId: "pr-1", Name: "draft", AuthorId: "u1", StatusId: models.StatusDraft})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), models.StatusDraft, pr.StatusId)
+
+	tests := []struct {
+		name       string
+		prId       string
+		statusId   int
+		wantErr    bool
+		wantClosed bool
+	}{
+		{name: "draft to open", prId: "pr-1", statusId: models.StatusOpen},
+		{name: "open to closed", prId: "pr-1", statusId: models.StatusClosed, wantClosed: true},
+		{name: "closed to open", prId: "pr-1", statusId: models.StatusOpen},
+		{name: "unknown status", prId: "pr-1", statusId: 999, wantErr: true},
+		{name: "not found", prId: "ghost", statusId: models.StatusOpen, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			pr, err := repo.UpdateStatus(s.ctx, tt.prId, tt.statusId)
+
+			if tt.wantErr {
+				assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+				return
+			}
+
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tt.statusId, pr.StatusId)
+
+			var closed bool
+			err = s.db.QueryRow(s.ctx, `SELECT closed_at IS NOT NULL FROM pull_requests WHERE pr_id = $1`, tt.prId).Scan(&closed)
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tt.wantClosed, closed)
+		})
+	}
+}
+
+func (s *TestSuite) TestPullRequestRepo_List() {
+	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'team-1'), (2, 'team-2');
+
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 1, true),
+			('u3', 'charlie', 2, true);
+
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id, created_at) VALUES
+			('pr-1', 'pr-1', 'u1', 1, '2025-01-01T10:00:00Z'),
+			('pr-2', 'pr-2', 'u1', 2, '2025-01-02T10:00:00Z'),
+			('pr-3', 'pr-3', 'u2', 1, '2025-01-03T10:00:00Z'),
+			('pr-4', 'pr-4', 'u3', 1, '2025-01-04T10:00:00Z');
+
+		INSERT INTO pull_requests_reviewers (pr_id, user_id) VALUES
+			('pr-1', 'u2'),
+			('pr-3', 'u1');
+	`)
+	require.NoError(s.T(), err)
+
+	from := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	after := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  *models.PullRequestFilter
+		wantIds []string
+	}{
+		{name: "all", filter: &models.PullRequestFilter{Limit: 10}, wantIds: []string{"pr-4", "pr-3", "pr-2", "pr-1"}},
+		{name: "by team", filter: &models.PullRequestFilter{TeamName: "team-1", Limit: 10}, wantIds: []string{"pr-3", "pr-2", "pr-1"}},
+		{name: "by author", filter: &models.PullRequestFilter{AuthorId: "u1", Limit: 10}, wantIds: []string{"pr-2", "pr-1"}},
+		{name: "by reviewer", filter: &models.PullRequestFilter{ReviewerId: "u1", Limit: 10}, wantIds: []string{"pr-3"}},
+		{name: "by status", filter: &models.PullRequestFilter{Status: "MERGED", Limit: 10}, wantIds: []string{"pr-2"}},
+		{name: "created from", filter: &models.PullRequestFilter{CreatedFrom: &from, Limit: 10}, wantIds: []string{"pr-4", "pr-3", "pr-2"}},
+		{name: "limit", filter: &models.PullRequestFilter{Limit: 2}, wantIds: []string{"pr-4", "pr-3"}},
+		{name: "after cursor", filter: &models.PullRequestFilter{AfterCreatedAt: &after, AfterPrId: "pr-3", Limit: 10}, wantIds: []string{"pr-2", "pr-1"}},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			prs, err := repo.List(s.ctx, tt.filter)
+			require.NoError(s.T(), err)
+
+			gotIds := make([]string, 0, len(prs))
+			for _, pr := range prs {
+				gotIds = append(gotIds, pr.PrId)
+			}
+			assert.Equal(s.T(), tt.wantIds, gotIds)
+		})
+	}
+
+	reviewers, err := repo.GetReviewersByPrIds(s.ctx, []string{"pr-1", "pr-2", "pr-3"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), map[string][]string{"pr-1": {"u2"}, "pr-3": {"u1"}}, reviewers)
+
+	s.Run("author without a team", func() {
+		_, err := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter).UpdateTeam(s.ctx, "u3", 0)
+		require.NoError(s.T(), err)
+
+		prs, err := repo.List(s.ctx, &models.PullRequestFilter{Limit: 10})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), prs, 4)
+		assert.Equal(s.T(), "pr-4", prs[0].PrId)
+
+		prs, err = repo.List(s.ctx, &models.PullRequestFilter{AuthorId: "u3", Limit: 10})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), prs, 1)
+		assert.Equal(s.T(), "pr-4", prs[0].PrId)
+
+		//The PR does not belong to the old team anymore
+		prs, err = repo.List(s.ctx, &models.PullRequestFilter{TeamName: "team-2", Limit: 10})
+		require.NoError(s.T(), err)
+		assert.Empty(s.T(), prs)
+	})
+}
+
+func (s *TestSuite) TestPullRequestRepo_CountUnfinishedByAuthors() {
+	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES ('u1', 'alice', 1, true), ('u2', 'bob', 1, true), ('u3', 'carol', 1, true);
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id) VALUES
+			('pr-1', 'open', 'u1', 1),
+			('pr-2', 'draft', 'u1', 3),
+			('pr-3', 'merged', 'u2', 2),
+			('pr-4', 'closed', 'u2', 4),
+			('pr-5', 'open', 'u3', 1);
+	`)
+	require.NoError(s.T(), err)
+
+	tests := []struct {
+		name    string
+		authors []string
+		want    int
+	}{
+		{name: "open and draft", authors: []string{"u1"}, want: 2},
+		{name: "only finished", authors: []string{"u2"}, want: 0},
+		{name: "several authors", authors: []string{"u1", "u2", "u3"}, want: 3},
+		{name: "no authors", authors: []string{}, want: 0},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			count, err := repo.CountUnfinishedByAuthors(s.ctx, tt.authors)
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tt.want, count)
+		})
+	}
+}
+
+func (s *TestSuite) TestPullRequestRepo_GetExternalReviewers() {
+	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1'), (2, 'test-team-2');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES ('u1', 'alice', 1, true), ('u2', 'bob', 1, true), ('u3', 'carol', 2, true), ('u4', 'dave', NULL, true);
+		INSERT INTO pull_requests (pr_id, name, author_id) VALUES ('pr-1', 'feature', 'u1'), ('pr-2', 'fix', 'u1');
+		INSERT INTO pull_requests_reviewers (pr_id, user_id) VALUES ('pr-1', 'u2'), ('pr-1', 'u3'), ('pr-1', 'u4'), ('pr-2', 'u2');
+	`)
+	require.NoError(s.T(), err)
+
+	external, err := repo.GetExternalReviewers(s.ctx, "pr-1")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"u3", "u4"}, external)
+
+	external, err = repo.GetExternalReviewers(s.ctx, "pr-2")
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), external)
+}
+
+func (s *TestSuite) TestPullRequestRepo_AddFiles_GetFiles() {
+	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES ('u1', 'alice', 1, true);
+		INSERT INTO pull_requests (pr_id, name, author_id) VALUES ('pr-1', 'feature', 'u1');
+	`)
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), repo.AddFiles(s.ctx, "pr-1", []string{"src/main.go", "README.md", "src/main.go"}))
+
+	files, err := repo.GetFiles(s.ctx, "pr-1")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"README.md", "src/main.go"}, files)
+
+	assert.ErrorIs(s.T(), repo.AddFiles(s.ctx, "pr-9", []string{"a.go"}), repository.ErrNotFound)
+
+	files, err = repo.GetFiles(s.ctx, "pr-9")
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), files)
+}
+
+func (s *TestSuite) TestPullRequestRepo_SlaBreaches() {
+	repo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name, review_sla_hours) VALUES (1, 'backend', 24);
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true), ('u2', 'bob', 1, true), ('u3', 'carol', 1, true);
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id) VALUES ('pr-1', 'pr-1', 'u1', 1), ('pr-2', 'pr-2', 'u1', 1);
+		INSERT INTO pull_requests_reviewers (pr_id, user_id, assigned_at) VALUES
+			('pr-1', 'u2', NOW() - INTERVAL '48 hours'), ('pr-1', 'u3', NOW() - INTERVAL '30 hours'),
+			('pr-2', 'u2', NOW() - INTERVAL '26 hours'), ('pr-2', 'u3', NOW() - INTERVAL '1 hour');
+	`)
+	require.NoError(s.T(), err)
+
+	//The oldest breaches come first, the rest wait for the next batch
+	breaches, err := repo.GetDueSlaBreaches(s.ctx, 2)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), breaches, 2)
+	assert.Equal(s.T(), "u2", breaches[0].ReviewerId)
+	assert.Equal(s.T(), "u3", breaches[1].ReviewerId)
+
+	require.NoError(s.T(), repo.MarkSlaBreached(s.ctx, &breaches[0]))
+	assert.ErrorIs(s.T(), repo.MarkSlaBreached(s.ctx, &breaches[0]), repository.ErrNotFound)
+
+	breaches, err = repo.GetDueSlaBreaches(s.ctx, 10)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), breaches, 2)
+	assert.Equal(s.T(), "pr-1", breaches[0].PrId)
+	assert.Equal(s.T(), "pr-2", breaches[1].PrId)
 }
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	"github.com/Estriper0/avito_intership/internal/service"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestSuite) TestPullRequestService_SweepSla() {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	trManager := manager.Must(trmpgx.NewDefaultFactory(s.db))
+	prService := s.newPullRequestService(trManager, logger)
+	teamService := service.NewTeamService(
+		db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter),
+		db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter),
+		db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter),
+		prService,
+		service.NewOutbox(db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter), db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter), 1, logger),
+		trManager,
+		logger,
+	)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name, min_reviewers, max_reviewers) VALUES (1, 'backend', 1, 1), (2, 'frontend', 1, 1);
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true), ('u2', 'bob', 1, true), ('u3', 'carol', 1, true);
+	`)
+	require.NoError(s.T(), err)
+
+	pr1, err := prService.Create(s.ctx, &dto.PrCreateRequest{PrId: "pr-1", PrName: "feature", AuthorId: "u1"})
+	require.NoError(s.T(), err)
+	pr2, err := prService.Create(s.ctx, &dto.PrCreateRequest{PrId: "pr-2", PrName: "fix", AuthorId: "u1"})
+	require.NoError(s.T(), err)
+
+	//Reviewers of pr-1 have been waiting for a day
+	overdue := func(prId string) {
+		_, err := s.db.Exec(s.ctx, `UPDATE pull_requests_reviewers SET assigned_at = NOW() - INTERVAL '25 hours' WHERE pr_id = $1`, prId)
+		require.NoError(s.T(), err)
+	}
+	overdue("pr-1")
+
+	s.Run("no sla", func() {
+		require.NoError(s.T(), prService.SweepSla(s.ctx))
+
+		report, err := teamService.SlaReport(s.ctx, &dto.SlaReportRequest{TeamName: "backend"})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), report, 1)
+		assert.Zero(s.T(), report[0].Breaches)
+		assert.Empty(s.T(), report[0].OpenBreaches)
+	})
+
+	one, slaHours := 1, 24
+	settings, err := teamService.UpdateSettings(s.ctx, &dto.TeamSettingsRequest{
+		TeamName:       "backend",
+		MinReviewers:   &one,
+		MaxReviewers:   &one,
+		ReviewSlaHours: &slaHours,
+	})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 24, settings.ReviewSlaHours)
+	assert.False(s.T(), settings.SlaAutoReassign)
+
+	s.Run("breach is marked once", func() {
+		require.NoError(s.T(), prService.SweepSla(s.ctx))
+		require.NoError(s.T(), prService.SweepSla(s.ctx))
+
+		history, err := prService.History(s.ctx, "pr-1")
+		require.NoError(s.T(), err)
+		var breached []string
+		for _, event := range history.Events {
+			if event.Type == models.EventSlaBreached {
+				breached = append(breached, event.ReviewerId)
+			}
+		}
+		assert.Equal(s.T(), pr1.AssignedReviewers, breached)
+
+		pr, err := prService.Get(s.ctx, "pr-1")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), pr1.AssignedReviewers, pr.AssignedReviewers)
+	})
+
+	autoReassign := true
+	_, err = teamService.UpdateSettings(s.ctx, &dto.TeamSettingsRequest{
+		TeamName:        "backend",
+		MinReviewers:    &one,
+		MaxReviewers:    &one,
+		SlaAutoReassign: &autoReassign,
+	})
+	require.NoError(s.T(), err)
+	overdue("pr-2")
+
+	s.Run("breach is reassigned", func() {
+		require.NoError(s.T(), prService.SweepSla(s.ctx))
+
+		pr, err := prService.Get(s.ctx, "pr-2")
+		require.NoError(s.T(), err)
+		require.Len(s.T(), pr.AssignedReviewers, 1)
+		assert.NotEqual(s.T(), pr2.AssignedReviewers[0], pr.AssignedReviewers[0])
+
+		history, err := prService.History(s.ctx, "pr-2")
+		require.NoError(s.T(), err)
+		last := history.Events[len(history.Events)-1]
+		assert.Equal(s.T(), models.EventReassigned, last.Type)
+		assert.Equal(s.T(), models.ReasonSla, last.Reason)
+	})
+
+	s.Run("report", func() {
+		report, err := teamService.SlaReport(s.ctx, &dto.SlaReportRequest{})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), report, 2)
+
+		assert.Equal(s.T(), "backend", report[0].TeamName)
+		assert.Equal(s.T(), 24, report[0].ReviewSlaHours)
+		assert.Equal(s.T(), 2, report[0].Breaches)
+		assert.Equal(s.T(), 1, report[0].Reassigned)
+		require.Len(s.T(), report[0].OpenBreaches, 1)
+		assert.Equal(s.T(), "pr-1", report[0].OpenBreaches[0].PrId)
+
+		assert.Equal(s.T(), "frontend", report[1].TeamName)
+		assert.Zero(s.T(), report[1].Breaches)
+
+		_, err = teamService.SlaReport(s.ctx, &dto.SlaReportRequest{TeamName: "ghosts"})
+		assert.ErrorIs(s.T(), err, service.ErrNotFound)
+	})
+}
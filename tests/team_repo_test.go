@@ -1,6 +1,8 @@
 package tests
 
 import (
+	"time"
+
 	"github.com/Estriper0/avito_intership/internal/models"
 	"github.com/Estriper0/avito_intership/internal/repository"
 	"github.com/Estriper0/avito_intership/internal/repository/db"
@@ -123,3 +125,256 @@ func (s *TestSuite) TestTeamRepo_GetNameById() {
 		})
 	}
 }
+
+func (s *TestSuite) TestTeamRepo_GetByName_UpdateSettings() {
+	repo := db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := repo.Create(s.ctx, &models.Team{Name: "team_1", MinReviewers: 1, MaxReviewers: 2})
+	require.NoError(s.T(), err)
+
+	team, err := repo.GetByName(s.ctx, "team_1")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, team.MinReviewers)
+	assert.Equal(s.T(), 2, team.MaxReviewers)
+
+	tests := []struct {
+		name      string
+		team      *models.Team
+		wantErr   bool
+		wantErrIs error
+	}{
+		{name: "update settings", team: &models.Team{Name: "team_1", MinReviewers: 2, MaxReviewers: 3}},
+		{name: "review sla", team: &models.Team{Name: "team_1", MinReviewers: 1, MaxReviewers: 2, ReviewSlaHours: 24, SlaAutoReassign: true}},
+		{name: "negative sla", team: &models.Team{Name: "team_1", MinReviewers: 1, MaxReviewers: 2, ReviewSlaHours: -1}, wantErr: true},
+		{name: "min greater than max", team: &models.Team{Name: "team_1", MinReviewers: 3, MaxReviewers: 1}, wantErr: true},
+		{name: "not found", team: &models.Team{Name: "unknown-team", MinReviewers: 1, MaxReviewers: 1}, wantErr: true, wantErrIs: repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			got, err := repo.UpdateSettings(s.ctx, tt.team)
+
+			if tt.wantErr {
+				require.Error(s.T(), err)
+				if tt.wantErrIs != nil {
+					assert.ErrorIs(s.T(), err, tt.wantErrIs)
+				}
+				return
+			}
+
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), tt.team.MinReviewers, got.MinReviewers)
+			assert.Equal(s.T(), tt.team.MaxReviewers, got.MaxReviewers)
+			assert.Equal(s.T(), tt.team.ReviewSlaHours, got.ReviewSlaHours)
+			assert.Equal(s.T(), tt.team.SlaAutoReassign, got.SlaAutoReassign)
+
+			//Checking GetById
+			byId, err := repo.GetById(s.ctx, got.Id)
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), got, byId)
+		})
+	}
+}
+
+func (s *TestSuite) TestTeamRepo_GetStatsPRByName() {
+	repo := db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name, min_reviewers, max_reviewers) VALUES (1, 'team_1', 1, 2);
+
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 1, true);
+
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id) VALUES
+			('pr-1', 'pr-1', 'u1', 1),
+			('pr-2', 'pr-2', 'u1', 1),
+			('pr-3', 'pr-3', 'u1', 2);
+
+		INSERT INTO pull_requests_reviewers (pr_id, user_id) VALUES ('pr-1', 'u2');
+	`)
+	require.NoError(s.T(), err)
+
+	stats, err := repo.GetStatsPRByName(s.ctx, "team_1", nil, nil)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 3, stats.TotalPr)
+	assert.Equal(s.T(), 2, stats.OpenPr)
+	assert.Equal(s.T(), 1, stats.MergedPr)
+	assert.Equal(s.T(), 1, stats.UnderReviewedPr)
+
+	_, err = repo.GetStatsPRByName(s.ctx, "unknown-team", nil, nil)
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+}
+
+func (s *TestSuite) TestTeamRepo_LeadTimes_MergedPerWeek_ReviewLoad() {
+	repo := db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name, min_reviewers, max_reviewers) VALUES (1, 'team_1', 1, 2);
+
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 1, true),
+			('u3', 'carol', 1, true);
+
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id, created_at, merged_at) VALUES
+			('pr-1', 'pr-1', 'u1', 2, '2025-01-06 10:00:00+00', '2025-01-06 12:00:00+00'),
+			('pr-2', 'pr-2', 'u1', 2, '2025-01-07 10:00:00+00', '2025-01-07 14:00:00+00'),
+			('pr-3', 'pr-3', 'u1', 2, '2025-01-20 10:00:00+00', '2025-01-20 20:00:00+00'),
+			('pr-4', 'pr-4', 'u1', 1, '2025-02-03 10:00:00+00', NULL);
+
+		INSERT INTO pull_requests_reviewers (pr_id, user_id) VALUES
+			('pr-1', 'u2'), ('pr-2', 'u2'), ('pr-4', 'u2'), ('pr-3', 'u3');
+
+		INSERT INTO pr_events (pr_id, type, actor, reviewer_id, created_at) VALUES
+			('pr-1', 'reviewed', 'u2', 'u2', '2025-01-06 11:00:00+00'),
+			('pr-1', 'reviewed', 'u2', 'u2', '2025-01-06 11:30:00+00'),
+			('pr-2', 'reviewed', 'u2', 'u2', '2025-01-07 13:00:00+00');
+	`)
+	require.NoError(s.T(), err)
+
+	s.Run("lead times", func() {
+		times, err := repo.GetLeadTimes(s.ctx, 1, nil, nil)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), 3, times.MergedCount)
+		require.NotNil(s.T(), times.MergeMedian)
+		assert.InDelta(s.T(), 4*3600, *times.MergeMedian, 1)
+		assert.Equal(s.T(), 2, times.ReviewedCount)
+		require.NotNil(s.T(), times.FirstReviewMedian)
+		assert.InDelta(s.T(), 2*3600, *times.FirstReviewMedian, 1)
+
+		//Nothing is merged in the window
+		from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		times, err = repo.GetLeadTimes(s.ctx, 1, &from, nil)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), 0, times.MergedCount)
+		assert.Nil(s.T(), times.MergeMedian)
+		assert.Nil(s.T(), times.FirstReviewP90)
+	})
+
+	s.Run("merged per week", func() {
+		weeks, err := repo.GetMergedPerWeek(s.ctx, 1, nil, nil)
+		require.NoError(s.T(), err)
+		require.Len(s.T(), weeks, 3)
+		assert.True(s.T(), weeks[0].Week.Equal(time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(s.T(), []int{2, 0, 1}, []int{weeks[0].Count, weeks[1].Count, weeks[2].Count})
+
+		from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		weeks, err = repo.GetMergedPerWeek(s.ctx, 1, &from, nil)
+		require.NoError(s.T(), err)
+		assert.Empty(s.T(), weeks)
+	})
+
+	s.Run("review load", func() {
+		load, err := repo.GetReviewLoad(s.ctx, 1, nil, nil)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), []models.ReviewLoad{
+			{UserId: "u2", Username: "bob", Reviews: 3, OpenReviews: 1},
+			{UserId: "u3", Username: "carol", Reviews: 1, OpenReviews: 0},
+			{UserId: "u1", Username: "alice", Reviews: 0, OpenReviews: 0},
+		}, load)
+
+		to := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+		load, err = repo.GetReviewLoad(s.ctx, 1, nil, &to)
+		require.NoError(s.T(), err)
+		require.Len(s.T(), load, 3)
+		assert.Equal(s.T(), 2, load[0].Reviews)
+		assert.Equal(s.T(), 0, load[1].Reviews)
+	})
+}
+
+func (s *TestSuite) TestTeamRepo_Rename_Delete() {
+	repo := db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend'), (2, 'frontend');
+	`)
+	require.NoError(s.T(), err)
+
+	team, err := repo.Rename(s.ctx, "backend", "platform")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, team.Id)
+	assert.Equal(s.T(), "platform", team.Name)
+
+	_, err = repo.Rename(s.ctx, "platform", "frontend")
+	assert.ErrorIs(s.T(), err, repository.ErrAlreadyExists)
+
+	_, err = repo.Rename(s.ctx, "backend", "mobile")
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+
+	require.NoError(s.T(), repo.Delete(s.ctx, 2))
+	_, err = repo.GetById(s.ctx, 2)
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+	assert.ErrorIs(s.T(), repo.Delete(s.ctx, 2), repository.ErrNotFound)
+}
+
+func (s *TestSuite) TestTeamRepo_SetFallbacks_GetFallbacks() {
+	repo := db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend'), (2, 'frontend'), (3, 'reviewers-pool');
+	`)
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), repo.SetFallbacks(s.ctx, 1, []int{3, 2}))
+
+	fallbacks, err := repo.GetFallbacks(s.ctx, 1)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), fallbacks, 2)
+	assert.Equal(s.T(), "reviewers-pool", fallbacks[0].Name)
+	assert.Equal(s.T(), "frontend", fallbacks[1].Name)
+
+	//The list is replaced
+	require.NoError(s.T(), repo.SetFallbacks(s.ctx, 1, []int{2}))
+	fallbacks, err = repo.GetFallbacks(s.ctx, 1)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), fallbacks, 1)
+	assert.Equal(s.T(), 2, fallbacks[0].Id)
+
+	assert.ErrorIs(s.T(), repo.SetFallbacks(s.ctx, 1, []int{9}), repository.ErrNotFound)
+
+	//Deleting the fallback team removes it from the list
+	require.NoError(s.T(), repo.Delete(s.ctx, 2))
+	fallbacks, err = repo.GetFallbacks(s.ctx, 1)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), fallbacks)
+
+	require.NoError(s.T(), repo.SetFallbacks(s.ctx, 1, nil))
+}
+
+func (s *TestSuite) TestTeamRepo_SetCodeOwners_GetCodeOwners() {
+	repo := db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend'), (2, 'dba'), (3, 'sre');
+	`)
+	require.NoError(s.T(), err)
+
+	rules := []models.CodeOwnerRule{
+		{Pattern: "*", UsersId: []string{"u1"}, TeamsId: []int{}},
+		{Pattern: "/migrations/", UsersId: []string{}, TeamsId: []int{3, 2}},
+	}
+	require.NoError(s.T(), repo.SetCodeOwners(s.ctx, 1, rules))
+
+	got, err := repo.GetCodeOwners(s.ctx, 1)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), got, 2)
+	assert.Equal(s.T(), "*", got[0].Pattern)
+	assert.Equal(s.T(), []string{"u1"}, got[0].UsersId)
+	assert.Empty(s.T(), got[0].TeamNames)
+	assert.Equal(s.T(), "/migrations/", got[1].Pattern)
+	assert.Equal(s.T(), []int{3, 2}, got[1].TeamsId)
+	assert.Equal(s.T(), []string{"dba", "sre"}, got[1].TeamNames)
+
+	//The rules are replaced
+	require.NoError(s.T(), repo.SetCodeOwners(s.ctx, 1, rules[1:]))
+	got, err = repo.GetCodeOwners(s.ctx, 1)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), got, 1)
+	assert.Equal(s.T(), "/migrations/", got[0].Pattern)
+
+	require.NoError(s.T(), repo.SetCodeOwners(s.ctx, 1, nil))
+	got, err = repo.GetCodeOwners(s.ctx, 1)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), got)
+}
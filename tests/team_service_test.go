@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/Estriper0/avito_intership/internal/handlers/dto"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	"github.com/Estriper0/avito_intership/internal/service"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/avito-tech/go-transaction-manager/trm/v2/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestSuite) TestTeamService_Add_UserInOtherTeam() {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	trManager := manager.Must(trmpgx.NewDefaultFactory(s.db))
+	prService := s.newPullRequestService(trManager, logger)
+	userRepo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+	teamRepo := db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter)
+	prRepo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+	outbox := service.NewOutbox(db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter), db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter), 1, logger)
+	teamService := service.NewTeamService(teamRepo, userRepo, prRepo, prService, outbox, trManager, logger)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (name) VALUES ('backend');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', (SELECT id FROM teams WHERE name = 'backend'), true), ('u2', 'bob', NULL, false);
+	`)
+	require.NoError(s.T(), err)
+	backendId, err := teamRepo.GetIdByName(s.ctx, "backend")
+	require.NoError(s.T(), err)
+
+	_, err = teamService.Add(s.ctx, &dto.Team{
+		TeamName: "mobile",
+		Members: []dto.Members{
+			{UserId: "m1", Username: "dave", IsActive: true},
+			{UserId: "u1", Username: "alice", IsActive: false},
+		},
+	})
+	assert.ErrorIs(s.T(), err, service.ErrUserInOtherTeam)
+
+	//Nothing is changed: the team is not created and the member stays where and how it was
+	_, err = teamRepo.GetIdByName(s.ctx, "mobile")
+	assert.Error(s.T(), err)
+	user, err := userRepo.GetById(s.ctx, "u1")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), backendId, user.TeamId)
+	assert.True(s.T(), user.IsActive)
+
+	//Users without a team may join a new one
+	teamId, err := teamService.Add(s.ctx, &dto.Team{
+		TeamName: "mobile",
+		Members: []dto.Members{
+			{UserId: "m1", Username: "dave", IsActive: true},
+			{UserId: "u2", Username: "bob", IsActive: true},
+		},
+	})
+	require.NoError(s.T(), err)
+	user, err = userRepo.GetById(s.ctx, "u2")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), teamId, user.TeamId)
+}
+
+func (s *TestSuite) TestUserService_Move_KeepsOtherTeamReviews() {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	trManager := manager.Must(trmpgx.NewDefaultFactory(s.db))
+	prService := s.newPullRequestService(trManager, logger)
+	userRepo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+	teamRepo := db.NewTeamRepo(s.db, trmpgx.DefaultCtxGetter)
+	prRepo := db.NewPullRequestRepo(s.db, trmpgx.DefaultCtxGetter)
+	outbox := service.NewOutbox(db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter), db.NewJobRepo(s.db, trmpgx.DefaultCtxGetter), 1, logger)
+	userService := service.NewUserService(userRepo, teamRepo, prRepo, prService, outbox, trManager, logger)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend'), (2, 'mobile'), (3, 'platform');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true), ('u2', 'bob', 1, true), ('u3', 'carol', 1, true),
+			('m1', 'dave', 2, true), ('m2', 'erin', 2, true);
+
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id) VALUES
+			('pr-backend', 'pr-backend', 'u1', 1),
+			('pr-mobile', 'pr-mobile', 'm1', 1);
+		--Bob reviews the mobile PR as a code owner
+		INSERT INTO pull_requests_reviewers (pr_id, user_id) VALUES
+			('pr-backend', 'u2'),
+			('pr-mobile', 'u2');
+	`)
+	require.NoError(s.T(), err)
+
+	resp, err := userService.Move(s.ctx, &dto.UserMoveRequest{UserId: "u2", TeamName: "platform", Reassign: true})
+	require.NoError(s.T(), err)
+
+	//Only the review of the old team's PR goes to the old team
+	require.Len(s.T(), resp.Reassignments, 1)
+	assert.Equal(s.T(), "pr-backend", resp.Reassignments[0].PrId)
+	assert.Equal(s.T(), "u3", resp.Reassignments[0].NewReviewerId)
+
+	reviewers, err := prRepo.GetReviewersByPrIds(s.ctx, []string{"pr-backend", "pr-mobile"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), map[string][]string{"pr-backend": {"u3"}, "pr-mobile": {"u2"}}, reviewers)
+}
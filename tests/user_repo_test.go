@@ -2,6 +2,7 @@ package tests
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/Estriper0/avito_intership/internal/models"
 	"github.com/Estriper0/avito_intership/internal/repository"
@@ -290,3 +291,329 @@ func (s *TestSuite) TestUserRepo_MassDeactivation() {
 		})
 	}
 }
+
+func (s *TestSuite) TestUserRepo_GetById() {
+	repo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	//Adding a team
+	_, err := s.db.Exec(s.ctx, `INSERT INTO teams (id, name) VALUES (1, 'test-team-1')`)
+	s.Require().NoError(err)
+
+	_, err = repo.CreateOrUpdate(s.ctx, &models.User{UserId: "u1", Username: "alice", TeamId: 1, IsActive: true})
+	require.NoError(s.T(), err)
+
+	user, err := repo.GetById(s.ctx, "u1")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "alice", user.Username)
+	assert.Equal(s.T(), 1, user.TeamId)
+	assert.True(s.T(), user.IsActive)
+
+	_, err = repo.GetById(s.ctx, "ghost")
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+}
+
+func (s *TestSuite) TestUserRepo_CountOpenReviews() {
+	repo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'test-team-1');
+
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 1, true),
+			('u3', 'charlie', 1, true);
+
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id) VALUES
+			('pr-1', 'pr-1', 'u1', 1),
+			('pr-2', 'pr-2', 'u1', 1),
+			('pr-3', 'pr-3', 'u1', 2);
+
+		INSERT INTO pull_requests_reviewers (pr_id, user_id) VALUES
+			('pr-1', 'u2'),
+			('pr-2', 'u2'),
+			('pr-3', 'u3');
+	`)
+	require.NoError(s.T(), err)
+
+	counts, err := repo.CountOpenReviews(s.ctx, []string{"u1", "u2", "u3"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), map[string]int{"u1": 0, "u2": 2, "u3": 0}, counts)
+}
+
+func (s *TestSuite) TestUserRepo_UpdateTeam_ClearTeam() {
+	repo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend'), (2, 'frontend');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 1, true),
+			('u3', 'carol', 2, true);
+	`)
+	require.NoError(s.T(), err)
+
+	user, err := repo.UpdateTeam(s.ctx, "u1", 2)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, user.TeamId)
+
+	//Without a team
+	user, err = repo.UpdateTeam(s.ctx, "u2", 0)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 0, user.TeamId)
+
+	user, err = repo.GetById(s.ctx, "u2")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 0, user.TeamId)
+
+	_, err = repo.UpdateTeam(s.ctx, "ghost", 1)
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+
+	require.NoError(s.T(), repo.ClearTeam(s.ctx, 2))
+	users, err := repo.GetAllByTeam(s.ctx, 2)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), users)
+
+	user, err = repo.UpdateIsActive(s.ctx, "u3", false)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 0, user.TeamId)
+}
+
+func (s *TestSuite) TestUserRepo_GetByIdsOrTeams() {
+	repo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend'), (2, 'frontend');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 2, false),
+			('u3', 'carol', 2, true),
+			('u4', 'dave', NULL, true);
+	`)
+	require.NoError(s.T(), err)
+
+	tests := []struct {
+		name    string
+		usersId []string
+		teamsId []int
+		want    []string
+	}{
+		{name: "users and teams", usersId: []string{"u1", "u4"}, teamsId: []int{2}, want: []string{"u1", "u2", "u3", "u4"}},
+		{name: "only users", usersId: []string{"u3", "ghost"}, want: []string{"u3"}},
+		{name: "only teams", usersId: []string{}, teamsId: []int{1}, want: []string{"u1"}},
+		{name: "nothing", want: nil},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			users, err := repo.GetByIdsOrTeams(s.ctx, tt.usersId, tt.teamsId)
+			require.NoError(s.T(), err)
+			var got []string
+			for _, user := range users {
+				got = append(got, user.UserId)
+			}
+			assert.Equal(s.T(), tt.want, got)
+		})
+	}
+}
+
+func (s *TestSuite) TestUserRepo_Absences() {
+	repo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 1, true),
+			('u3', 'carol', 1, true);
+	`)
+	require.NoError(s.T(), err)
+
+	now := time.Now()
+	current, err := repo.AddAbsence(s.ctx, &models.Absence{UserId: "u2", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Reason: "vacation"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "vacation", current.Reason)
+	assert.Nil(s.T(), current.ReassignedAt)
+
+	upcoming, err := repo.AddAbsence(s.ctx, &models.Absence{UserId: "u3", StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)})
+	require.NoError(s.T(), err)
+	_, err = repo.AddAbsence(s.ctx, &models.Absence{UserId: "u3", StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)})
+	require.NoError(s.T(), err)
+
+	s.Run("unknown user", func() {
+		_, err := repo.AddAbsence(s.ctx, &models.Absence{UserId: "ghost", StartsAt: now, EndsAt: now.Add(time.Hour)})
+		assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+	})
+
+	s.Run("finished absences are omitted", func() {
+		absences, err := repo.GetAbsences(s.ctx, "u3")
+		require.NoError(s.T(), err)
+		require.Len(s.T(), absences, 1)
+		assert.Equal(s.T(), upcoming.Id, absences[0].Id)
+	})
+
+	s.Run("only current absences make users absent", func() {
+		absentId, err := repo.GetAbsentIds(s.ctx, []string{"u1", "u2", "u3"})
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"u2"}, absentId)
+
+		members, err := repo.GetActiveTeamMembersById(s.ctx, "u1")
+		require.NoError(s.T(), err)
+		require.Len(s.T(), members, 1)
+		assert.Equal(s.T(), "u3", members[0].UserId)
+	})
+
+	s.Run("started absences are marked once", func() {
+		started, err := repo.GetStartedAbsences(s.ctx, 10)
+		require.NoError(s.T(), err)
+		require.Len(s.T(), started, 1)
+		assert.Equal(s.T(), current.Id, started[0].Id)
+
+		require.NoError(s.T(), repo.MarkAbsencesReassigned(s.ctx, []int{current.Id}))
+		assert.ErrorIs(s.T(), repo.MarkAbsencesReassigned(s.ctx, []int{current.Id}), repository.ErrNotFound)
+		started, err = repo.GetStartedAbsences(s.ctx, 10)
+		require.NoError(s.T(), err)
+		assert.Empty(s.T(), started)
+	})
+
+	s.Run("delete", func() {
+		assert.ErrorIs(s.T(), repo.DeleteAbsence(s.ctx, "u1", current.Id), repository.ErrNotFound)
+		require.NoError(s.T(), repo.DeleteAbsence(s.ctx, "u2", current.Id))
+
+		absentId, err := repo.GetAbsentIds(s.ctx, []string{"u2"})
+		require.NoError(s.T(), err)
+		assert.Empty(s.T(), absentId)
+	})
+}
+
+func (s *TestSuite) TestUserRepo_GetReviewStats() {
+	repo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend'), (2, 'frontend');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 1, true),
+			('u3', 'carol', 1, false),
+			('u4', 'dave', 2, true);
+
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id, created_at) VALUES
+			('pr-1', 'pr-1', 'u1', 1, '2025-01-06 10:00:00+00'),
+			('pr-2', 'pr-2', 'u1', 2, '2025-01-07 10:00:00+00'),
+			('pr-3', 'pr-3', 'u4', 1, '2025-02-03 10:00:00+00');
+
+		INSERT INTO pull_requests_reviewers (pr_id, user_id, assigned_at, decision, decided_at) VALUES
+			('pr-1', 'u2', '2025-01-06 10:00:00+00', NULL, NULL),
+			('pr-2', 'u2', '2025-01-07 10:00:00+00', 'APPROVED', '2025-01-07 12:00:00+00'),
+			('pr-3', 'u2', '2025-02-03 10:00:00+00', 'COMMENTED', '2025-02-03 14:00:00+00');
+
+		INSERT INTO pr_events (pr_id, type, actor, reviewer_id, old_reviewer_id, reason, created_at) VALUES
+			('pr-1', 'reassigned', 'u1', 'u2', 'u3', 'manual', '2025-01-06 11:00:00+00');
+	`)
+	require.NoError(s.T(), err)
+
+	s.Run("all users with zero load", func() {
+		users, err := repo.GetReviewStats(s.ctx, &models.UserStatsFilter{SortBy: "total_reviews", Desc: true})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), users, 4)
+
+		bob := users[0]
+		assert.Equal(s.T(), "u2", bob.UserId)
+		assert.Equal(s.T(), "backend", bob.TeamName)
+		assert.Equal(s.T(), 3, bob.TotalReviews)
+		assert.Equal(s.T(), 2, bob.OpenReviews)
+		//A comment does not complete the review and is not in the average time
+		assert.Equal(s.T(), 1, bob.CompletedReviews)
+		require.NotNil(s.T(), bob.AvgReviewSeconds)
+		assert.InDelta(s.T(), 2*3600, *bob.AvgReviewSeconds, 1)
+
+		//Ties are ordered by user_id
+		assert.Equal(s.T(), []string{"u1", "u3", "u4"}, []string{users[1].UserId, users[2].UserId, users[3].UserId})
+		assert.Equal(s.T(), 2, users[1].AuthoredPr)
+		assert.Nil(s.T(), users[1].AvgReviewSeconds)
+		assert.Equal(s.T(), 1, users[2].ReassignedAway)
+	})
+
+	s.Run("team and period", func() {
+		to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		users, err := repo.GetReviewStats(s.ctx, &models.UserStatsFilter{TeamName: "backend", To: &to, SortBy: "authored_pull_requests", Desc: true})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), users, 3)
+		assert.Equal(s.T(), "u1", users[0].UserId)
+		assert.Equal(s.T(), 2, users[1].TotalReviews)
+		require.NotNil(s.T(), users[1].AvgReviewSeconds)
+		assert.InDelta(s.T(), 2*3600, *users[1].AvgReviewSeconds, 1)
+	})
+
+	s.Run("ascending sort keeps nulls last", func() {
+		users, err := repo.GetReviewStats(s.ctx, &models.UserStatsFilter{SortBy: "avg_review_seconds"})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), users, 4)
+		assert.Equal(s.T(), "u2", users[0].UserId)
+		assert.Nil(s.T(), users[3].AvgReviewSeconds)
+	})
+}
+
+func (s *TestSuite) TestUserRepo_GetReviewStats_Decisions() {
+	repo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true),
+			('u2', 'bob', 1, true),
+			('u3', 'carol', 1, true);
+
+		INSERT INTO pull_requests (pr_id, name, author_id, status_id) VALUES
+			('pr-1', 'pr-1', 'u1', 1),
+			('pr-2', 'pr-2', 'u1', 1);
+
+		INSERT INTO pull_requests_reviewers (pr_id, user_id, assigned_at, decision, decided_at) VALUES
+			('pr-1', 'u2', NOW() - INTERVAL '10 hours', 'COMMENTED', NOW()),
+			('pr-1', 'u3', NOW() - INTERVAL '1 hour', 'APPROVED', NOW()),
+			('pr-2', 'u3', NOW() - INTERVAL '3 hours', 'CHANGES_REQUESTED', NOW());
+	`)
+	require.NoError(s.T(), err)
+
+	users, err := repo.GetReviewStats(s.ctx, &models.UserStatsFilter{SortBy: "completed_reviews", Desc: true})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), users, 3)
+
+	completed := make(map[string]int, len(users))
+	total := make(map[string]int, len(users))
+	for _, user := range users {
+		completed[user.UserId] = user.CompletedReviews
+		total[user.UserId] = user.TotalReviews
+	}
+	//Bob has only commented, the review is still in progress
+	assert.Equal(s.T(), map[string]int{"u1": 0, "u2": 0, "u3": 2}, completed)
+	assert.Equal(s.T(), map[string]int{"u1": 0, "u2": 1, "u3": 2}, total)
+	assert.Equal(s.T(), "u3", users[0].UserId)
+
+	//The average time is of the same completed reviews as the count
+	avg := make(map[string]*float64, len(users))
+	for _, user := range users {
+		avg[user.UserId] = user.AvgReviewSeconds
+	}
+	assert.Nil(s.T(), avg["u2"])
+	require.NotNil(s.T(), avg["u3"])
+	assert.InDelta(s.T(), 2*3600, *avg["u3"], 1)
+}
+
+func (s *TestSuite) TestUserRepo_GetActiveIds() {
+	repo := db.NewUserRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO teams (id, name) VALUES (1, 'backend');
+		INSERT INTO users (user_id, username, team_id, is_active) VALUES
+			('u1', 'alice', 1, true), ('u2', 'bob', 1, false), ('u3', 'carol', 1, true);
+	`)
+	require.NoError(s.T(), err)
+
+	ids, err := repo.GetActiveIds(s.ctx, []string{"u3", "u2", "u1", "ghost"})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"u1", "u3"}, ids)
+
+	ids, err = repo.GetActiveIds(s.ctx, []string{"u2"})
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), ids)
+}
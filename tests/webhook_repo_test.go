@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"github.com/Estriper0/avito_intership/internal/models"
+	"github.com/Estriper0/avito_intership/internal/repository"
+	"github.com/Estriper0/avito_intership/internal/repository/db"
+	trmpgx "github.com/avito-tech/go-transaction-manager/drivers/pgxv5/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *TestSuite) TestWebhookRepo_CRUD() {
+	repo := db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	webhook, err := repo.Create(s.ctx, &models.Webhook{
+		Url:      "http://ci.local/hook",
+		Secret:   "0123456789abcdef",
+		Events:   []string{models.WebhookPrCreated, models.WebhookPrMerged},
+		IsActive: true,
+	})
+	require.NoError(s.T(), err)
+	assert.Greater(s.T(), webhook.Id, 0)
+	assert.Equal(s.T(), []string{models.WebhookPrCreated, models.WebhookPrMerged}, webhook.Events)
+
+	got, err := repo.GetById(s.ctx, webhook.Id)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), webhook.Url, got.Url)
+
+	webhook.Events = []string{models.WebhookTeamCreated}
+	webhook.IsActive = false
+	updated, err := repo.Update(s.ctx, webhook)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{models.WebhookTeamCreated}, updated.Events)
+	assert.False(s.T(), updated.IsActive)
+
+	all, err := repo.GetAll(s.ctx)
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), all, 1)
+
+	err = repo.Delete(s.ctx, webhook.Id)
+	require.NoError(s.T(), err)
+
+	_, err = repo.GetById(s.ctx, webhook.Id)
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+
+	err = repo.Delete(s.ctx, webhook.Id)
+	assert.ErrorIs(s.T(), err, repository.ErrNotFound)
+}
+
+func (s *TestSuite) TestWebhookRepo_GetSubscribed() {
+	repo := db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	_, err := s.db.Exec(s.ctx, `
+		INSERT INTO webhooks (url, secret, events, is_active) VALUES
+			('http://a.local', 's', '{pr.created,pr.merged}', true),
+			('http://b.local', 's', '{pr.created}', false),
+			('http://c.local', 's', '{team.created}', true)
+	`)
+	require.NoError(s.T(), err)
+
+	tests := []struct {
+		name     string
+		event    string
+		wantUrls []string
+	}{
+		{name: "active subscribers only", event: models.WebhookPrCreated, wantUrls: []string{"http://a.local"}},
+		{name: "other event", event: models.WebhookTeamCreated, wantUrls: []string{"http://c.local"}},
+		{name: "no subscribers", event: models.WebhookUserDeactivated},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			webhooks, err := repo.GetSubscribed(s.ctx, tt.event)
+			require.NoError(s.T(), err)
+
+			var urls []string
+			for _, webhook := range webhooks {
+				urls = append(urls, webhook.Url)
+			}
+			assert.Equal(s.T(), tt.wantUrls, urls)
+		})
+	}
+}
+
+func (s *TestSuite) TestWebhookRepo_Deliveries() {
+	repo := db.NewWebhookRepo(s.db, trmpgx.DefaultCtxGetter)
+
+	webhook, err := repo.Create(s.ctx, &models.Webhook{Url: "http://a.local", Secret: "s", Events: []string{models.WebhookPrCreated}, IsActive: true})
+	require.NoError(s.T(), err)
+
+	jobId := uuid.NewString()
+	err = repo.AddDelivery(s.ctx, &models.WebhookDelivery{WebhookId: webhook.Id, JobId: jobId, Event: models.WebhookPrCreated, Attempt: 1, Error: "connection refused", DurationMs: 3})
+	require.NoError(s.T(), err)
+	err = repo.AddDelivery(s.ctx, &models.WebhookDelivery{WebhookId: webhook.Id, JobId: jobId, Event: models.WebhookPrCreated, Attempt: 2, StatusCode: 200, DurationMs: 5})
+	require.NoError(s.T(), err)
+
+	deliveries, err := repo.GetDeliveries(s.ctx, webhook.Id, 10)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), deliveries, 2)
+
+	//Newest attempt first
+	assert.Equal(s.T(), 2, deliveries[0].Attempt)
+	assert.Equal(s.T(), 200, deliveries[0].StatusCode)
+	assert.Empty(s.T(), deliveries[0].Error)
+	assert.Equal(s.T(), 1, deliveries[1].Attempt)
+	assert.Equal(s.T(), 0, deliveries[1].StatusCode)
+	assert.Equal(s.T(), "connection refused", deliveries[1].Error)
+	assert.Equal(s.T(), jobId, deliveries[1].JobId)
+
+	deliveries, err = repo.GetDeliveries(s.ctx, webhook.Id, 1)
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), deliveries, 1)
+}